@@ -0,0 +1,91 @@
+// Command auditverify walks the audit log chain and reports the first tampered entry.
+//
+// Exit code is 0 if the chain is intact, 1 if it is tampered and 2 if it can't be checked.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"os"
+
+	"github.com/aspirin100/finapi/internal/audit"
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/repository"
+)
+
+const (
+	exitTampered = 1
+	exitFailed   = 2
+)
+
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+	var (
+		postgresDSN string
+		expectHead  string
+	)
+
+	flag.StringVar(&postgresDSN, "dsn", os.Getenv("FINAPI_POSTGRES_DSN"), "URL to postgres")
+	flag.StringVar(&expectHead, "expect-head", "",
+		"hash of the last entry known from a previous run, detects removal of the log tail")
+
+	flag.Parse()
+
+	if postgresDSN == "" {
+		flag.Usage()
+
+		return exitFailed
+	}
+
+	ctx := context.Background()
+
+	repo, err := repository.NewConnection(ctx, postgresDSN)
+	if err != nil {
+		slog.Error("failed to connect to postgres", slog.Any("error", err))
+
+		return exitFailed
+	}
+	defer repo.DB.Close()
+
+	var (
+		verifier  audit.Verifier
+		headFound = expectHead == ""
+	)
+
+	err = repo.WalkAuditLog(ctx, func(entry entity.AuditEntry) error {
+		err := verifier.Check(entry)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+
+		if entry.Hash == expectHead {
+			headFound = true
+		}
+
+		return nil
+	})
+
+	switch {
+	case errors.Is(err, audit.ErrBrokenChain), errors.Is(err, audit.ErrHashMismatch):
+		slog.Error("audit log is tampered", slog.Any("error", err), slog.Int("intact_entries", verifier.Entries()))
+
+		return exitTampered
+	case err != nil:
+		slog.Error("failed to verify audit log", slog.Any("error", err))
+
+		return exitFailed
+	case !headFound:
+		slog.Error("audit log is tampered: expected head entry is missing", slog.String("expected_head", expectHead))
+
+		return exitTampered
+	}
+
+	slog.Info("audit log is intact", slog.Int("entries", verifier.Entries()), slog.String("head", verifier.Head()))
+
+	return 0
+}
@@ -0,0 +1,67 @@
+// Command blocklist imports the sanctions blocklist from a CSV or JSON file.
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+
+	"github.com/aspirin100/finapi/internal/audit"
+	"github.com/aspirin100/finapi/internal/repository"
+	"github.com/aspirin100/finapi/internal/screening"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+	var (
+		postgresDSN string
+		path        string
+		format      string
+		replace     bool
+	)
+
+	flag.StringVar(&postgresDSN, "dsn", os.Getenv("FINAPI_POSTGRES_DSN"), "URL to postgres")
+	flag.StringVar(&path, "file", "", "path to the blocklist file")
+	flag.StringVar(&format, "format", "", "blocklist format: csv or json, detected by the file extension if empty")
+	flag.BoolVar(&replace, "replace", false, "remove parties missing in the file from the blocklist")
+
+	flag.Parse()
+
+	if postgresDSN == "" || path == "" {
+		flag.Usage()
+
+		return 2 //nolint:mnd
+	}
+
+	parties, err := screening.ReadFile(path, format)
+	if err != nil {
+		slog.Error("failed to read blocklist", slog.Any("error", err))
+
+		return 1
+	}
+
+	ctx := audit.WithActor(context.Background(), audit.ActorSystem)
+
+	repo, err := repository.NewConnection(ctx, postgresDSN)
+	if err != nil {
+		slog.Error("failed to connect to postgres", slog.Any("error", err))
+
+		return 1
+	}
+	defer repo.DB.Close()
+
+	err = repo.ImportBlockedParties(ctx, parties, replace)
+	if err != nil {
+		slog.Error("failed to import blocklist", slog.Any("error", err))
+
+		return 1
+	}
+
+	slog.Info("blocklist imported", slog.Int("parties", len(parties)), slog.Bool("replace", replace))
+
+	return 0
+}
@@ -2,43 +2,154 @@ package main
 
 import (
 	"context"
-	"fmt"
-	"log"
+	"errors"
+	"flag"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
 
+	"github.com/gin-gonic/gin"
+
 	"github.com/aspirin100/finapi/internal/app"
 	"github.com/aspirin100/finapi/internal/config"
+	"github.com/aspirin100/finapi/internal/logger"
 )
 
 func main() {
-	cfg, err := config.Load()
+	os.Exit(run())
+}
+
+func run() int {
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+
+	if err != nil {
+		slog.Error("failed to load config", slog.Any("error", err))
+
+		return 1
+	}
+
+	if cfg.PrintConfig {
+		err = cfg.Print(os.Stdout)
+		if err != nil {
+			slog.Error("failed to print config", slog.Any("error", err))
+
+			return 1
+		}
+
+		return 0
+	}
+
+	// the level is changed on SIGHUP, see reload
+	level := new(slog.LevelVar)
+
+	lvl, err := logger.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		slog.Error("failed to create logger", slog.Any("error", err))
+
+		return 1
+	}
+
+	level.Set(lvl)
+
+	log, err := logger.NewWithLevel(os.Stdout, level, cfg.LogFormat)
 	if err != nil {
-		log.Fatal(err)
+		slog.Error("failed to create logger", slog.Any("error", err))
+
+		return 1
+	}
+
+	slog.SetDefault(log)
+
+	if cfg.LogLevel != "debug" {
+		gin.SetMode(gin.ReleaseMode)
 	}
 
-	fmt.Println("current config:", cfg)
+	log.Info("current config", slog.Any("config", cfg))
+
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+
+	defer signal.Stop(hangup)
 
 	application, err := app.New(context.Background(), cfg)
 	if err != nil {
-		log.Fatal(err)
+		log.Error("failed to create application", slog.Any("error", err))
+
+		return 1
+	}
+
+	serverErrs := application.Run()
+
+	stop, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer cancel()
+
+	exitCode := 0
+
+wait:
+	for {
+		select {
+		case <-hangup:
+			reload(log, level, application)
+		case <-stop.Done():
+			log.Info("shutting down", slog.Duration("timeout", cfg.ShutdownTimeout))
+
+			break wait
+		case err = <-serverErrs:
+			log.Error("server stopped unexpectedly", slog.Any("error", err))
+
+			exitCode = 1
+
+			break wait
+		}
+	}
+
+	// restore default behavior, so the second signal kills the process immediately
+	cancel()
+
+	ctx, cancelShutdown := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancelShutdown()
+
+	err = application.Stop(ctx)
+	if err != nil {
+		log.Error("failed to stop application", slog.Any("error", err))
+
+		return 1
 	}
 
-	err = application.Run()
+	log.Info("server correctly stopped")
+
+	return exitCode
+}
+
+// reload reads the config again and applies its log level and rate limits,
+// other settings take effect after restart. The running config is kept if the new one is invalid.
+func reload(log *slog.Logger, level *slog.LevelVar, application *app.App) {
+	cfg, err := config.Load(os.Args[1:])
 	if err != nil {
-		log.Fatal(err)
+		log.Error("failed to reload config", slog.Any("error", err))
+
+		return
 	}
 
-	stop := make(chan os.Signal, 1)
-	signal.Notify(stop, syscall.SIGTERM, syscall.SIGINT)
+	lvl, err := logger.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		log.Error("failed to reload config", slog.Any("error", err))
 
-	<-stop
+		return
+	}
 
-	err = application.Stop(context.Background())
+	err = application.Reload(cfg)
 	if err != nil {
-		log.Fatal(err)
+		log.Error("failed to reload config", slog.Any("error", err))
+
+		return
 	}
 
-	log.Println("server correctly stopped")
+	level.Set(lvl)
+
+	log.Info("config reloaded", slog.String("log_level", cfg.LogLevel))
 }
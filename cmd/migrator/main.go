@@ -1,77 +1,474 @@
+// Command migrator applies and inspects the postgres migrations.
+//
+//	migrator [flags] <command> [args]
+//
+// Commands:
+//
+//	up              apply all pending migrations
+//	up-to VERSION   apply pending migrations up to VERSION
+//	down            roll back the latest applied migration
+//	redo            roll back and apply again the latest applied migration
+//	status          print applied and pending migrations
+//	version         print the schema version of the database
+//	create NAME     create an empty migration in --dir and its mirror in --sqlite-dir
+//	validate        check annotations and versions of the migrations and that sqlite mirrors postgres
+//
+// Migrations embedded into the binary are used unless --dir is set. Concurrent migrators wait
+// for each other on a postgres advisory lock. Exit code is 0 on success, 1 if the command failed,
+// 2 on invalid usage and 3 if postgres is unreachable.
 package main
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"flag"
-	"log"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"strconv"
+	"text/tabwriter"
 	"time"
 
-	_ "github.com/lib/pq"
+	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/pressly/goose/v3"
+
+	"github.com/aspirin100/finapi/internal/migrator"
+	"github.com/aspirin100/finapi/internal/repository/migrations"
+	sqlitemigrations "github.com/aspirin100/finapi/internal/repository/sqlite/migrations"
 )
 
 const (
-	RetriesCount = 5
-	RetriesWait  = time.Second * 1
+	exitFailed      = 1
+	exitUsage       = 2
+	exitUnreachable = 3
+)
+
+const (
+	defaultRetries     = 5
+	defaultRetryWait   = time.Second
+	defaultLockTimeout = 5 * time.Minute
+
+	// create needs directories of the sources, the paths are relative to the repository root
+	defaultDir       = "internal/repository/migrations"
+	defaultSQLiteDir = "internal/repository/sqlite/migrations"
 )
 
+var (
+	errUsage         = errors.New("invalid usage")
+	errNothingToRedo = errors.New("no applied migration to redo")
+)
+
+type options struct {
+	postgresDSN string
+	dir         string
+	sqliteDir   string
+	dryRun      bool
+	retries     int
+	retryWait   time.Duration
+	lockTimeout time.Duration
+}
+
 func main() {
-	var migrationsPath string
-	var postgresDSN string
+	os.Exit(run())
+}
 
-	flag.StringVar(&migrationsPath, "migrations-path", "", "path to migrations")
-	flag.StringVar(&postgresDSN, "dsn", "", "URL to postgres for up migrations")
+func run() int {
+	var opts options
+
+	flag.StringVar(&opts.postgresDSN, "dsn", os.Getenv("FINAPI_POSTGRES_DSN"), "URL to postgres")
+	flag.StringVar(&opts.dir, "dir", "", "directory of postgres migrations, the embedded ones are used if empty")
+	flag.StringVar(&opts.sqliteDir, "sqlite-dir", "",
+		"directory of sqlite migrations checked by validate, the embedded ones are used if empty")
+	flag.BoolVar(&opts.dryRun, "dry-run", false, "print the SQL of the migrations instead of applying it")
+	flag.IntVar(&opts.retries, "retries", defaultRetries, "number of retries to reach postgres")
+	flag.DurationVar(&opts.retryWait, "retry-wait", defaultRetryWait, "wait between retries to reach postgres")
+	flag.DurationVar(&opts.lockTimeout, "lock-timeout", defaultLockTimeout,
+		"max wait for concurrent migrators to finish")
+
+	flag.Usage = usage
 
 	flag.Parse()
-	validateFlags(migrationsPath, postgresDSN)
+
+	if flag.NArg() == 0 || opts.retries < 0 {
+		flag.Usage()
+
+		return exitUsage
+	}
+
+	command, args := flag.Arg(0), flag.Args()[1:]
 
 	var err error
 
-	var db *sql.DB
+	switch command {
+	case "create":
+		err = create(args, opts)
+	case "validate":
+		err = validate(opts)
+	case "up", "up-to", "down", "redo", "status", "version":
+		return migrate(command, args, opts)
+	default:
+		err = fmt.Errorf("%w: unknown command %q", errUsage, command)
+	}
+
+	return exitCode(err)
+}
+
+func usage() {
+	fmt.Fprintln(flag.CommandLine.Output(),
+		"usage: migrator [flags] up|up-to VERSION|down|redo|status|version|create NAME|validate")
+	flag.PrintDefaults()
+}
+
+func exitCode(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, errUsage):
+		slog.Error(err.Error())
+		flag.Usage()
+
+		return exitUsage
+	default:
+		slog.Error("migrator failed", slog.Any("error", err))
+
+		return exitFailed
+	}
+}
+
+// migrate runs the commands needing postgres.
+func migrate(command string, args []string, opts options) int {
+	var target int64
+
+	switch {
+	case opts.postgresDSN == "":
+		return exitCode(fmt.Errorf("%w: empty dsn", errUsage))
+	case command == "up-to" && len(args) != 1:
+		return exitCode(fmt.Errorf("%w: up-to needs the target version", errUsage))
+	case command == "up-to":
+		var err error
+
+		target, err = strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return exitCode(fmt.Errorf("%w: invalid version %q", errUsage, args[0]))
+		}
+	case len(args) != 0:
+		return exitCode(fmt.Errorf("%w: %s takes no arguments", errUsage, command))
+	}
+
+	ctx := context.Background()
+
+	db, err := connect(ctx, opts)
+	if err != nil {
+		slog.Error("postgres is unreachable", slog.Any("error", err))
+
+		return exitUnreachable
+	}
+	defer db.Close()
+
+	fsys := sources(opts.dir, migrations.Migrations)
+
+	provider, err := migrator.NewProvider(db, fsys, opts.lockTimeout)
+	if err != nil {
+		return exitCode(err)
+	}
+
+	m := &runner{
+		provider:    provider,
+		db:          db,
+		fsys:        fsys,
+		dryRun:      opts.dryRun,
+		lockTimeout: opts.lockTimeout,
+	}
+
+	switch command {
+	case "up":
+		err = m.upTo(ctx, goose.MaxVersion)
+	case "up-to":
+		err = m.upTo(ctx, target)
+	case "down":
+		err = m.down(ctx)
+	case "redo":
+		err = m.redo(ctx)
+	case "status":
+		err = m.status(ctx)
+	case "version":
+		err = m.version(ctx)
+	}
+
+	return exitCode(err)
+}
+
+// connect opens the database and waits until postgres answers.
+func connect(ctx context.Context, opts options) (*sql.DB, error) {
+	db, err := sql.Open("pgx", opts.postgresDSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open postgres: %w", err)
+	}
+
+	for attempt := 0; ; attempt++ {
+		err = db.PingContext(ctx)
+		if err == nil {
+			return db, nil
+		}
+
+		if attempt >= opts.retries {
+			db.Close()
+
+			return nil, fmt.Errorf("failed to connect to postgres: %w", err)
+		}
+
+		slog.Warn("postgres is unreachable, retrying",
+			slog.Int("attempt", attempt+1),
+			slog.Any("error", err))
+
+		time.Sleep(opts.retryWait)
+	}
+}
+
+func sources(dir string, embedded fs.FS) fs.FS {
+	if dir == "" {
+		return embedded
+	}
+
+	return os.DirFS(dir)
+}
+
+type runner struct {
+	provider    *goose.Provider
+	db          *sql.DB
+	fsys        fs.FS
+	dryRun      bool
+	lockTimeout time.Duration
+}
+
+func (m *runner) upTo(ctx context.Context, target int64) error {
+	if m.dryRun {
+		statuses, err := m.provider.Status(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get status: %w", err)
+		}
+
+		for _, status := range statuses {
+			if status.State == goose.StatePending && status.Source.Version <= target {
+				err = m.print(status.Source.Version, goose.StatePending)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}
+
+	results, err := m.provider.UpTo(ctx, target)
+	printResults(results)
+
+	if err != nil {
+		return fmt.Errorf("failed to migrate up: %w", err)
+	}
+
+	if len(results) == 0 {
+		slog.Info("no pending migrations")
+	}
 
-	for i := 0; i < RetriesCount; {
-		db, err = sql.Open("postgres", postgresDSN)
+	return nil
+}
+
+func (m *runner) down(ctx context.Context) error {
+	if m.dryRun {
+		current, err := m.provider.GetDBVersion(ctx)
 		if err != nil {
-			time.Sleep(RetriesWait)
-			i++
-		} else {
-			break
+			return fmt.Errorf("failed to get schema version: %w", err)
 		}
 
-		if i == RetriesCount-1 {
-			log.Fatal("failed to connect", err)
+		return m.print(current, goose.StateApplied)
+	}
+
+	result, err := m.provider.Down(ctx)
+	if result != nil {
+		printResults([]*goose.MigrationResult{result})
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to migrate down: %w", err)
+	}
+
+	return nil
+}
+
+// redo rolls back and applies again the latest migration holding the lock across both steps,
+// so another migrator can't apply migrations in between.
+func (m *runner) redo(ctx context.Context) error {
+	if m.dryRun {
+		current, err := redoVersion(ctx, m.provider)
+		if err != nil {
+			return err
 		}
+
+		err = m.print(current, goose.StateApplied)
+		if err != nil {
+			return err
+		}
+
+		return m.print(current, goose.StatePending)
 	}
 
-	for i := 0; i < RetriesCount; i++ {
-		err = goose.Up(db, migrationsPath)
+	return migrator.Locked(ctx, m.db, m.fsys, m.lockTimeout, func(ctx context.Context, provider *goose.Provider) error {
+		current, err := redoVersion(ctx, provider)
 		if err != nil {
-			if errors.Is(err, goose.ErrAlreadyApplied) {
-				log.Print("no new migrations")
-			} else {
-				log.Print("failed to migrate: ", err)
+			return err
+		}
+
+		for _, up := range []bool{false, true} {
+			result, err := provider.ApplyVersion(ctx, current, up)
+			if result != nil {
+				printResults([]*goose.MigrationResult{result})
 			}
 
-			time.Sleep(RetriesWait)
-			i++
-		} else {
-			break
+			if err != nil {
+				return fmt.Errorf("failed to redo migration %d: %w", current, err)
+			}
 		}
 
-		if i == RetriesCount-1 {
-			log.Fatal(err)
+		return nil
+	})
+}
+
+// redoVersion returns the latest applied migration, errNothingToRedo if none is applied.
+func redoVersion(ctx context.Context, provider *goose.Provider) (int64, error) {
+	current, err := provider.GetDBVersion(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get schema version: %w", err)
+	}
+
+	if current == 0 {
+		return 0, errNothingToRedo
+	}
+
+	return current, nil
+}
+
+func (m *runner) status(ctx context.Context) error {
+	statuses, err := m.provider.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get status: %w", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0) //nolint:mnd
+
+	fmt.Fprintln(w, "VERSION\tSTATE\tAPPLIED AT\tFILE")
+
+	for _, status := range statuses {
+		appliedAt := "-"
+		if status.State == goose.StateApplied {
+			appliedAt = status.AppliedAt.UTC().Format(time.RFC3339)
 		}
+
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", status.Source.Version, status.State, appliedAt, status.Source.Path)
 	}
 
+	err = w.Flush()
+	if err != nil {
+		return fmt.Errorf("failed to print status: %w", err)
+	}
+
+	return nil
 }
 
-func validateFlags(migrationsPath, DSN string) {
-	if migrationsPath == "" {
-		panic("migrations path should be not empty")
+func (m *runner) version(ctx context.Context) error {
+	current, err := m.provider.GetDBVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get schema version: %w", err)
 	}
 
-	if DSN == "" {
-		panic("empty DSN flag")
+	fmt.Println(current) //nolint:forbidigo
+
+	return nil
+}
+
+// print prints the SQL applying the migration if it is pending or rolling it back if it is applied.
+func (m *runner) print(version int64, state goose.State) error {
+	all, err := migrator.Load(m.fsys)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	migration, err := migrator.Find(all, version)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	direction, query := "up", migration.Up
+	if state == goose.StateApplied {
+		direction, query = "down", migration.Down
+	}
+
+	fmt.Printf("-- %s %s\n%s\n\n", direction, migration.File, query) //nolint:forbidigo
+
+	return nil
+}
+
+func printResults(results []*goose.MigrationResult) {
+	for _, result := range results {
+		if result.Error != nil {
+			slog.Error("migration failed", slog.String("result", result.String()), slog.Any("error", result.Error))
+
+			continue
+		}
+
+		slog.Info("migration applied", slog.String("result", result.String()))
+	}
+}
+
+func create(args []string, opts options) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: create needs the migration name", errUsage)
+	}
+
+	dir, sqliteDir := opts.dir, opts.sqliteDir
+	if dir == "" {
+		dir = defaultDir
 	}
+
+	if sqliteDir == "" {
+		sqliteDir = defaultSQLiteDir
+	}
+
+	paths, err := migrator.Create(args[0], time.Now(), dir, sqliteDir)
+	if errors.Is(err, migrator.ErrInvalidName) {
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	for _, path := range paths {
+		slog.Info("migration created", slog.String("path", path))
+	}
+
+	return nil
+}
+
+func validate(opts options) error {
+	postgres, err := migrator.Load(sources(opts.dir, migrations.Migrations))
+	if err != nil {
+		return fmt.Errorf("postgres: %w", err)
+	}
+
+	sqlite, err := migrator.Load(sources(opts.sqliteDir, sqlitemigrations.Migrations))
+	if err != nil {
+		return fmt.Errorf("sqlite: %w", err)
+	}
+
+	err = migrator.Mirrored(postgres, sqlite)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	slog.Info("migrations are valid", slog.Int("postgres", len(postgres)), slog.Int("sqlite", len(sqlite)))
+
+	return nil
 }
@@ -0,0 +1,215 @@
+// Command partitions maintains the monthly partitions of transactions.
+//
+//	partitions maintain -ahead 3 -keep 12 -archive-dir ./archive
+//
+// creates partitions for the coming months and archives partitions older than the kept months
+// to compressed NDJSON files, it is meant to run daily.
+//
+//	partitions history -user <id> -from 2024-01-01 -to 2024-02-01 -archive-dir ./archive
+//
+// prints transactions of the user in the range as NDJSON, archived ones included.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aspirin100/finapi/internal/repository"
+)
+
+const (
+	exitFailed = 1
+	exitUsage  = 2
+)
+
+const (
+	defaultAhead = 3
+	defaultKeep  = 12
+	dateLayout   = "2006-01-02"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	if len(args) == 0 {
+		usage()
+
+		return exitUsage
+	}
+
+	switch args[0] {
+	case "maintain":
+		return maintain(args[1:])
+	case "history":
+		return history(args[1:])
+	default:
+		usage()
+
+		return exitUsage
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: partitions maintain|history [flags]")
+}
+
+func maintain(args []string) int {
+	var (
+		postgresDSN string
+		archiveDir  string
+		ahead       int
+		keep        int
+	)
+
+	flags := flag.NewFlagSet("maintain", flag.ContinueOnError)
+	flags.StringVar(&postgresDSN, "dsn", os.Getenv("FINAPI_POSTGRES_DSN"), "URL to postgres")
+	flags.StringVar(&archiveDir, "archive-dir", "", "directory of the archive files")
+	flags.IntVar(&ahead, "ahead", defaultAhead, "number of months to create partitions for after the current one")
+	flags.IntVar(&keep, "keep", defaultKeep,
+		"number of months before the current one kept in postgres, older partitions are archived, 0 keeps all")
+
+	err := flags.Parse(args)
+	if err != nil || postgresDSN == "" || ahead < 0 || keep < 0 || (keep > 0 && archiveDir == "") {
+		flags.Usage()
+
+		return exitUsage
+	}
+
+	ctx := context.Background()
+
+	repo, err := repository.NewConnection(ctx, postgresDSN)
+	if err != nil {
+		slog.Error("failed to connect to postgres", slog.Any("error", err))
+
+		return exitFailed
+	}
+	defer repo.Close()
+
+	current := repository.MonthPartition(time.Now())
+
+	for month := range ahead + 1 {
+		partition := current.AddMonths(month)
+
+		err = repo.CreatePartition(ctx, partition)
+		if errors.Is(err, repository.ErrPartitionExists) {
+			continue
+		}
+
+		if err != nil {
+			slog.Error("failed to create partition", slog.Any("error", err))
+
+			return exitFailed
+		}
+
+		slog.Info("partition created", slog.String("partition", partition.Name))
+	}
+
+	if keep == 0 {
+		return 0
+	}
+
+	partitions, err := repo.Partitions(ctx)
+	if err != nil {
+		slog.Error("failed to list partitions", slog.Any("error", err))
+
+		return exitFailed
+	}
+
+	cutoff := current.AddMonths(-keep).Start
+
+	for _, partition := range partitions {
+		if partition.End.After(cutoff) {
+			break
+		}
+
+		archive, err := repo.ArchivePartition(ctx, partition, archiveDir)
+		if err != nil {
+			slog.Error("failed to archive partition", slog.Any("error", err))
+
+			return exitFailed
+		}
+
+		slog.Info("partition archived",
+			slog.String("partition", archive.Name),
+			slog.String("file", archive.File),
+			slog.Int64("rows", archive.Rows))
+	}
+
+	return 0
+}
+
+func history(args []string) int {
+	var (
+		postgresDSN string
+		archiveDir  string
+		user        string
+		from        string
+		to          string
+	)
+
+	flags := flag.NewFlagSet("history", flag.ContinueOnError)
+	flags.StringVar(&postgresDSN, "dsn", os.Getenv("FINAPI_POSTGRES_DSN"), "URL to postgres")
+	flags.StringVar(&archiveDir, "archive-dir", "", "directory of the archive files, archived transactions are skipped if empty")
+	flags.StringVar(&user, "user", "", "id of the user")
+	flags.StringVar(&from, "from", "", "first day of the range, YYYY-MM-DD in UTC")
+	flags.StringVar(&to, "to", "", "day after the range, YYYY-MM-DD in UTC")
+
+	err := flags.Parse(args)
+	if err != nil || postgresDSN == "" {
+		flags.Usage()
+
+		return exitUsage
+	}
+
+	userID, errUser := uuid.Parse(user)
+	fromDate, errFrom := time.Parse(dateLayout, from)
+	toDate, errTo := time.Parse(dateLayout, to)
+
+	err = errors.Join(errUser, errFrom, errTo)
+	if err != nil {
+		slog.Error("invalid flags", slog.Any("error", err))
+		flags.Usage()
+
+		return exitUsage
+	}
+
+	ctx := context.Background()
+
+	repo, err := repository.NewConnection(ctx, postgresDSN)
+	if err != nil {
+		slog.Error("failed to connect to postgres", slog.Any("error", err))
+
+		return exitFailed
+	}
+	defer repo.Close()
+
+	transactions, err := repo.GetTransactionsBetween(ctx, userID, fromDate, toDate, archiveDir)
+	if err != nil {
+		slog.Error("failed to get transactions", slog.Any("error", err))
+
+		return exitFailed
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+
+	for _, transaction := range transactions {
+		err = encoder.Encode(transaction)
+		if err != nil {
+			slog.Error("failed to print transaction", slog.Any("error", err))
+
+			return exitFailed
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,172 @@
+// Command seed loads fixture accounts and transactions and generates synthetic fixtures.
+//
+//	seed load -env development configs/fixtures/dev.yml
+//
+// loads the YAML or JSON fixtures through the service. The environment is required, -env or FINAPI_ENV,
+// a fixture is refused unless it lists it, development and test are allowed by default.
+//
+//	seed generate -accounts 1000 -transactions 100000 -out load.json
+//
+// writes a fixture of random accounts and transactions for load testing.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/aspirin100/finapi/internal/audit"
+	"github.com/aspirin100/finapi/internal/repository"
+	"github.com/aspirin100/finapi/internal/seed"
+	"github.com/aspirin100/finapi/internal/service"
+)
+
+const (
+	exitFailed = 1
+	exitUsage  = 2
+)
+
+const (
+	defaultAccounts     = 100
+	defaultTransactions = 1000
+	defaultTimeout      = 5 * time.Second
+)
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	if len(args) == 0 {
+		usage()
+
+		return exitUsage
+	}
+
+	switch args[0] {
+	case "load":
+		return load(args[1:])
+	case "generate":
+		return generate(args[1:])
+	default:
+		usage()
+
+		return exitUsage
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: seed load|generate [flags]")
+}
+
+func load(args []string) int {
+	var (
+		postgresDSN string
+		env         string
+	)
+
+	flags := flag.NewFlagSet("load", flag.ContinueOnError)
+	flags.StringVar(&postgresDSN, "dsn", os.Getenv("FINAPI_POSTGRES_DSN"), "URL to postgres")
+	flags.StringVar(&env, "env", os.Getenv("FINAPI_ENV"),
+		"environment, required, fixtures not listing it are refused")
+
+	err := flags.Parse(args)
+	if err != nil || postgresDSN == "" || env == "" || flags.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: seed load [flags] FILE...")
+		flags.PrintDefaults()
+
+		return exitUsage
+	}
+
+	fixtures := make([]*seed.Fixture, 0, flags.NArg())
+
+	for _, path := range flags.Args() {
+		fixture, err := seed.ReadFile(path)
+		if err != nil {
+			slog.Error("failed to read fixture", slog.String("path", path), slog.Any("error", err))
+
+			return exitFailed
+		}
+
+		if !fixture.Allowed(env) {
+			slog.Error("fixture isn't allowed in the environment", slog.String("path", path), slog.String("env", env))
+
+			return exitFailed
+		}
+
+		fixtures = append(fixtures, fixture)
+	}
+
+	ctx := audit.WithActor(context.Background(), audit.ActorSystem)
+
+	repo, err := repository.NewConnection(ctx, postgresDSN)
+	if err != nil {
+		slog.Error("failed to connect to postgres", slog.Any("error", err))
+
+		return exitFailed
+	}
+	defer repo.Close()
+
+	srvc := service.New(defaultTimeout, repo)
+
+	for i, fixture := range fixtures {
+		stats, err := seed.Load(ctx, srvc, env, fixture)
+		if err != nil {
+			slog.Error("failed to load fixture", slog.String("path", flags.Arg(i)), slog.Any("error", err))
+
+			return exitFailed
+		}
+
+		slog.Info("fixture loaded",
+			slog.String("path", flags.Arg(i)),
+			slog.Int("accounts", stats.Accounts),
+			slog.Int("skipped_accounts", stats.SkippedAccounts),
+			slog.Int("transactions", stats.Transactions),
+			slog.Int("skipped_transactions", stats.SkippedTransactions))
+	}
+
+	return 0
+}
+
+func generate(args []string) int {
+	var (
+		accounts     int
+		transactions int
+		randSeed     int64
+		out          string
+	)
+
+	flags := flag.NewFlagSet("generate", flag.ContinueOnError)
+	flags.IntVar(&accounts, "accounts", defaultAccounts, "number of accounts")
+	flags.IntVar(&transactions, "transactions", defaultTransactions, "number of transactions")
+	flags.Int64Var(&randSeed, "seed", time.Now().UnixNano(), "random seed, the same seed generates the same fixture")
+	flags.StringVar(&out, "out", "", "path of the fixture, JSON if it has the .json extension and YAML otherwise")
+
+	err := flags.Parse(args)
+	if err != nil || out == "" || accounts < 1 || transactions < 0 {
+		flags.Usage()
+
+		return exitUsage
+	}
+
+	fixture := seed.Generate(rand.New(rand.NewSource(randSeed)), accounts, transactions) //nolint:gosec
+
+	err = seed.WriteFile(out, fixture)
+	if err != nil {
+		slog.Error("failed to write fixture", slog.Any("error", err))
+
+		return exitFailed
+	}
+
+	slog.Info("fixture generated",
+		slog.String("path", out),
+		slog.Int("accounts", accounts),
+		slog.Int("transactions", transactions),
+		slog.Int64("seed", randSeed))
+
+	return 0
+}
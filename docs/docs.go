@@ -0,0 +1,30 @@
+// Package docs embeds the API specification into the binary.
+package docs
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// OpenAPI is the OpenAPI specification of the http API.
+//
+//go:embed openapi_v1.yml
+var OpenAPI []byte
+
+// LoadOpenAPI parses the embedded specification and checks it is valid.
+func LoadOpenAPI() (*openapi3.T, error) {
+	spec, err := openapi3.NewLoader().LoadFromData(OpenAPI)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load openapi specification: %w", err)
+	}
+
+	err = spec.Validate(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("invalid openapi specification: %w", err)
+	}
+
+	return spec, nil
+}
@@ -0,0 +1,15 @@
+package docs_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/aspirin100/finapi/docs"
+)
+
+func TestLoadOpenAPI(t *testing.T) {
+	spec, err := docs.LoadOpenAPI()
+	require.NoError(t, err)
+	require.NotNil(t, spec.Paths.Value("/v1/transfers"))
+}
@@ -2,51 +2,596 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
 
+	"github.com/aspirin100/finapi/docs"
+	"github.com/aspirin100/finapi/internal/breaker"
 	"github.com/aspirin100/finapi/internal/config"
+	"github.com/aspirin100/finapi/internal/events"
+	"github.com/aspirin100/finapi/internal/fee"
+	"github.com/aspirin100/finapi/internal/grpcserver"
 	"github.com/aspirin100/finapi/internal/handler"
+	"github.com/aspirin100/finapi/internal/health"
+	"github.com/aspirin100/finapi/internal/interest"
+	"github.com/aspirin100/finapi/internal/logger"
+	"github.com/aspirin100/finapi/internal/metrics"
+	"github.com/aspirin100/finapi/internal/operator"
+	"github.com/aspirin100/finapi/internal/proxy"
+	"github.com/aspirin100/finapi/internal/ratelimit"
 	"github.com/aspirin100/finapi/internal/repository"
+	"github.com/aspirin100/finapi/internal/repository/memory"
+	"github.com/aspirin100/finapi/internal/repository/migrations"
+	"github.com/aspirin100/finapi/internal/repository/sqlite"
+	"github.com/aspirin100/finapi/internal/risk"
+	"github.com/aspirin100/finapi/internal/screening"
 	"github.com/aspirin100/finapi/internal/service"
+	"github.com/aspirin100/finapi/internal/tracing"
 )
 
+// Stores of accounts and transactions.
+const (
+	StorePostgres = "postgres"
+	// StoreSQLite is for single node and embedded deployments, the database file is migrated on start.
+	StoreSQLite = "sqlite"
+	// StoreMemory is for tests and local demos, its data is lost on exit.
+	StoreMemory = "memory"
+)
+
+var (
+	ErrUnknownStore     = errors.New("unknown store")
+	ErrPostgresRequired = errors.New("postgres store is required")
+	ErrSchemaVersion    = errors.New("schema version differs from the binary")
+	ErrRestartRequired  = errors.New("setting can't change without restart")
+)
+
+// store keeps accounts and transactions.
+type store interface {
+	service.UserManager
+	risk.History
+	health.Database
+	WaitTx(ctx context.Context) error
+	Close()
+}
+
 type App struct {
 	requestHandler *handler.Handler
+	grpcServer     *grpcserver.Server
+	broker         *events.Broker
+	stopWorkers    context.CancelFunc
+	workers        sync.WaitGroup
+	store          store
 	repo           *repository.Repository
+	tracerProvider *tracing.Provider
+	readiness      *health.Checker
+	limiter        *ratelimit.Limiter
+	rateLimitStore string
+	bucketsStore   *ratelimit.PostgresStore
+	screener       *screening.Screener
+	reloadInterval time.Duration
+	srvc           *service.Service
+	payoutInterval time.Duration
+	drainDelay     time.Duration
+	secretRefresh  time.Duration
 }
 
-func New(ctx context.Context, cfg *config.Config) (*App, error) {
-	repo, err := repository.NewConnection(ctx, cfg.PostgresDSN)
+const (
+	// bucketsPruneInterval is how often full rate limit buckets are removed from postgres.
+	bucketsPruneInterval = time.Minute
+	// replicaCheckInterval is how often read replicas are pinged.
+	replicaCheckInterval = 5 * time.Second
+)
+
+func New(ctx context.Context, cfg *config.Config) (_ *App, err error) {
+	tracerProvider, err := tracing.Setup(cfg.TraceExporter, os.Stdout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create app instance: %w", err)
+	}
+
+	// the app isn't returned on error, so nobody else shuts the provider down
+	defer func() {
+		if err == nil {
+			return
+		}
+
+		// ctx may be already done, e.g. if connecting to the store timed out
+		shutdownErr := tracerProvider.Shutdown(context.WithoutCancel(ctx))
+		if shutdownErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to shutdown tracer provider: %w", shutdownErr))
+		}
+	}()
+
+	var dbBreaker *breaker.Breaker
+	if cfg.Store == StorePostgres && cfg.DBBreakerThreshold > 0 {
+		dbBreaker = breaker.New(cfg.DBBreakerThreshold, cfg.DBBreakerCooldown)
+	}
+
+	store, repo, err := newStore(ctx, cfg, dbBreaker)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create app instance: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			store.Close()
+		}
+	}()
+
+	schemaVersion, err := migrations.Version()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create app instance: %w", err)
+	}
+
+	err = prepareSchema(ctx, cfg, store, repo, schemaVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create app instance: %w", err)
+	}
+
+	broker := events.NewBroker()
+
+	var serviceOpts []service.Option
+
+	// transactions committed in postgres are delivered through notifications, see Run
+	if repo == nil {
+		serviceOpts = append(serviceOpts, service.WithPublisher(broker))
+	}
+
+	if cfg.RiskRules != "" {
+		rules, err := risk.LoadRules(cfg.RiskRules)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create app instance: %w", err)
+		}
+
+		serviceOpts = append(serviceOpts, service.WithRiskEvaluator(risk.NewEngine(rules, store)))
+	}
+
+	if cfg.Fees != "" {
+		fees, err := newFeeEngine(ctx, cfg.Fees, store)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create app instance: %w", err)
+		}
+
+		serviceOpts = append(serviceOpts, service.WithFees(fees))
+	}
+
+	var payoutInterval time.Duration
+
+	if cfg.Interest != "" {
+		products, err := newInterestEngine(ctx, cfg.Interest, store)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create app instance: %w", err)
+		}
+
+		serviceOpts = append(serviceOpts, service.WithInterest(products))
+		payoutInterval = cfg.InterestPayoutInterval
+	}
+
+	var screener *screening.Screener
+
+	if cfg.Screening {
+		screener = screening.New(store)
+
+		// screening fails closed: the app doesn't start without the blocklist
+		err = screener.Reload(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create app instance: %w", err)
+		}
+
+		serviceOpts = append(serviceOpts, service.WithScreener(screener))
+	}
+
+	srvc := service.New(cfg.Timeout, store, serviceOpts...)
+
+	readiness := health.New(store, schemaVersion)
+
+	spec, err := docs.LoadOpenAPI()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create app instance: %w", err)
+	}
+
+	validator, err := handler.NewSpecValidator(spec, cfg.OpenAPIValidation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create app instance: %w", err)
+	}
+
+	var handlerOpts []handler.Option
+	if validator != nil {
+		handlerOpts = append(handlerOpts, handler.WithSpecValidator(validator))
+	}
+
+	limiter, bucketsStore, err := newRateLimiter(cfg, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create app instance: %w", err)
+	}
+
+	if limiter != nil {
+		handlerOpts = append(handlerOpts, handler.WithRateLimiter(limiter))
+	}
+
+	if dbBreaker != nil {
+		handlerOpts = append(handlerOpts, handler.WithCircuitBreaker(dbBreaker))
+	}
+
+	operators, err := operator.ParseTokens(cfg.OperatorTokens)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create app instance: %w", err)
+	}
+
+	proxies, err := proxy.ParseTrusted(cfg.TrustedProxies)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create app instance: %w", err)
 	}
 
-	srvc := service.New(cfg.Timeout, repo)
+	handlerOpts = append(handlerOpts, handler.WithOperators(operators), handler.WithTrustedProxies(proxies))
 
-	requestHandler := handler.New(cfg.Hostname, cfg.Port, srvc)
+	requestHandler := handler.New(cfg.Hostname, cfg.Port, srvc, readiness, broker, handlerOpts...)
+
+	grpcServer := grpcserver.New(cfg.Hostname, cfg.GRPCPort, srvc, broker, grpcserver.WithTrustedProxies(proxies))
 
 	return &App{
 		requestHandler: requestHandler,
+		grpcServer:     grpcServer,
+		broker:         broker,
+		readiness:      readiness,
+		limiter:        limiter,
+		rateLimitStore: cfg.RateLimitStore,
+		bucketsStore:   bucketsStore,
+		screener:       screener,
+		reloadInterval: cfg.BlocklistReloadInterval,
+		srvc:           srvc,
+		payoutInterval: payoutInterval,
+		store:          store,
 		repo:           repo,
+		tracerProvider: tracerProvider,
+		drainDelay:     cfg.DrainDelay,
+		secretRefresh:  cfg.SecretRefreshInterval,
 	}, nil
 }
 
-func (app *App) Run() error {
-	err := app.requestHandler.Run()
+// Run starts serving http and grpc requests in background. The returned channel receives
+// an error if any server fails, and is closed once both servers are stopped.
+func (app *App) Run() <-chan error {
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	app.stopWorkers = stopWorkers
+
+	// committed transactions of all replicas are delivered through postgres notifications
+	if app.repo != nil {
+		app.workers.Add(1)
+
+		go func() {
+			defer app.workers.Done()
+
+			app.repo.ListenTransactions(workersCtx, app.broker)
+		}()
+
+		app.workers.Add(1)
+
+		go func() {
+			defer app.workers.Done()
+
+			app.repo.RunReplicaChecks(workersCtx, replicaCheckInterval)
+		}()
+
+		if app.secretRefresh > 0 {
+			app.workers.Add(1)
+
+			go func() {
+				defer app.workers.Done()
+
+				app.repo.RunPasswordRotation(workersCtx, app.secretRefresh)
+			}()
+		}
+	}
+
+	if app.bucketsStore != nil {
+		app.workers.Add(1)
+
+		go func() {
+			defer app.workers.Done()
+
+			app.bucketsStore.RunPruning(workersCtx, bucketsPruneInterval)
+		}()
+	}
+
+	if app.screener != nil {
+		app.workers.Add(1)
+
+		go func() {
+			defer app.workers.Done()
+
+			app.screener.RunReload(workersCtx, app.reloadInterval)
+		}()
+	}
+
+	if app.payoutInterval > 0 {
+		app.workers.Add(1)
+
+		go func() {
+			defer app.workers.Done()
+
+			app.srvc.RunInterestPayouts(workersCtx, app.payoutInterval)
+		}()
+	}
+
+	servers := []func() error{
+		app.requestHandler.Run,
+		app.grpcServer.Run,
+	}
+
+	errs := make(chan error, len(servers))
+
+	var wg sync.WaitGroup
+
+	for _, run := range servers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			err := run()
+			if err != nil {
+				errs <- fmt.Errorf("failed to run application: %w", err)
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(errs)
+	}()
+
+	return errs
+}
+
+// Reload applies the rate limits of cfg to the running application, buckets are kept.
+// Enabling or disabling rate limiting and changing its store require restart.
+func (app *App) Reload(cfg *config.Config) error {
+	if cfg.RateLimitStore != app.rateLimitStore {
+		return fmt.Errorf("%w: rate limit store %s", ErrRestartRequired, cfg.RateLimitStore)
+	}
+
+	if app.limiter == nil {
+		return nil
+	}
+
+	limits, err := ratelimit.ParseLimits(cfg.RateLimitIP, cfg.RateLimitUser, cfg.RateLimitRoutes)
 	if err != nil {
-		return fmt.Errorf("failed to start application: %w", err)
+		return fmt.Errorf("invalid rate limits: %w", err)
 	}
 
+	app.limiter.SetLimits(limits)
+
 	return nil
 }
 
+// Stop gracefully shuts the application down: marks it as not ready, ends transaction feeds,
+// stops accepting requests and waits for in-flight ones, stops background workers, waits for
+// db transactions in progress and closes the db pool. Steps are done in order even if the previous one fails.
 func (app *App) Stop(ctx context.Context) error {
-	app.repo.DB.Close()
+	log := logger.FromContext(ctx)
+
+	app.readiness.Drain()
+
+	if app.drainDelay > 0 {
+		log.Info("waiting for load balancers to drain traffic", slog.Duration("delay", app.drainDelay))
+
+		select {
+		case <-time.After(app.drainDelay):
+		case <-ctx.Done():
+		}
+	}
+
+	var errs []error
+
+	// streams never finish by themselves, so they are ended before servers shutdown
+	app.broker.Close()
 
 	err := app.requestHandler.Shutdown(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to shutdown http server: %w", err)
+		errs = append(errs, fmt.Errorf("failed to shutdown http server: %w", err))
+	}
+
+	err = app.grpcServer.Shutdown(ctx)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to shutdown grpc server: %w", err))
+	}
+
+	if app.stopWorkers != nil {
+		app.stopWorkers()
+	}
+
+	err = waitGroup(ctx, &app.workers)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to wait for background workers: %w", err))
+	}
+
+	err = app.store.WaitTx(ctx)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to wait for db transactions: %w", err))
+	}
+
+	app.store.Close()
+
+	err = app.tracerProvider.Shutdown(ctx)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to shutdown tracer provider: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
+
+// newStore connects to the configured store, the postgres repository is also returned
+// for features that need postgres itself, it is nil for other stores.
+func newStore(ctx context.Context,
+	cfg *config.Config,
+	dbBreaker *breaker.Breaker) (store, *repository.Repository, error) {
+	switch cfg.Store {
+	case StorePostgres:
+		opts := []repository.Option{
+			repository.WithPool(repository.PoolConfig{
+				MaxConns:          cfg.DBMaxConns,
+				MinConns:          cfg.DBMinConns,
+				MaxConnLifetime:   cfg.DBMaxConnLifetime,
+				MaxConnIdleTime:   cfg.DBMaxConnIdleTime,
+				HealthCheckPeriod: cfg.DBHealthCheckPeriod,
+				StatementTimeout:  cfg.DBStatementTimeout,
+			}),
+			repository.WithConnectRetry(cfg.DBConnectRetries, cfg.DBConnectBackoff),
+			repository.WithReplicas(cfg.PostgresReplicaDSNs...),
+			repository.WithReadYourWrites(cfg.ReadYourWrites),
+			// db transactions of the service don't outlive its timeout
+			repository.WithResumeOverlap(cfg.Timeout),
+			repository.WithBreaker(dbBreaker),
+		}
+
+		if cfg.PostgresPassword != "" {
+			secrets := cfg.Secrets()
+
+			opts = append(opts, repository.WithPassword(func(ctx context.Context) (string, error) {
+				return secrets.Secret(ctx, config.EnvPostgresPassword) //nolint:wrapcheck
+			}))
+		}
+
+		repo, err := repository.NewConnection(ctx, cfg.PostgresDSN, opts...)
+		if err != nil {
+			return nil, nil, err //nolint:wrapcheck
+		}
+
+		err = metrics.RegisterPool(repo.DB)
+		if err != nil {
+			return nil, nil, err //nolint:wrapcheck
+		}
+
+		return repo, repo, nil
+	case StoreSQLite:
+		repo, err := sqlite.Open(ctx, cfg.SQLitePath)
+		if err != nil {
+			return nil, nil, err //nolint:wrapcheck
+		}
+
+		return repo, nil, nil
+	case StoreMemory:
+		logger.FromContext(ctx).Warn("data is kept in memory and is lost on exit")
+
+		return memory.New(), nil, nil
+	default:
+		return nil, nil, fmt.Errorf("%w: %s", ErrUnknownStore, cfg.Store)
+	}
+}
+
+// prepareSchema applies pending postgres migrations if it is configured, then in the strict mode
+// checks that the schema is at the version of the binary. The sqlite file is migrated on open
+// and the in-memory store has no schema, so only the check applies to them.
+func prepareSchema(ctx context.Context,
+	cfg *config.Config,
+	store store,
+	repo *repository.Repository,
+	expected int64) error {
+	if cfg.DBMigrate && repo != nil {
+		applied, err := repo.Migrate(ctx, cfg.DBMigrateLockTimeout)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+
+		if len(applied) > 0 {
+			logger.FromContext(ctx).Info("migrations applied", slog.Any("versions", applied))
+		}
+	}
+
+	if !cfg.DBSchemaStrict {
+		return nil
+	}
+
+	version, err := store.SchemaVersion(ctx)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	switch {
+	case version < expected:
+		return fmt.Errorf("%w: schema %d is behind the binary %d, apply the migrations", ErrSchemaVersion, version, expected)
+	case version > expected:
+		return fmt.Errorf("%w: schema %d is ahead of the binary %d", ErrSchemaVersion, version, expected)
 	}
 
 	return nil
 }
+
+// newFeeEngine loads fee schedules and checks that the revenue account exists,
+// otherwise every charged operation would fail.
+func newFeeEngine(ctx context.Context, path string, store store) (*fee.Engine, error) {
+	engine, err := fee.Load(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid fee schedules: %w", err)
+	}
+
+	_, err = store.GetAccount(ctx, engine.RevenueAccount())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fees revenue account %s: %w", engine.RevenueAccount(), err)
+	}
+
+	return engine, nil
+}
+
+// newInterestEngine loads interest products and checks that the payer account exists,
+// otherwise every payout would fail.
+func newInterestEngine(ctx context.Context, path string, store store) (*interest.Engine, error) {
+	engine, err := interest.Load(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid interest products: %w", err)
+	}
+
+	_, err = store.GetAccount(ctx, engine.PayerAccount())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get interest payer account %s: %w", engine.PayerAccount(), err)
+	}
+
+	return engine, nil
+}
+
+// newRateLimiter creates limiter with the configured store, nil limiter is returned if rate limiting is disabled.
+// The postgres store is returned to prune its buckets.
+func newRateLimiter(cfg *config.Config,
+	repo *repository.Repository) (*ratelimit.Limiter, *ratelimit.PostgresStore, error) {
+	limits, err := ratelimit.ParseLimits(cfg.RateLimitIP, cfg.RateLimitUser, cfg.RateLimitRoutes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid rate limits: %w", err)
+	}
+
+	switch cfg.RateLimitStore {
+	case ratelimit.StoreNone:
+		return nil, nil, nil
+	case ratelimit.StoreMemory:
+		return ratelimit.New(ratelimit.NewMemoryStore(), limits), nil, nil
+	case ratelimit.StorePostgres:
+		if repo == nil {
+			return nil, nil, fmt.Errorf("%w for postgres rate limit store", ErrPostgresRequired)
+		}
+
+		store := ratelimit.NewPostgresStore(repo.DB)
+
+		return ratelimit.New(store, limits), store, nil
+	default:
+		return nil, nil, fmt.Errorf("%w: %s", ratelimit.ErrUnknownStore, cfg.RateLimitStore)
+	}
+}
+
+func waitGroup(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err() //nolint:wrapcheck
+	}
+}
@@ -0,0 +1,198 @@
+// Package audit builds entries of the hash-chained audit log and verifies the chain.
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/logger"
+)
+
+// Actions recorded in the audit log.
+const (
+	ActionAccountCreate   = "account.create"
+	ActionAccountTier     = "account.tier"
+	ActionDeposit         = "deposit"
+	ActionTransfer        = "transfer"
+	ActionTransferHold    = "transfer.hold"
+	ActionReviewApprove   = "review.approve"
+	ActionReviewReject    = "review.reject"
+	ActionPartyBlock      = "blocklist.block"
+	ActionPartyUnblock    = "blocklist.unblock"
+	ActionBlocklistImport = "blocklist.import"
+	ActionInterestPayout  = "interest.payout"
+)
+
+// Actors of state changes without a known initiator.
+const (
+	ActorAnonymous = "anonymous"
+	ActorSystem    = "system"
+)
+
+const (
+	maxActorLength = 128
+	// postgres keeps timestamps with microseconds, so hashed time is truncated to survive the round trip
+	createdAtPrecision = time.Microsecond
+	// firstPrevHash is the previous hash of the first entry
+	firstPrevHash = ""
+)
+
+var (
+	ErrBrokenChain  = errors.New("previous hash doesn't match the previous entry")
+	ErrHashMismatch = errors.New("hash doesn't match the entry content")
+)
+
+type actorCtxKey struct{}
+
+// WithActor returns a copy of ctx carrying the actor of state changes. Too long actors are truncated.
+func WithActor(ctx context.Context, actor string) context.Context {
+	if len(actor) > maxActorLength {
+		actor = actor[:maxActorLength]
+	}
+
+	return context.WithValue(ctx, actorCtxKey{}, actor)
+}
+
+// Actor returns the actor carried by ctx or ActorAnonymous.
+func Actor(ctx context.Context) string {
+	actor, _ := ctx.Value(actorCtxKey{}).(string)
+	if actor == "" {
+		return ActorAnonymous
+	}
+
+	return actor
+}
+
+// NewEntry creates the entry of the state change made in ctx, before and after are encoded as JSON.
+// Chain fields are set when the entry is appended to the log.
+func NewEntry(ctx context.Context, action, resource string, before, after any) (entity.AuditEntry, error) {
+	beforeJSON, err := marshalState(before)
+	if err != nil {
+		return entity.AuditEntry{}, err
+	}
+
+	afterJSON, err := marshalState(after)
+	if err != nil {
+		return entity.AuditEntry{}, err
+	}
+
+	return entity.AuditEntry{
+		ID:        uuid.New(),
+		Actor:     Actor(ctx),
+		Action:    action,
+		Resource:  resource,
+		Before:    beforeJSON,
+		After:     afterJSON,
+		RequestID: logger.RequestID(ctx),
+		CreatedAt: time.Now().UTC().Truncate(createdAtPrecision),
+	}, nil
+}
+
+func marshalState(state any) (json.RawMessage, error) {
+	if state == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode audit state: %w", err)
+	}
+
+	return data, nil
+}
+
+// Chain links the entry to the previous one and sets its hash.
+func Chain(entry *entity.AuditEntry, prevHash string) error {
+	entry.PrevHash = prevHash
+
+	hash, err := Hash(*entry)
+	if err != nil {
+		return err
+	}
+
+	entry.Hash = hash
+
+	return nil
+}
+
+// Hash returns hex encoded sha256 of the entry content and the previous hash.
+func Hash(entry entity.AuditEntry) (string, error) {
+	payload, err := json.Marshal(struct {
+		ID        uuid.UUID       `json:"id"`
+		Actor     string          `json:"actor"`
+		Action    string          `json:"action"`
+		Resource  string          `json:"resource"`
+		Before    json.RawMessage `json:"before"`
+		After     json.RawMessage `json:"after"`
+		RequestID string          `json:"requestID"`
+		CreatedAt string          `json:"createdAt"`
+		PrevHash  string          `json:"prevHash"`
+	}{
+		ID:        entry.ID,
+		Actor:     entry.Actor,
+		Action:    entry.Action,
+		Resource:  entry.Resource,
+		Before:    entry.Before,
+		After:     entry.After,
+		RequestID: entry.RequestID,
+		CreatedAt: entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+		PrevHash:  entry.PrevHash,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+
+	sum := sha256.Sum256(payload)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Verifier checks entries of the log one by one in the chain order.
+type Verifier struct {
+	head    string
+	entries int
+}
+
+// Check reports the first entry which is edited, or follows a removed or edited entry.
+func (v *Verifier) Check(entry entity.AuditEntry) error {
+	prevHash := firstPrevHash
+	if v.entries > 0 {
+		prevHash = v.head
+	}
+
+	if entry.PrevHash != prevHash {
+		return fmt.Errorf("audit entry %d: %w", entry.Seq, ErrBrokenChain)
+	}
+
+	hash, err := Hash(entry)
+	if err != nil {
+		return err
+	}
+
+	if hash != entry.Hash {
+		return fmt.Errorf("audit entry %d: %w", entry.Seq, ErrHashMismatch)
+	}
+
+	v.head = entry.Hash
+	v.entries++
+
+	return nil
+}
+
+// Head returns the hash of the last checked entry, it can be kept elsewhere to detect removal of the tail.
+func (v *Verifier) Head() string {
+	return v.head
+}
+
+// Entries returns the number of checked entries.
+func (v *Verifier) Entries() int {
+	return v.entries
+}
@@ -0,0 +1,123 @@
+package audit_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/aspirin100/finapi/internal/audit"
+	"github.com/aspirin100/finapi/internal/entity"
+)
+
+func newChain(t *testing.T, count int) []entity.AuditEntry {
+	t.Helper()
+
+	ctx := audit.WithActor(context.Background(), "operator")
+
+	entries := make([]entity.AuditEntry, 0, count)
+	prevHash := ""
+
+	for i := range count {
+		entry, err := audit.NewEntry(ctx, audit.ActionDeposit, "account/1",
+			map[string]int{"balance": i}, map[string]int{"balance": i + 1})
+		require.NoError(t, err)
+
+		require.NoError(t, audit.Chain(&entry, prevHash))
+
+		entry.Seq = int64(i + 1)
+		prevHash = entry.Hash
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+func TestActor(t *testing.T) {
+	require.Equal(t, audit.ActorAnonymous, audit.Actor(context.Background()))
+	require.Equal(t, "operator", audit.Actor(audit.WithActor(context.Background(), "operator")))
+}
+
+func TestVerifier(t *testing.T) {
+	cases := []struct {
+		Name          string
+		Tamper        func(entries []entity.AuditEntry) []entity.AuditEntry
+		ExpectedError error
+	}{
+		{
+			Name:   "intact chain",
+			Tamper: func(entries []entity.AuditEntry) []entity.AuditEntry { return entries },
+		},
+		{
+			Name: "edited state",
+			Tamper: func(entries []entity.AuditEntry) []entity.AuditEntry {
+				entries[1].After = json.RawMessage(`{"balance":1000}`)
+
+				return entries
+			},
+			ExpectedError: audit.ErrHashMismatch,
+		},
+		{
+			Name: "edited actor and rehashed",
+			Tamper: func(entries []entity.AuditEntry) []entity.AuditEntry {
+				entries[1].Actor = "someone"
+				entries[1].Hash, _ = audit.Hash(entries[1])
+
+				return entries
+			},
+			ExpectedError: audit.ErrBrokenChain,
+		},
+		{
+			Name: "removed entry",
+			Tamper: func(entries []entity.AuditEntry) []entity.AuditEntry {
+				return append(entries[:1], entries[2:]...)
+			},
+			ExpectedError: audit.ErrBrokenChain,
+		},
+		{
+			Name: "removed first entry",
+			Tamper: func(entries []entity.AuditEntry) []entity.AuditEntry {
+				return entries[1:]
+			},
+			ExpectedError: audit.ErrBrokenChain,
+		},
+	}
+
+	for _, tcase := range cases {
+		t.Run(tcase.Name, func(t *testing.T) {
+			entries := tcase.Tamper(newChain(t, 3))
+
+			var (
+				verifier audit.Verifier
+				err      error
+			)
+
+			for _, entry := range entries {
+				err = verifier.Check(entry)
+				if err != nil {
+					break
+				}
+			}
+
+			require.ErrorIs(t, err, tcase.ExpectedError)
+
+			if tcase.ExpectedError == nil {
+				require.Equal(t, entries[len(entries)-1].Hash, verifier.Head())
+				require.Equal(t, len(entries), verifier.Entries())
+			}
+		})
+	}
+}
+
+func TestHashStoredEntry(t *testing.T) {
+	entry := newChain(t, 1)[0]
+
+	// postgres returns the time in the session time zone
+	entry.CreatedAt = entry.CreatedAt.In(time.FixedZone("UTC+9", 9*60*60))
+
+	hash, err := audit.Hash(entry)
+	require.NoError(t, err)
+	require.Equal(t, entry.Hash, hash)
+}
@@ -0,0 +1,90 @@
+// Package breaker is the circuit breaker of the database. After consecutive failures to reach
+// the database requests fail fast for a cooldown instead of waiting for timeouts.
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/aspirin100/finapi/internal/metrics"
+)
+
+var ErrOpen = errors.New("database is unavailable")
+
+// Breaker opens after threshold consecutive failures. When the cooldown is over requests are let
+// through again to probe the database: a success closes the breaker, a failure opens it for another cooldown.
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+}
+
+// New creates the breaker, it never opens if threshold isn't positive.
+func New(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// Allow returns ErrOpen while the breaker is open.
+func (b *Breaker) Allow() error {
+	if b.RetryAfter() > 0 {
+		return ErrOpen
+	}
+
+	return nil
+}
+
+// RetryAfter returns the time left until the database is probed again, zero if the breaker isn't open.
+func (b *Breaker) RetryAfter() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.open() {
+		return 0
+	}
+
+	return max(b.openedAt.Add(b.cooldown).Sub(b.now()), 0)
+}
+
+// Success records a successful round trip to the database.
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.open() {
+		metrics.DBCircuitOpen.Set(0)
+	}
+
+	b.failures = 0
+	b.openedAt = time.Time{}
+}
+
+// Failure records a failure to reach the database.
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.threshold <= 0 {
+		return
+	}
+
+	b.failures++
+
+	if b.failures >= b.threshold {
+		b.openedAt = b.now()
+
+		metrics.DBCircuitOpen.Set(1)
+	}
+}
+
+func (b *Breaker) open() bool {
+	return !b.openedAt.IsZero()
+}
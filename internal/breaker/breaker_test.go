@@ -0,0 +1,52 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBreaker(t *testing.T) {
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	b := New(3, 10*time.Second)
+	b.now = func() time.Time { return now }
+
+	b.Failure()
+	b.Failure()
+	require.NoError(t, b.Allow(), "closed below the threshold")
+
+	b.Success()
+	b.Failure()
+	b.Failure()
+	require.NoError(t, b.Allow(), "success resets failures")
+
+	b.Failure()
+	require.ErrorIs(t, b.Allow(), ErrOpen)
+	require.Equal(t, 10*time.Second, b.RetryAfter())
+
+	now = now.Add(4 * time.Second)
+	require.Equal(t, 6*time.Second, b.RetryAfter())
+
+	now = now.Add(6 * time.Second)
+	require.NoError(t, b.Allow(), "database is probed after the cooldown")
+
+	b.Failure()
+	require.ErrorIs(t, b.Allow(), ErrOpen, "failed probe opens for another cooldown")
+
+	now = now.Add(10 * time.Second)
+	b.Success()
+	require.NoError(t, b.Allow())
+	require.Zero(t, b.RetryAfter())
+}
+
+func TestBreakerDisabled(t *testing.T) {
+	b := New(0, time.Second)
+
+	for range 10 {
+		b.Failure()
+	}
+
+	require.NoError(t, b.Allow())
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const envPrefix = "FINAPI_"
+
+// flagValues are values of the flags set on the command line by config fields,
+// they are applied after the file and env.
+type flagValues map[int]reflect.Value
+
+// parseFlags parses args, --config and --print-config are set to cfg right away, the other flags
+// are named after env variables of the fields, e.g. --db-max-conns overrides FINAPI_DB_MAX_CONNS.
+func parseFlags(cfg *Config, args []string) (flagValues, error) {
+	flags := flag.NewFlagSet("finapi", flag.ContinueOnError)
+	flags.StringVar(&cfg.File, "config", "", "path of YAML or TOML config file, "+EnvFile+" if empty")
+	flags.BoolVar(&cfg.PrintConfig, "print-config", false, "print the config with secrets redacted and exit")
+
+	values := make(flagValues)
+	fields := reflect.TypeFor[Config]()
+
+	for i := range fields.NumField() {
+		field := fields.Field(i)
+
+		env := field.Tag.Get("env")
+		if env == "" {
+			continue
+		}
+
+		set := func(raw string) error {
+			value := reflect.New(field.Type).Elem()
+
+			err := parseValue(value, raw, field.Tag.Get("env-separator"))
+			if err != nil {
+				return err
+			}
+
+			values[i] = value
+
+			return nil
+		}
+
+		usage := "overrides " + env
+
+		if field.Type.Kind() == reflect.Bool {
+			flags.BoolFunc(FlagName(env), usage, set)
+		} else {
+			flags.Func(FlagName(env), usage, set)
+		}
+	}
+
+	err := flags.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil, err //nolint:wrapcheck
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+
+	return values, nil
+}
+
+func (v flagValues) apply(cfg *Config) {
+	fields := reflect.ValueOf(cfg).Elem()
+	cfg.flagged = make(map[string]bool, len(v))
+
+	for i, value := range v {
+		fields.Field(i).Set(value)
+		cfg.flagged[fields.Type().Field(i).Tag.Get("env")] = true
+	}
+}
+
+// FlagName returns the flag overriding the env variable.
+func FlagName(env string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimPrefix(env, envPrefix)), "_", "-")
+}
+
+// parseValue parses raw into value of the config field types, sep splits lists.
+func parseValue(value reflect.Value, raw, sep string) error {
+	if value.Type() == reflect.TypeFor[time.Duration]() {
+		duration, err := time.ParseDuration(raw)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+
+		value.SetInt(int64(duration))
+
+		return nil
+	}
+
+	switch value.Kind() { //nolint:exhaustive
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+
+		value.SetBool(parsed)
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(raw, 10, value.Type().Bits())
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+
+		value.SetInt(parsed)
+	case reflect.Slice:
+		if sep == "" {
+			sep = ","
+		}
+
+		items := strings.Split(raw, sep)
+		for i := range items {
+			items[i] = strings.TrimSpace(items[i])
+		}
+
+		value.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", value.Type()) //nolint:err113
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"reflect"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redacted replaces secrets, the same way url.URL.Redacted does.
+const redacted = "xxxxx"
+
+var (
+	// keywordPassword matches the password of a keyword/value DSN, quoted or not.
+	keywordPassword = regexp.MustCompile(`(password\s*=\s*)('(?:\\.|[^'])*'|\S+)`)
+	// userinfoPassword matches the password of a URL DSN which url.Parse rejects.
+	userinfoPassword = regexp.MustCompile(`(://[^:/@]*:)[^@]*@`)
+)
+
+// Redacted returns a copy of the config with the secret settings and passwords of DSNs replaced.
+func (c *Config) Redacted() Config {
+	copied := *c
+
+	fields := reflect.ValueOf(&copied).Elem()
+	for i := range fields.NumField() {
+		if fields.Type().Field(i).Tag.Get("secret") == "true" && !fields.Field(i).IsZero() {
+			fields.Field(i).SetString(redacted)
+		}
+	}
+
+	copied.PostgresDSN = RedactDSN(c.PostgresDSN)
+
+	copied.PostgresReplicaDSNs = make([]string, len(c.PostgresReplicaDSNs))
+	for i, dsn := range c.PostgresReplicaDSNs {
+		copied.PostgresReplicaDSNs[i] = RedactDSN(dsn)
+	}
+
+	return copied
+}
+
+// LogValue logs the redacted config.
+func (c *Config) LogValue() slog.Value {
+	return slog.AnyValue(c.Redacted())
+}
+
+// Print writes the redacted config as YAML, which can be used as the config file.
+func (c *Config) Print(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+
+	err := encoder.Encode(c.Redacted())
+	if err != nil {
+		return fmt.Errorf("failed to print config: %w", err)
+	}
+
+	return encoder.Close() //nolint:wrapcheck
+}
+
+// RedactDSN replaces the password of the URL or keyword/value postgres DSN.
+func RedactDSN(dsn string) string {
+	parsed, err := url.Parse(dsn)
+	if err != nil || parsed.Scheme == "" {
+		dsn = userinfoPassword.ReplaceAllString(dsn, "${1}"+redacted+"@")
+
+		return keywordPassword.ReplaceAllString(dsn, "${1}"+redacted)
+	}
+
+	query := parsed.Query()
+	if query.Has("password") {
+		query.Set("password", redacted)
+		parsed.RawQuery = query.Encode()
+	}
+
+	return parsed.Redacted()
+}
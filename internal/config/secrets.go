@@ -0,0 +1,131 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// fileSuffix is appended to the env variable of a setting to read the setting from the file,
+// e.g. FINAPI_POSTGRES_PASSWORD_FILE=/run/secrets/postgres_password.
+const fileSuffix = "_FILE"
+
+// EnvPostgresPassword is the secret setting of the postgres password.
+const EnvPostgresPassword = "FINAPI_POSTGRES_PASSWORD"
+
+var (
+	ErrSecretNotFound = errors.New("secret not found")
+	ErrSecretConflict = errors.New("setting is set both by env and by file")
+)
+
+// SecretProvider returns the current value of the secret by the env variable of its setting,
+// e.g. FINAPI_POSTGRES_PASSWORD. ErrSecretNotFound is returned if the provider doesn't have it.
+type SecretProvider interface {
+	Secret(ctx context.Context, name string) (string, error)
+}
+
+// EnvSecrets reads secrets from env variables.
+type EnvSecrets struct{}
+
+func (EnvSecrets) Secret(_ context.Context, name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+	}
+
+	return value, nil
+}
+
+// FileSecrets reads secrets from the files named by <name>_FILE env variables, the way docker and
+// kubernetes mount secrets. The file is read on every call, so a rotated secret is picked up,
+// trailing newlines are trimmed.
+type FileSecrets struct{}
+
+func (FileSecrets) Secret(_ context.Context, name string) (string, error) {
+	path, ok := os.LookupEnv(name + fileSuffix)
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotFound, name+fileSuffix)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", name+fileSuffix, err)
+	}
+
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// readFiles sets the settings having <env>_FILE variables from their files.
+func readFiles(cfg *Config) error {
+	fields := reflect.ValueOf(cfg).Elem()
+
+	for i := range fields.NumField() {
+		field := fields.Type().Field(i)
+
+		env := field.Tag.Get("env")
+		if env == "" {
+			continue
+		}
+
+		raw, err := FileSecrets{}.Secret(context.Background(), env)
+		if errors.Is(err, ErrSecretNotFound) {
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		_, ok := os.LookupEnv(env)
+		if ok {
+			return fmt.Errorf("%w: %s and %s", ErrSecretConflict, env, env+fileSuffix)
+		}
+
+		err = parseValue(fields.Field(i), raw, field.Tag.Get("env-separator"))
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", env+fileSuffix, err)
+		}
+	}
+
+	return nil
+}
+
+// Secrets returns the current values of the secret settings: their files and env variables
+// are read again on every call, the loaded value is returned if the setting comes from
+// the config file or is overridden by a flag.
+func (c *Config) Secrets() SecretProvider {
+	return configSecrets{cfg: c}
+}
+
+type configSecrets struct {
+	cfg *Config
+}
+
+func (s configSecrets) Secret(ctx context.Context, name string) (string, error) {
+	fields := reflect.ValueOf(s.cfg).Elem()
+
+	for i := range fields.NumField() {
+		field := fields.Type().Field(i)
+		if field.Tag.Get("env") != name || field.Tag.Get("secret") != "true" {
+			continue
+		}
+
+		if s.cfg.flagged[name] {
+			return fields.Field(i).String(), nil
+		}
+
+		for _, provider := range []SecretProvider{FileSecrets{}, EnvSecrets{}} {
+			value, err := provider.Secret(ctx, name)
+			if !errors.Is(err, ErrSecretNotFound) {
+				return value, err //nolint:wrapcheck
+			}
+		}
+
+		return fields.Field(i).String(), nil
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+}
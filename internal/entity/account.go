@@ -0,0 +1,37 @@
+package entity
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// TierStandard is the tier of new accounts, tiers choose fee schedules.
+const TierStandard = "standard"
+
+// maxTierLength limits names of tiers.
+const maxTierLength = 32
+
+// Types of accounts, savings accounts earn interest.
+const (
+	AccountChecking = "checking"
+	AccountSavings  = "savings"
+)
+
+type Account struct {
+	ID      uuid.UUID       `json:"id"`
+	Balance decimal.Decimal `json:"balance"`
+	Tier    string          `json:"tier"`
+	Type    string          `json:"type"`
+}
+
+// ValidAccountType reports whether accounts of the type may be opened.
+func ValidAccountType(accountType string) bool {
+	return accountType == AccountChecking || accountType == AccountSavings
+}
+
+// ValidTier reports whether accounts may be moved to the tier: up to 32 lowercase letters, digits, '_' and '-'.
+func ValidTier(tier string) bool {
+	return tier != "" && len(tier) <= maxTierLength && strings.Trim(tier, "abcdefghijklmnopqrstuvwxyz0123456789_-") == ""
+}
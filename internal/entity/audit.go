@@ -0,0 +1,24 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditEntry is a state change recorded in the audit log. Hash covers all fields except Seq
+// and PrevHash is the hash of the previous entry, so any edit breaks the chain.
+type AuditEntry struct {
+	Seq       int64           `json:"seq"`
+	ID        uuid.UUID       `json:"id"`
+	Actor     string          `json:"actor"`
+	Action    string          `json:"action"`
+	Resource  string          `json:"resource"`
+	Before    json.RawMessage `json:"before,omitempty"`
+	After     json.RawMessage `json:"after,omitempty"`
+	RequestID string          `json:"requestID"` //nolint:tagliatelle
+	CreatedAt time.Time       `json:"createdAt"`
+	PrevHash  string          `json:"prevHash"`
+	Hash      string          `json:"hash"`
+}
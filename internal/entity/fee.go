@@ -0,0 +1,17 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// FeeQuote is a preview of the fee the account would be charged for the operation.
+// BalanceChange is how the balance of the account would change, including the fee.
+type FeeQuote struct {
+	Operation     string          `json:"operation"`
+	AccountID     uuid.UUID       `json:"accountID"` //nolint:tagliatelle
+	Tier          string          `json:"tier"`
+	Amount        decimal.Decimal `json:"amount"`
+	Fee           decimal.Decimal `json:"fee"`
+	BalanceChange decimal.Decimal `json:"balanceChange"`
+}
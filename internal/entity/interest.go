@@ -0,0 +1,22 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// DailyBalance is the balance of the account at the end of the day (UTC).
+type DailyBalance struct {
+	Day     time.Time       `json:"day"`
+	Balance decimal.Decimal `json:"balance"`
+}
+
+// InterestPayout is the interest paid to the account for the period, the month starting on Period.
+type InterestPayout struct {
+	AccountID     uuid.UUID       `json:"accountID"` //nolint:tagliatelle
+	Period        time.Time       `json:"period"`
+	Amount        decimal.Decimal `json:"amount"`
+	TransactionID uuid.UUID       `json:"transactionID"` //nolint:tagliatelle
+}
@@ -0,0 +1,30 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Statuses of held transfers.
+const (
+	ReviewPending  = "pending"
+	ReviewApproved = "approved"
+	ReviewRejected = "rejected"
+)
+
+// Review is a transfer held by risk rules until an operator approves or rejects it.
+// TransactionID is set once the approved transfer is executed.
+type Review struct {
+	ID            uuid.UUID       `json:"id"`
+	SenderID      uuid.UUID       `json:"senderID"`   //nolint:tagliatelle
+	ReceiverID    uuid.UUID       `json:"receiverID"` //nolint:tagliatelle
+	Amount        decimal.Decimal `json:"amount"`
+	Rule          string          `json:"rule"`
+	Reason        string          `json:"reason"`
+	Status        string          `json:"status"`
+	TransactionID *uuid.UUID      `json:"transactionID,omitempty"` //nolint:tagliatelle
+	CreatedAt     time.Time       `json:"createdAt"`
+	DecidedAt     *time.Time      `json:"decidedAt,omitempty"`
+}
@@ -0,0 +1,25 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BlockedParty is an account that must never send or receive money.
+type BlockedParty struct {
+	ID        uuid.UUID `json:"id"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ScreeningDecision records a check of the party against the blocklist.
+type ScreeningDecision struct {
+	ID        uuid.UUID `json:"id"`
+	Operation string    `json:"operation"`
+	PartyID   uuid.UUID `json:"partyID"` //nolint:tagliatelle
+	Blocked   bool      `json:"blocked"`
+	Reason    string    `json:"reason"`
+	RequestID string    `json:"requestID"` //nolint:tagliatelle
+	CreatedAt time.Time `json:"createdAt"`
+}
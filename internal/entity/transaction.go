@@ -7,11 +7,21 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+// Transaction is a money movement. ParentID links a fee to the charged transaction,
+// Fee is set only in the result of the charged operation.
 type Transaction struct {
 	ID         uuid.UUID       `json:"id"`
 	SenderID   uuid.UUID       `json:"senderID"`   //nolint:tagliatelle
 	ReceiverID uuid.UUID       `json:"receiverID"` //nolint:tagliatelle
 	Amount     decimal.Decimal `json:"amount"`
 	Operation  string          `json:"operation"`
+	ParentID   *uuid.UUID      `json:"parentID,omitempty"` //nolint:tagliatelle
 	CreatedAt  time.Time       `json:"createdAt"`
-}
\ No newline at end of file
+	Fee        *Transaction    `json:"fee,omitempty"`
+}
+
+// Deposit is a result of deposit operation.
+type Deposit struct {
+	Transaction Transaction     `json:"transaction"`
+	Balance     decimal.Decimal `json:"balance"`
+}
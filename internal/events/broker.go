@@ -0,0 +1,124 @@
+package events
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/aspirin100/finapi/internal/entity"
+)
+
+const subscriptionBuffer = 64
+
+type subscription struct {
+	userID uuid.UUID
+	ch     chan entity.Transaction
+}
+
+// Broker fans out committed transactions to subscribers of the involved users.
+type Broker struct {
+	mu     sync.Mutex
+	subs   map[uuid.UUID]map[*subscription]struct{}
+	closed bool
+}
+
+func NewBroker() *Broker {
+	return &Broker{
+		subs: make(map[uuid.UUID]map[*subscription]struct{}),
+	}
+}
+
+// Publish delivers the transaction to subscribers of its sender and receiver.
+// Subscribers which don't keep up are unsubscribed, their channels are closed.
+func (b *Broker) Publish(transaction entity.Transaction) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.deliver(transaction.SenderID, transaction)
+
+	if transaction.ReceiverID != transaction.SenderID {
+		b.deliver(transaction.ReceiverID, transaction)
+	}
+}
+
+func (b *Broker) deliver(userID uuid.UUID, transaction entity.Transaction) {
+	for sub := range b.subs[userID] {
+		select {
+		case sub.ch <- transaction:
+		default:
+			b.remove(sub)
+		}
+	}
+}
+
+// Subscribe returns channel of transactions involving the user and function to unsubscribe.
+// The channel is closed after unsubscribe, on broker close or if the subscriber is too slow.
+func (b *Broker) Subscribe(userID uuid.UUID) (<-chan entity.Transaction, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub := &subscription{
+		userID: userID,
+		ch:     make(chan entity.Transaction, subscriptionBuffer),
+	}
+
+	if b.closed {
+		close(sub.ch)
+
+		return sub.ch, func() {}
+	}
+
+	if b.subs[userID] == nil {
+		b.subs[userID] = make(map[*subscription]struct{})
+	}
+
+	b.subs[userID][sub] = struct{}{}
+
+	return sub.ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		b.remove(sub)
+	}
+}
+
+// Close unsubscribes everyone, so streams relying on the broker can finish.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, subs := range b.subs {
+		for sub := range subs {
+			b.remove(sub)
+		}
+	}
+
+	b.closed = true
+}
+
+// Closed tells whether the broker is closed, so subscribers can tell shutdown
+// from being unsubscribed for not keeping up.
+func (b *Broker) Closed() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.closed
+}
+
+func (b *Broker) remove(sub *subscription) {
+	subs, ok := b.subs[sub.userID]
+	if !ok {
+		return
+	}
+
+	if _, ok = subs[sub]; !ok {
+		return
+	}
+
+	delete(subs, sub)
+	close(sub.ch)
+
+	if len(subs) == 0 {
+		delete(b.subs, sub.userID)
+	}
+}
@@ -0,0 +1,78 @@
+package events_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/events"
+)
+
+func TestBroker(t *testing.T) {
+	broker := events.NewBroker()
+
+	sender, receiver, other := uuid.New(), uuid.New(), uuid.New()
+
+	senderFeed, unsubscribeSender := broker.Subscribe(sender)
+	receiverFeed, _ := broker.Subscribe(receiver)
+	otherFeed, _ := broker.Subscribe(other)
+
+	transaction := entity.Transaction{
+		ID:         uuid.New(),
+		SenderID:   sender,
+		ReceiverID: receiver,
+		Amount:     decimal.NewFromInt(10),
+		Operation:  "transfer",
+	}
+
+	broker.Publish(transaction)
+
+	require.Equal(t, transaction, <-senderFeed)
+	require.Equal(t, transaction, <-receiverFeed)
+	require.Empty(t, otherFeed)
+
+	unsubscribeSender()
+
+	_, ok := <-senderFeed
+	require.False(t, ok)
+
+	require.False(t, broker.Closed())
+
+	broker.Close()
+
+	require.True(t, broker.Closed())
+
+	_, ok = <-receiverFeed
+	require.False(t, ok)
+
+	_, ok = <-otherFeed
+	require.False(t, ok)
+
+	closedFeed, _ := broker.Subscribe(sender)
+
+	_, ok = <-closedFeed
+	require.False(t, ok)
+}
+
+func TestBrokerSlowSubscriber(t *testing.T) {
+	broker := events.NewBroker()
+
+	userID := uuid.New()
+
+	feed, unsubscribe := broker.Subscribe(userID)
+	defer unsubscribe()
+
+	for range 1000 {
+		broker.Publish(entity.Transaction{ID: uuid.New(), SenderID: userID, ReceiverID: userID})
+	}
+
+	received := 0
+	for range feed {
+		received++
+	}
+
+	require.Less(t, received, 1000)
+}
@@ -0,0 +1,85 @@
+package fee
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gopkg.in/yaml.v3"
+)
+
+type bandConfig struct {
+	UpTo    *decimal.Decimal `yaml:"upTo"`
+	Flat    decimal.Decimal  `yaml:"flat"`
+	Percent decimal.Decimal  `yaml:"percent"`
+}
+
+type scheduleConfig struct {
+	Operation string           `yaml:"operation"`
+	Tier      string           `yaml:"tier"`
+	Flat      decimal.Decimal  `yaml:"flat"`
+	Percent   decimal.Decimal  `yaml:"percent"`
+	Bands     []bandConfig     `yaml:"bands"`
+	Min       *decimal.Decimal `yaml:"min"`
+	Max       *decimal.Decimal `yaml:"max"`
+}
+
+type config struct {
+	RevenueAccount uuid.UUID        `yaml:"revenueAccount"`
+	Precision      *int32           `yaml:"precision"`
+	Schedules      []scheduleConfig `yaml:"schedules"`
+}
+
+// Load reads fee schedules from a YAML or JSON file.
+func Load(path string) (*Engine, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fee schedules: %w", err)
+	}
+
+	return Parse(data)
+}
+
+// Parse parses fee schedules in YAML or JSON, e.g.
+//
+//	revenueAccount: 9c2b6d1e-4d0b-4bb8-9d4a-52f1c7a0e001
+//	schedules:
+//	  - operation: transfer
+//	    percent: "1"
+//	    min: "0.5"
+//	    max: "20"
+func Parse(data []byte) (*Engine, error) {
+	var cfg config
+
+	err := yaml.Unmarshal(data, &cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse fee schedules: %w", err)
+	}
+
+	precision := int32(DefaultPrecision)
+	if cfg.Precision != nil {
+		precision = *cfg.Precision
+	}
+
+	schedules := make([]Schedule, 0, len(cfg.Schedules))
+
+	for _, scheduleCfg := range cfg.Schedules {
+		bands := make([]Band, 0, len(scheduleCfg.Bands))
+		for _, band := range scheduleCfg.Bands {
+			bands = append(bands, Band(band))
+		}
+
+		schedules = append(schedules, Schedule{
+			Operation: scheduleCfg.Operation,
+			Tier:      scheduleCfg.Tier,
+			Flat:      scheduleCfg.Flat,
+			Percent:   scheduleCfg.Percent,
+			Bands:     bands,
+			Min:       scheduleCfg.Min,
+			Max:       scheduleCfg.Max,
+		})
+	}
+
+	return NewEngine(cfg.RevenueAccount, precision, schedules)
+}
@@ -0,0 +1,169 @@
+// Package fee computes fees of money operations by configurable schedules.
+package fee
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Operations fees may be charged for. Withdrawals and FX conversions are out of scope,
+// no fees are charged for them.
+const (
+	OperationTransfer = "transfer"
+	OperationDeposit  = "deposit"
+)
+
+// DefaultPrecision is the number of decimal places fees are rounded to.
+const DefaultPrecision = 2
+
+var (
+	ErrInvalidSchedule  = errors.New("invalid fee schedule")
+	ErrNoRevenueAccount = errors.New("revenue account is required to charge fees")
+)
+
+var hundred = decimal.NewFromInt(100)
+
+// Band is a part of tiered schedule applied to amounts up to UpTo, the last band has no upper bound.
+type Band struct {
+	UpTo    *decimal.Decimal
+	Flat    decimal.Decimal
+	Percent decimal.Decimal
+}
+
+// Schedule is the fee of the operation for accounts of the tier, empty tier matches any one.
+// The fee is Flat plus Percent of the amount, or those of the band the amount falls into
+// if Bands are set, limited by Min and Max.
+type Schedule struct {
+	Operation string
+	Tier      string
+	Flat      decimal.Decimal
+	Percent   decimal.Decimal
+	Bands     []Band
+	Min       *decimal.Decimal
+	Max       *decimal.Decimal
+}
+
+// Compute returns the not rounded fee of the amount.
+func (s Schedule) Compute(amount decimal.Decimal) decimal.Decimal {
+	flat, percent := s.Flat, s.Percent
+
+	for _, band := range s.Bands {
+		if band.UpTo == nil || amount.LessThanOrEqual(*band.UpTo) {
+			flat, percent = band.Flat, band.Percent
+
+			break
+		}
+	}
+
+	fee := flat.Add(amount.Mul(percent).Div(hundred))
+
+	if s.Min != nil && fee.LessThan(*s.Min) {
+		fee = *s.Min
+	}
+
+	if s.Max != nil && fee.GreaterThan(*s.Max) {
+		fee = *s.Max
+	}
+
+	return fee
+}
+
+func (s Schedule) validate() error {
+	switch s.Operation {
+	case OperationTransfer, OperationDeposit:
+	default:
+		return fmt.Errorf("%w: unknown operation %q", ErrInvalidSchedule, s.Operation)
+	}
+
+	if s.Flat.IsNegative() || s.Percent.IsNegative() ||
+		(s.Min != nil && s.Min.IsNegative()) || (s.Max != nil && s.Max.IsNegative()) {
+		return fmt.Errorf("%w: fees must not be negative", ErrInvalidSchedule)
+	}
+
+	if s.Min != nil && s.Max != nil && s.Min.GreaterThan(*s.Max) {
+		return fmt.Errorf("%w: min is greater than max", ErrInvalidSchedule)
+	}
+
+	for i, band := range s.Bands {
+		if band.Flat.IsNegative() || band.Percent.IsNegative() {
+			return fmt.Errorf("%w: band #%d fees must not be negative", ErrInvalidSchedule, i+1)
+		}
+
+		last := i == len(s.Bands)-1
+
+		switch {
+		case band.UpTo == nil && !last:
+			return fmt.Errorf("%w: only the last band may have no upTo", ErrInvalidSchedule)
+		case band.UpTo != nil && i > 0 && !band.UpTo.GreaterThan(*s.Bands[i-1].UpTo):
+			return fmt.Errorf("%w: bands must be ordered by upTo", ErrInvalidSchedule)
+		}
+	}
+
+	return nil
+}
+
+type scheduleKey struct {
+	operation string
+	tier      string
+}
+
+// Engine chooses the schedule of the operation and the account tier and computes fees.
+type Engine struct {
+	schedules map[scheduleKey]Schedule
+	revenue   uuid.UUID
+	precision int32
+}
+
+// NewEngine validates the schedules, fees are credited to the revenue account.
+func NewEngine(revenueAccount uuid.UUID, precision int32, schedules []Schedule) (*Engine, error) {
+	if revenueAccount == uuid.Nil && len(schedules) > 0 {
+		return nil, ErrNoRevenueAccount
+	}
+
+	engine := &Engine{
+		schedules: make(map[scheduleKey]Schedule, len(schedules)),
+		revenue:   revenueAccount,
+		precision: precision,
+	}
+
+	for i, schedule := range schedules {
+		err := schedule.validate()
+		if err != nil {
+			return nil, fmt.Errorf("schedule #%d: %w", i+1, err)
+		}
+
+		key := scheduleKey{operation: schedule.Operation, tier: schedule.Tier}
+
+		if _, ok := engine.schedules[key]; ok {
+			return nil, fmt.Errorf("schedule #%d: %w: duplicate of %s for tier %q",
+				i+1, ErrInvalidSchedule, schedule.Operation, schedule.Tier)
+		}
+
+		engine.schedules[key] = schedule
+	}
+
+	return engine, nil
+}
+
+// Fee returns the fee of the operation for the account of the tier, rounded to the engine precision.
+// It is zero if no schedule matches.
+func (e *Engine) Fee(operation, tier string, amount decimal.Decimal) decimal.Decimal {
+	schedule, ok := e.schedules[scheduleKey{operation: operation, tier: tier}]
+	if !ok {
+		schedule, ok = e.schedules[scheduleKey{operation: operation}]
+	}
+
+	if !ok {
+		return decimal.Zero
+	}
+
+	return schedule.Compute(amount).Round(e.precision)
+}
+
+// RevenueAccount returns the account fees are credited to.
+func (e *Engine) RevenueAccount() uuid.UUID {
+	return e.revenue
+}
@@ -0,0 +1,125 @@
+package fee_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aspirin100/finapi/internal/fee"
+)
+
+func decimalPtr(value string) *decimal.Decimal {
+	d := decimal.RequireFromString(value)
+
+	return &d
+}
+
+func TestEngineFee(t *testing.T) {
+	engine, err := fee.Load("../../configs/fees.example.yml")
+	require.NoError(t, err)
+	require.Equal(t, uuid.MustParse("00000000-0000-0000-0000-00000000fee0"), engine.RevenueAccount())
+
+	cases := []struct {
+		Name        string
+		Operation   string
+		Tier        string
+		Amount      string
+		ExpectedFee string
+	}{
+		{Name: "flat and percent", Operation: fee.OperationTransfer, Amount: "100", ExpectedFee: "1.3"},
+		{Name: "min cap", Operation: fee.OperationTransfer, Amount: "10", ExpectedFee: "0.5"},
+		{Name: "max cap", Operation: fee.OperationTransfer, Amount: "5000", ExpectedFee: "25"},
+		{Name: "rounding", Operation: fee.OperationTransfer, Amount: "123.456", ExpectedFee: "1.53"},
+		{Name: "unknown tier uses default", Operation: fee.OperationTransfer, Tier: "gold", Amount: "100", ExpectedFee: "1.3"},
+		{Name: "free band", Operation: fee.OperationTransfer, Tier: "premium", Amount: "1000", ExpectedFee: "0"},
+		{Name: "second band", Operation: fee.OperationTransfer, Tier: "premium", Amount: "2000", ExpectedFee: "10"},
+		{Name: "last band", Operation: fee.OperationTransfer, Tier: "premium", Amount: "12000", ExpectedFee: "30"},
+		{Name: "last band max cap", Operation: fee.OperationTransfer, Tier: "premium", Amount: "20000", ExpectedFee: "40"},
+		{Name: "no schedule", Operation: fee.OperationDeposit, Amount: "100", ExpectedFee: "0"},
+	}
+
+	for _, tcase := range cases {
+		t.Run(tcase.Name, func(t *testing.T) {
+			got := engine.Fee(tcase.Operation, tcase.Tier, decimal.RequireFromString(tcase.Amount))
+			require.Equal(t, tcase.ExpectedFee, got.String())
+		})
+	}
+}
+
+func TestNewEngine(t *testing.T) {
+	revenue := uuid.New()
+
+	cases := []struct {
+		Name          string
+		Revenue       uuid.UUID
+		Schedules     []fee.Schedule
+		ExpectedError error
+	}{
+		{
+			Name:      "ok",
+			Revenue:   revenue,
+			Schedules: []fee.Schedule{{Operation: fee.OperationTransfer, Flat: decimal.NewFromInt(1)}},
+		},
+		{
+			Name:          "no revenue account",
+			Schedules:     []fee.Schedule{{Operation: fee.OperationTransfer}},
+			ExpectedError: fee.ErrNoRevenueAccount,
+		},
+		{
+			Name:          "unknown operation",
+			Revenue:       revenue,
+			Schedules:     []fee.Schedule{{Operation: "withdrawal"}},
+			ExpectedError: fee.ErrInvalidSchedule,
+		},
+		{
+			Name:          "negative fee",
+			Revenue:       revenue,
+			Schedules:     []fee.Schedule{{Operation: fee.OperationTransfer, Percent: decimal.NewFromInt(-1)}},
+			ExpectedError: fee.ErrInvalidSchedule,
+		},
+		{
+			Name:    "min above max",
+			Revenue: revenue,
+			Schedules: []fee.Schedule{{
+				Operation: fee.OperationTransfer, Min: decimalPtr("2"), Max: decimalPtr("1"),
+			}},
+			ExpectedError: fee.ErrInvalidSchedule,
+		},
+		{
+			Name:    "unordered bands",
+			Revenue: revenue,
+			Schedules: []fee.Schedule{{
+				Operation: fee.OperationTransfer,
+				Bands:     []fee.Band{{UpTo: decimalPtr("100")}, {UpTo: decimalPtr("10")}},
+			}},
+			ExpectedError: fee.ErrInvalidSchedule,
+		},
+		{
+			Name:    "unbounded band in the middle",
+			Revenue: revenue,
+			Schedules: []fee.Schedule{{
+				Operation: fee.OperationTransfer,
+				Bands:     []fee.Band{{}, {UpTo: decimalPtr("10")}},
+			}},
+			ExpectedError: fee.ErrInvalidSchedule,
+		},
+		{
+			Name:    "duplicate",
+			Revenue: revenue,
+			Schedules: []fee.Schedule{
+				{Operation: fee.OperationTransfer, Tier: "premium"},
+				{Operation: fee.OperationTransfer, Tier: "premium"},
+			},
+			ExpectedError: fee.ErrInvalidSchedule,
+		},
+	}
+
+	for _, tcase := range cases {
+		t.Run(tcase.Name, func(t *testing.T) {
+			_, err := fee.NewEngine(tcase.Revenue, fee.DefaultPrecision, tcase.Schedules)
+			require.ErrorIs(t, err, tcase.ExpectedError)
+		})
+	}
+}
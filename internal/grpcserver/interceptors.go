@@ -0,0 +1,130 @@
+package grpcserver
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/aspirin100/finapi/internal/audit"
+	"github.com/aspirin100/finapi/internal/logger"
+	"github.com/aspirin100/finapi/internal/metrics"
+	"github.com/aspirin100/finapi/internal/proxy"
+)
+
+const (
+	requestIDKey       = "x-request-id"
+	maxRequestIDLength = 128
+	actorKey           = "x-actor"
+)
+
+func unaryRequestLogger(proxies proxy.Trusted) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+
+		ctx = withActor(withRequestID(ctx), proxies)
+
+		resp, err := handler(ctx, req)
+
+		logCall(ctx, info.FullMethod, start, err)
+
+		return resp, err
+	}
+}
+
+func streamRequestLogger(proxies proxy.Trusted) grpc.StreamServerInterceptor {
+	return func(srv any,
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler) error {
+		start := time.Now()
+
+		ctx := withActor(withRequestID(stream.Context()), proxies)
+
+		err := handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+
+		logCall(ctx, info.FullMethod, start, err)
+
+		return err
+	}
+}
+
+// withRequestID propagates incoming request id or assigns a new one
+// and returns it to the client in response header.
+func withRequestID(ctx context.Context) context.Context {
+	var requestID string
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if ok {
+		if values := md.Get(requestIDKey); len(values) > 0 {
+			requestID = values[0]
+		}
+	}
+
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		requestID = uuid.NewString()
+	}
+
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, requestID))
+
+	return logger.WithRequestID(ctx, requestID)
+}
+
+// withActor puts the initiator of the call from metadata into ctx for the audit log. The metadata
+// is only believed from trusted proxies, the actor of other calls is anonymous.
+func withActor(ctx context.Context, proxies proxy.Trusted) context.Context {
+	client, ok := peer.FromContext(ctx)
+	if !ok || client.Addr == nil || !proxies.Contains(client.Addr.String()) {
+		return ctx
+	}
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+
+	if values := md.Get(actorKey); len(values) > 0 && values[0] != "" {
+		return audit.WithActor(ctx, values[0])
+	}
+
+	return ctx
+}
+
+func logCall(ctx context.Context, method string, start time.Time, err error) {
+	code := status.Code(err)
+	latency := time.Since(start)
+
+	metrics.GRPCRequests.WithLabelValues(method, code.String()).Inc()
+	metrics.GRPCRequestDuration.WithLabelValues(method, code.String()).Observe(latency.Seconds())
+
+	level := slog.LevelInfo
+	if code == codes.Internal || code == codes.Unknown {
+		level = slog.LevelError
+	}
+
+	logger.FromContext(ctx).LogAttrs(ctx, level, "rpc handled",
+		slog.String("method", method),
+		slog.String("code", code.String()),
+		slog.Duration("latency", latency),
+	)
+}
+
+// contextStream overrides context of the wrapped stream.
+type contextStream struct {
+	grpc.ServerStream
+
+	ctx context.Context //nolint:containedctx
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,327 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	finapiv1 "github.com/aspirin100/finapi/api/finapi/v1"
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/logger"
+	"github.com/aspirin100/finapi/internal/proxy"
+	"github.com/aspirin100/finapi/internal/service"
+)
+
+var (
+	ErrInvalidFormat  = errors.New("invalid user id format")
+	ErrInvalidAmount  = errors.New("amount must be a positive decimal number")
+	ErrSameUser       = errors.New("receiver and sender must be different person")
+	ErrFeedOverflowed = errors.New("transactions feed overflowed, resubscribe")
+	ErrShuttingDown   = errors.New("server is shutting down, resubscribe")
+)
+
+type TransactionManager interface {
+	CreateAccount(ctx context.Context, accountType string) (*entity.Account, error)
+	GetAccount(ctx context.Context, userID uuid.UUID) (*entity.Account, error)
+	Deposit(ctx context.Context, userID uuid.UUID, amount decimal.Decimal) (*entity.Deposit, error)
+	GetTransactions(ctx context.Context, userID uuid.UUID) ([]entity.Transaction, error)
+	Transfer(ctx context.Context, receiverID, senderID uuid.UUID, amount decimal.Decimal) (*entity.Transaction, error)
+}
+
+// TransactionFeed delivers committed transactions involving the user.
+// Closed tells whether subscriptions ended because the feed itself was closed.
+type TransactionFeed interface {
+	Subscribe(userID uuid.UUID) (<-chan entity.Transaction, func())
+	Closed() bool
+}
+
+type Server struct {
+	finapiv1.UnimplementedFinAPIServer
+
+	addr     string
+	server   *grpc.Server
+	tmanager TransactionManager
+	feed     TransactionFeed
+	proxies  proxy.Trusted
+}
+
+type Option func(s *Server)
+
+// WithTrustedProxies believes the x-actor metadata of calls from the proxies,
+// the metadata of other clients is ignored.
+func WithTrustedProxies(proxies proxy.Trusted) Option {
+	return func(s *Server) {
+		s.proxies = proxies
+	}
+}
+
+func New(hostname, port string, tmanager TransactionManager, feed TransactionFeed, opts ...Option) *Server {
+	srv := &Server{
+		addr:     hostname + ":" + port,
+		tmanager: tmanager,
+		feed:     feed,
+	}
+
+	for _, opt := range opts {
+		opt(srv)
+	}
+
+	srv.server = grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(unaryRequestLogger(srv.proxies)),
+		grpc.ChainStreamInterceptor(streamRequestLogger(srv.proxies)),
+	)
+
+	finapiv1.RegisterFinAPIServer(srv.server, srv)
+
+	return srv
+}
+
+func (s *Server) Run() error {
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen %s: %w", s.addr, err)
+	}
+
+	return s.Serve(listener)
+}
+
+// Serve handles rpc calls accepted by the listener until Shutdown is called.
+func (s *Server) Serve(listener net.Listener) error {
+	err := s.server.Serve(listener)
+	if err != nil {
+		return fmt.Errorf("failed to start grpc server: %w", err)
+	}
+
+	return nil
+}
+
+// Shutdown stops accepting new rpc calls and waits for in-flight ones.
+// Calls not finished until ctx is done are cancelled.
+func (s *Server) Shutdown(ctx context.Context) error {
+	stopped := make(chan struct{})
+
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.server.Stop()
+
+		return fmt.Errorf("failed to correctly stop grpc server: %w", ctx.Err())
+	}
+}
+
+func (s *Server) CreateAccount(ctx context.Context,
+	req *finapiv1.CreateAccountRequest) (*finapiv1.Account, error) {
+	account, err := s.tmanager.CreateAccount(ctx, req.GetType())
+	if err != nil {
+		return nil, statusOnServiceError(ctx, err)
+	}
+
+	return accountToProto(account), nil
+}
+
+func (s *Server) GetAccount(ctx context.Context,
+	req *finapiv1.GetAccountRequest) (*finapiv1.Account, error) {
+	userID, err := parseUserID(req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := s.tmanager.GetAccount(ctx, userID)
+	if err != nil {
+		return nil, statusOnServiceError(ctx, err)
+	}
+
+	return accountToProto(account), nil
+}
+
+func (s *Server) Deposit(ctx context.Context,
+	req *finapiv1.DepositRequest) (*finapiv1.DepositResponse, error) {
+	userID, err := parseUserID(req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	amount, err := parseAmount(req.GetAmount())
+	if err != nil {
+		return nil, err
+	}
+
+	deposit, err := s.tmanager.Deposit(ctx, userID, amount)
+	if err != nil {
+		return nil, statusOnServiceError(ctx, err)
+	}
+
+	return &finapiv1.DepositResponse{
+		Balance: deposit.Balance.String(),
+	}, nil
+}
+
+func (s *Server) Transfer(ctx context.Context,
+	req *finapiv1.TransferRequest) (*finapiv1.Transaction, error) {
+	senderID, err := parseUserID(req.GetSenderId())
+	if err != nil {
+		return nil, err
+	}
+
+	receiverID, err := parseUserID(req.GetReceiverId())
+	if err != nil {
+		return nil, err
+	}
+
+	if senderID == receiverID {
+		return nil, status.Error(codes.InvalidArgument, ErrSameUser.Error())
+	}
+
+	amount, err := parseAmount(req.GetAmount())
+	if err != nil {
+		return nil, err
+	}
+
+	transaction, err := s.tmanager.Transfer(ctx, receiverID, senderID, amount)
+	if err != nil {
+		return nil, statusOnServiceError(ctx, err)
+	}
+
+	return transactionToProto(transaction), nil
+}
+
+func (s *Server) GetTransactions(ctx context.Context,
+	req *finapiv1.GetTransactionsRequest) (*finapiv1.GetTransactionsResponse, error) {
+	userID, err := parseUserID(req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, err := s.tmanager.GetTransactions(ctx, userID)
+	if err != nil {
+		return nil, statusOnServiceError(ctx, err)
+	}
+
+	response := &finapiv1.GetTransactionsResponse{
+		Transactions: make([]*finapiv1.Transaction, 0, len(transactions)),
+	}
+
+	for i := range transactions {
+		response.Transactions = append(response.Transactions, transactionToProto(&transactions[i]))
+	}
+
+	return response, nil
+}
+
+func (s *Server) WatchTransactions(req *finapiv1.WatchTransactionsRequest,
+	stream grpc.ServerStreamingServer[finapiv1.Transaction]) error {
+	userID, err := parseUserID(req.GetUserId())
+	if err != nil {
+		return err
+	}
+
+	feed, unsubscribe := s.feed.Subscribe(userID)
+	defer unsubscribe()
+
+	// headers tell the client that subscription is established
+	err = stream.SendHeader(nil)
+	if err != nil {
+		return fmt.Errorf("failed to send headers: %w", err)
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case transaction, ok := <-feed:
+			if !ok {
+				if s.feed.Closed() {
+					return status.Error(codes.Unavailable, ErrShuttingDown.Error())
+				}
+
+				return status.Error(codes.Unavailable, ErrFeedOverflowed.Error())
+			}
+
+			err = stream.Send(transactionToProto(&transaction))
+			if err != nil {
+				return fmt.Errorf("failed to send transaction: %w", err)
+			}
+		}
+	}
+}
+
+func parseUserID(userID string) (uuid.UUID, error) {
+	parsed, err := uuid.Parse(userID)
+	if err != nil {
+		return uuid.Nil, status.Error(codes.InvalidArgument, ErrInvalidFormat.Error())
+	}
+
+	return parsed, nil
+}
+
+func parseAmount(amount string) (decimal.Decimal, error) {
+	parsed, err := decimal.NewFromString(amount)
+	if err != nil || decimal.Zero.Compare(parsed) >= 0 {
+		return decimal.Zero, status.Error(codes.InvalidArgument, ErrInvalidAmount.Error())
+	}
+
+	return parsed, nil
+}
+
+func statusOnServiceError(ctx context.Context, err error) error {
+	var held *service.HeldError
+
+	switch {
+	case errors.As(err, &held):
+		return status.Errorf(codes.Aborted, "transfer held for review %s", held.Review.ID)
+	case errors.Is(err, service.ErrTransferDenied):
+		return status.Error(codes.PermissionDenied, "transfer denied by risk rules")
+	case errors.Is(err, service.ErrPartyBlocked):
+		return status.Error(codes.PermissionDenied, "operation blocked by sanctions screening")
+	case errors.Is(err, service.ErrAccountType):
+		return status.Error(codes.InvalidArgument, "unknown account type")
+	case errors.Is(err, service.ErrUserNotFound):
+		return status.Error(codes.NotFound, "user not found")
+	case errors.Is(err, service.ErrNegativeBalance):
+		return status.Error(codes.FailedPrecondition, "not enough money on account")
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, "operation timed out")
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, "operation cancelled")
+	default:
+		logger.FromContext(ctx).Error("rpc failed", slog.Any("error", err))
+
+		return status.Error(codes.Internal, "internal error")
+	}
+}
+
+func accountToProto(account *entity.Account) *finapiv1.Account {
+	return &finapiv1.Account{
+		Id:      account.ID.String(),
+		Balance: account.Balance.String(),
+		Type:    account.Type,
+	}
+}
+
+func transactionToProto(transaction *entity.Transaction) *finapiv1.Transaction {
+	return &finapiv1.Transaction{
+		Id:         transaction.ID.String(),
+		SenderId:   transaction.SenderID.String(),
+		ReceiverId: transaction.ReceiverID.String(),
+		Amount:     transaction.Amount.String(),
+		Operation:  transaction.Operation,
+		CreatedAt:  timestamppb.New(transaction.CreatedAt),
+	}
+}
@@ -0,0 +1,472 @@
+package grpcserver_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	finapiv1 "github.com/aspirin100/finapi/api/finapi/v1"
+	"github.com/aspirin100/finapi/internal/audit"
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/events"
+	"github.com/aspirin100/finapi/internal/grpcserver"
+	"github.com/aspirin100/finapi/internal/proxy"
+	"github.com/aspirin100/finapi/internal/service"
+)
+
+const bufSize = 1024 * 1024
+
+var (
+	richUserID = uuid.MustParse("4178f61f-2ff9-4ab5-afa5-f30dc16e6ad9")
+	poorUserID = uuid.MustParse("3fec06e9-29cc-4ff4-9ae7-fb0e7c757b61")
+)
+
+// fakeManager knows only rich and poor users, poor one can't send money.
+// Transfers above 1000 are denied and above 100 are held for review.
+type fakeManager struct {
+	broker *events.Broker
+}
+
+func (m fakeManager) CreateAccount(_ context.Context, accountType string) (*entity.Account, error) {
+	if accountType == "" {
+		accountType = entity.AccountChecking
+	}
+
+	if !entity.ValidAccountType(accountType) {
+		return nil, service.ErrAccountType
+	}
+
+	return &entity.Account{ID: uuid.New(), Balance: decimal.Zero, Type: accountType}, nil
+}
+
+func (m fakeManager) GetAccount(_ context.Context, userID uuid.UUID) (*entity.Account, error) {
+	if userID != richUserID && userID != poorUserID {
+		return nil, service.ErrUserNotFound
+	}
+
+	return &entity.Account{ID: userID, Balance: decimal.NewFromInt(100)}, nil
+}
+
+func (m fakeManager) Deposit(ctx context.Context, userID uuid.UUID, amount decimal.Decimal) (*entity.Deposit, error) {
+	account, err := m.GetAccount(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.Deposit{
+		Transaction: entity.Transaction{ID: uuid.New(), SenderID: userID, ReceiverID: userID, Amount: amount},
+		Balance:     account.Balance.Add(amount),
+	}, nil
+}
+
+func (m fakeManager) GetTransaction(_ context.Context, _ uuid.UUID) (*entity.Transaction, error) {
+	return nil, service.ErrNotFound
+}
+
+func (m fakeManager) GetTransactions(ctx context.Context, userID uuid.UUID) ([]entity.Transaction, error) {
+	_, err := m.GetAccount(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return []entity.Transaction{
+		{ID: uuid.New(), SenderID: userID, ReceiverID: userID, Amount: decimal.NewFromInt(1), Operation: "deposit"},
+	}, nil
+}
+
+func (m fakeManager) GetTransactionsAfter(_ context.Context, _, _ uuid.UUID) ([]entity.Transaction, error) {
+	return nil, nil
+}
+
+func (m fakeManager) Transfer(ctx context.Context,
+	receiverID, senderID uuid.UUID,
+	amount decimal.Decimal) (*entity.Transaction, error) {
+	for _, userID := range []uuid.UUID{receiverID, senderID} {
+		_, err := m.GetAccount(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if senderID == poorUserID {
+		return nil, service.ErrNegativeBalance
+	}
+
+	switch {
+	case amount.GreaterThan(decimal.NewFromInt(1000)):
+		return nil, service.ErrTransferDenied
+	case amount.GreaterThan(decimal.NewFromInt(100)):
+		return nil, &service.HeldError{Review: &entity.Review{ID: uuid.New(), Status: entity.ReviewPending}}
+	}
+
+	transaction := entity.Transaction{
+		ID:         uuid.New(),
+		SenderID:   senderID,
+		ReceiverID: receiverID,
+		Amount:     amount,
+		Operation:  "transfer",
+		CreatedAt:  time.Now(),
+	}
+
+	m.broker.Publish(transaction)
+
+	return &transaction, nil
+}
+
+func (m fakeManager) GetReview(_ context.Context, _ uuid.UUID) (*entity.Review, error) {
+	return nil, service.ErrNotFound
+}
+
+func (m fakeManager) GetReviews(_ context.Context, _ string) ([]entity.Review, error) {
+	return []entity.Review{}, nil
+}
+
+func (m fakeManager) ApproveReview(_ context.Context, _ uuid.UUID) (*entity.Review, error) {
+	return nil, service.ErrNotFound
+}
+
+func (m fakeManager) RejectReview(_ context.Context, _ uuid.UUID) (*entity.Review, error) {
+	return nil, service.ErrNotFound
+}
+
+func (m fakeManager) GetBlockedParties(_ context.Context) ([]entity.BlockedParty, error) {
+	return []entity.BlockedParty{}, nil
+}
+
+func (m fakeManager) BlockParty(_ context.Context, partyID uuid.UUID, reason string) (*entity.BlockedParty, error) {
+	return &entity.BlockedParty{ID: partyID, Reason: reason}, nil
+}
+
+func (m fakeManager) UnblockParty(_ context.Context, _ uuid.UUID) error {
+	return service.ErrNotFound
+}
+
+func (m fakeManager) QuoteFee(_ context.Context,
+	operation string,
+	accountID uuid.UUID,
+	amount decimal.Decimal) (*entity.FeeQuote, error) {
+	return &entity.FeeQuote{
+		Operation:     operation,
+		AccountID:     accountID,
+		Tier:          entity.TierStandard,
+		Amount:        amount,
+		Fee:           decimal.NewFromInt(1),
+		BalanceChange: decimal.Zero.Sub(amount).Sub(decimal.NewFromInt(1)),
+	}, nil
+}
+
+func (m fakeManager) UpdateAccountTier(_ context.Context, userID uuid.UUID, tier string) (*entity.Account, error) {
+	return &entity.Account{ID: userID, Tier: tier}, nil
+}
+
+func newClient(t *testing.T) (finapiv1.FinAPIClient, *events.Broker) {
+	t.Helper()
+
+	broker := events.NewBroker()
+	srv := grpcserver.New("localhost", "0", fakeManager{broker: broker}, broker)
+
+	listener := bufconn.Listen(bufSize)
+
+	go srv.Serve(listener) //nolint:errcheck
+
+	t.Cleanup(func() {
+		broker.Close()
+		require.NoError(t, srv.Shutdown(context.Background()))
+	})
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+
+	t.Cleanup(func() { conn.Close() })
+
+	return finapiv1.NewFinAPIClient(conn), broker
+}
+
+func TestDeposit(t *testing.T) {
+	client, _ := newClient(t)
+
+	cases := []struct {
+		Name            string
+		Request         *finapiv1.DepositRequest
+		ExpectedCode    codes.Code
+		ExpectedBalance string
+	}{
+		{
+			Name:            "ok case",
+			Request:         &finapiv1.DepositRequest{UserId: richUserID.String(), Amount: "10.5"},
+			ExpectedCode:    codes.OK,
+			ExpectedBalance: "110.5",
+		},
+		{
+			Name:         "user not found case",
+			Request:      &finapiv1.DepositRequest{UserId: uuid.NewString(), Amount: "10"},
+			ExpectedCode: codes.NotFound,
+		},
+		{
+			Name:         "invalid user id case",
+			Request:      &finapiv1.DepositRequest{UserId: "not-uuid", Amount: "10"},
+			ExpectedCode: codes.InvalidArgument,
+		},
+		{
+			Name:         "negative amount case",
+			Request:      &finapiv1.DepositRequest{UserId: richUserID.String(), Amount: "-1"},
+			ExpectedCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tcase := range cases {
+		t.Run(tcase.Name, func(t *testing.T) {
+			resp, err := client.Deposit(context.Background(), tcase.Request)
+
+			require.Equal(t, tcase.ExpectedCode, status.Code(err))
+			require.Equal(t, tcase.ExpectedBalance, resp.GetBalance())
+		})
+	}
+}
+
+func TestTransfer(t *testing.T) {
+	client, _ := newClient(t)
+
+	cases := []struct {
+		Name         string
+		Request      *finapiv1.TransferRequest
+		ExpectedCode codes.Code
+	}{
+		{
+			Name: "ok case",
+			Request: &finapiv1.TransferRequest{
+				SenderId: richUserID.String(), ReceiverId: poorUserID.String(), Amount: "1",
+			},
+			ExpectedCode: codes.OK,
+		},
+		{
+			Name: "negative balance case",
+			Request: &finapiv1.TransferRequest{
+				SenderId: poorUserID.String(), ReceiverId: richUserID.String(), Amount: "1",
+			},
+			ExpectedCode: codes.FailedPrecondition,
+		},
+		{
+			Name: "user not found case",
+			Request: &finapiv1.TransferRequest{
+				SenderId: richUserID.String(), ReceiverId: uuid.NewString(), Amount: "1",
+			},
+			ExpectedCode: codes.NotFound,
+		},
+		{
+			Name: "same user case",
+			Request: &finapiv1.TransferRequest{
+				SenderId: richUserID.String(), ReceiverId: richUserID.String(), Amount: "1",
+			},
+			ExpectedCode: codes.InvalidArgument,
+		},
+		{
+			Name: "held for review case",
+			Request: &finapiv1.TransferRequest{
+				SenderId: richUserID.String(), ReceiverId: poorUserID.String(), Amount: "101",
+			},
+			ExpectedCode: codes.Aborted,
+		},
+		{
+			Name: "denied case",
+			Request: &finapiv1.TransferRequest{
+				SenderId: richUserID.String(), ReceiverId: poorUserID.String(), Amount: "1001",
+			},
+			ExpectedCode: codes.PermissionDenied,
+		},
+	}
+
+	for _, tcase := range cases {
+		t.Run(tcase.Name, func(t *testing.T) {
+			transaction, err := client.Transfer(context.Background(), tcase.Request)
+
+			require.Equal(t, tcase.ExpectedCode, status.Code(err))
+
+			if err == nil {
+				require.Equal(t, tcase.Request.GetSenderId(), transaction.GetSenderId())
+				require.Equal(t, tcase.Request.GetReceiverId(), transaction.GetReceiverId())
+				require.Equal(t, "transfer", transaction.GetOperation())
+			}
+		})
+	}
+}
+
+func TestAccountsAndTransactions(t *testing.T) {
+	client, _ := newClient(t)
+
+	ctx := context.Background()
+
+	account, err := client.CreateAccount(ctx, &finapiv1.CreateAccountRequest{})
+	require.NoError(t, err)
+	require.Equal(t, "0", account.GetBalance())
+	require.Equal(t, entity.AccountChecking, account.GetType())
+
+	account, err = client.CreateAccount(ctx, &finapiv1.CreateAccountRequest{Type: entity.AccountSavings})
+	require.NoError(t, err)
+	require.Equal(t, entity.AccountSavings, account.GetType())
+
+	_, err = client.CreateAccount(ctx, &finapiv1.CreateAccountRequest{Type: "gold"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	account, err = client.GetAccount(ctx, &finapiv1.GetAccountRequest{UserId: richUserID.String()})
+	require.NoError(t, err)
+	require.Equal(t, "100", account.GetBalance())
+
+	_, err = client.GetAccount(ctx, &finapiv1.GetAccountRequest{UserId: uuid.NewString()})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	var header metadata.MD
+
+	ctx = metadata.AppendToOutgoingContext(ctx, "x-request-id", "grpc-request")
+
+	list, err := client.GetTransactions(ctx,
+		&finapiv1.GetTransactionsRequest{UserId: richUserID.String()},
+		grpc.Header(&header))
+	require.NoError(t, err)
+	require.Len(t, list.GetTransactions(), 1)
+	require.Equal(t, []string{"grpc-request"}, header.Get("x-request-id"))
+}
+
+func TestWatchTransactions(t *testing.T) {
+	client, broker := newClient(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.WatchTransactions(ctx, &finapiv1.WatchTransactionsRequest{UserId: poorUserID.String()})
+	require.NoError(t, err)
+
+	// headers are sent once the subscription is established
+	_, err = stream.Header()
+	require.NoError(t, err)
+
+	_, err = client.Transfer(ctx, &finapiv1.TransferRequest{
+		SenderId: richUserID.String(), ReceiverId: poorUserID.String(), Amount: "7",
+	})
+	require.NoError(t, err)
+
+	transaction, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "7", transaction.GetAmount())
+	require.Equal(t, poorUserID.String(), transaction.GetReceiverId())
+
+	broker.Close()
+
+	_, err = stream.Recv()
+	require.Equal(t, codes.Unavailable, status.Code(err))
+	require.Equal(t, grpcserver.ErrShuttingDown.Error(), status.Convert(err).Message())
+}
+
+func TestWatchTransactionsOverflow(t *testing.T) {
+	client, broker := newClient(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.WatchTransactions(ctx, &finapiv1.WatchTransactionsRequest{UserId: poorUserID.String()})
+	require.NoError(t, err)
+
+	_, err = stream.Header()
+	require.NoError(t, err)
+
+	// the stream isn't read, so sends block on flow control and the subscription overflows
+	for range 10000 {
+		broker.Publish(entity.Transaction{ID: uuid.New(), SenderID: poorUserID, ReceiverID: poorUserID})
+	}
+
+	for err == nil {
+		_, err = stream.Recv()
+	}
+
+	require.Equal(t, codes.Unavailable, status.Code(err))
+	require.Equal(t, grpcserver.ErrFeedOverflowed.Error(), status.Convert(err).Message())
+}
+
+// actorManager opens accounts with the audit actor of the call as the type.
+type actorManager struct {
+	fakeManager
+}
+
+func (actorManager) CreateAccount(ctx context.Context, accountType string) (*entity.Account, error) {
+	return &entity.Account{ID: uuid.New(), Type: audit.Actor(ctx)}, nil
+}
+
+func TestActor(t *testing.T) {
+	trusted, err := proxy.ParseTrusted("127.0.0.1")
+	require.NoError(t, err)
+
+	newTCPClient := func(opts ...grpcserver.Option) finapiv1.FinAPIClient {
+		broker := events.NewBroker()
+		srv := grpcserver.New("localhost", "0", actorManager{}, broker, opts...)
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+
+		go srv.Serve(listener) //nolint:errcheck
+
+		t.Cleanup(func() {
+			broker.Close()
+			require.NoError(t, srv.Shutdown(context.Background()))
+		})
+
+		conn, err := grpc.NewClient(listener.Addr().String(),
+			grpc.WithTransportCredentials(insecure.NewCredentials()))
+		require.NoError(t, err)
+
+		t.Cleanup(func() { conn.Close() })
+
+		return finapiv1.NewFinAPIClient(conn)
+	}
+
+	direct, proxied := newTCPClient(), newTCPClient(grpcserver.WithTrustedProxies(trusted))
+
+	cases := []struct {
+		Name          string
+		Client        finapiv1.FinAPIClient
+		Metadata      []string
+		ExpectedActor string
+	}{
+		{
+			Name:          "metadata from trusted proxy",
+			Client:        proxied,
+			Metadata:      []string{"x-actor", "alice"},
+			ExpectedActor: "alice",
+		},
+		{
+			Name:          "metadata from untrusted client",
+			Client:        direct,
+			Metadata:      []string{"x-actor", "alice"},
+			ExpectedActor: audit.ActorAnonymous,
+		},
+		{
+			Name:          "trusted proxy without metadata",
+			Client:        proxied,
+			ExpectedActor: audit.ActorAnonymous,
+		},
+	}
+
+	for _, tcase := range cases {
+		t.Run(tcase.Name, func(t *testing.T) {
+			ctx := metadata.AppendToOutgoingContext(context.Background(), tcase.Metadata...)
+
+			resp, err := tcase.Client.CreateAccount(ctx, &finapiv1.CreateAccountRequest{})
+			require.NoError(t, err)
+			require.Equal(t, tcase.ExpectedActor, resp.GetType())
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type blockPartyRequestBody struct {
+	ID     uuid.UUID `json:"id"`
+	Reason string    `json:"reason"`
+}
+
+func (h *Handler) ListBlockedParties(ctx *gin.Context) {
+	parties, err := h.tmanager.GetBlockedParties(ctx.Request.Context())
+	if err != nil {
+		responseOnServiceError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, parties)
+}
+
+func (h *Handler) BlockParty(ctx *gin.Context) {
+	var body blockPartyRequestBody
+
+	err := decodeBody(ctx.Request, &body)
+	if err != nil {
+		responseOnValidationErr(ctx, err)
+
+		return
+	}
+
+	if body.ID == uuid.Nil {
+		responseOnValidationErr(ctx, ErrInvalidFormat)
+
+		return
+	}
+
+	party, err := h.tmanager.BlockParty(ctx.Request.Context(), body.ID, body.Reason)
+	if err != nil {
+		responseOnServiceError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, party)
+}
+
+func (h *Handler) UnblockParty(ctx *gin.Context) {
+	partyID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		responseOnValidationErr(ctx, ErrInvalidFormat)
+
+		return
+	}
+
+	err = h.tmanager.UnblockParty(ctx.Request.Context(), partyID)
+	if err != nil {
+		responseOnServiceError(ctx, err)
+
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
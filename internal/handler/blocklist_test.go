@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/service"
+)
+
+var blockedPartyID = uuid.New()
+
+// screeningManager blocks operations of blockedPartyID, which is the only one on the blocklist.
+type screeningManager struct {
+	fakeManager
+}
+
+func (m screeningManager) Deposit(ctx context.Context,
+	userID uuid.UUID,
+	amount decimal.Decimal) (*entity.Deposit, error) {
+	if userID == blockedPartyID {
+		return nil, service.ErrPartyBlocked
+	}
+
+	return m.fakeManager.Deposit(ctx, userID, amount)
+}
+
+func (screeningManager) GetBlockedParties(_ context.Context) ([]entity.BlockedParty, error) {
+	return []entity.BlockedParty{{ID: blockedPartyID, Reason: "sanctions list"}}, nil
+}
+
+func (screeningManager) UnblockParty(_ context.Context, partyID uuid.UUID) error {
+	if partyID != blockedPartyID {
+		return service.ErrNotFound
+	}
+
+	return nil
+}
+
+func TestBlocklist(t *testing.T) {
+	srv := newTestServer(t, screeningManager{})
+
+	partyID := uuid.NewString()
+
+	cases := []struct {
+		Name           string
+		Method         string
+		Path           string
+		Body           string
+		ExpectedStatus int
+	}{
+		{
+			Name:           "blocked deposit",
+			Method:         http.MethodPost,
+			Path:           "/v1/accounts/" + blockedPartyID.String() + "/deposits",
+			Body:           `{"amount": 10}`,
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Name:           "list",
+			Method:         http.MethodGet,
+			Path:           "/v1/admin/blocklist",
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "block",
+			Method:         http.MethodPost,
+			Path:           "/v1/admin/blocklist",
+			Body:           `{"id": "` + partyID + `", "reason": "fraud"}`,
+			ExpectedStatus: http.StatusCreated,
+		},
+		{
+			Name:           "block without id",
+			Method:         http.MethodPost,
+			Path:           "/v1/admin/blocklist",
+			Body:           `{"reason": "fraud"}`,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "unblock",
+			Method:         http.MethodDelete,
+			Path:           "/v1/admin/blocklist/" + blockedPartyID.String(),
+			ExpectedStatus: http.StatusNoContent,
+		},
+		{
+			Name:           "unblock unknown",
+			Method:         http.MethodDelete,
+			Path:           "/v1/admin/blocklist/" + partyID,
+			ExpectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tcase := range cases {
+		t.Run(tcase.Name, func(t *testing.T) {
+			resp := doOperatorRequest(t, tcase.Method, srv.URL+tcase.Path, tcase.Body)
+			require.Equal(t, tcase.ExpectedStatus, resp.StatusCode)
+		})
+	}
+
+	resp := doOperatorRequest(t, http.MethodGet, srv.URL+"/v1/admin/blocklist", "")
+
+	var parties []entity.BlockedParty
+
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&parties))
+	require.Equal(t, []entity.BlockedParty{{ID: blockedPartyID, Reason: "sanctions list"}}, parties)
+}
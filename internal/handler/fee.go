@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+type accountTierRequestBody struct {
+	Tier string `json:"tier"`
+}
+
+// quotedOperations are operations charged with fees.
+var quotedOperations = map[string]bool{
+	"transfer": true,
+	"deposit":  true,
+}
+
+func (h *Handler) QuoteFee(ctx *gin.Context) {
+	operation := ctx.DefaultQuery("operation", "transfer")
+	if !quotedOperations[operation] {
+		writeError(ctx, http.StatusBadRequest, "unknown operation")
+
+		return
+	}
+
+	accountID, err := uuid.Parse(ctx.Query("accountID"))
+	if err != nil {
+		responseOnValidationErr(ctx, ErrInvalidFormat)
+
+		return
+	}
+
+	amount, err := decimal.NewFromString(ctx.Query("amount"))
+	if err != nil || !amount.IsPositive() {
+		responseOnValidationErr(ctx, ErrNegativeAmount)
+
+		return
+	}
+
+	quote, err := h.tmanager.QuoteFee(ctx.Request.Context(), operation, accountID, amount)
+	if err != nil {
+		responseOnServiceError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, quote)
+}
+
+// UpdateAccountTier moves the account to the tier in the body, which chooses its fee schedules.
+func (h *Handler) UpdateAccountTier(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		responseOnValidationErr(ctx, ErrInvalidFormat)
+
+		return
+	}
+
+	var body accountTierRequestBody
+
+	err = decodeBody(ctx.Request, &body)
+	if err != nil {
+		responseOnValidationErr(ctx, err)
+
+		return
+	}
+
+	account, err := h.tmanager.UpdateAccountTier(ctx.Request.Context(), userID, body.Tier)
+	if err != nil {
+		responseOnServiceError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, account)
+}
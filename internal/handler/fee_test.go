@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aspirin100/finapi/internal/entity"
+)
+
+func TestQuoteFee(t *testing.T) {
+	srv := newTestServer(t, fakeManager{})
+
+	accountID := uuid.NewString()
+
+	cases := []struct {
+		Name                  string
+		Query                 string
+		ExpectedStatus        int
+		ExpectedBalanceChange string
+	}{
+		{
+			Name:                  "transfer",
+			Query:                 "?operation=transfer&accountID=" + accountID + "&amount=10.5",
+			ExpectedStatus:        http.StatusOK,
+			ExpectedBalanceChange: "-11.5",
+		},
+		{
+			Name:                  "default operation",
+			Query:                 "?accountID=" + accountID + "&amount=10",
+			ExpectedStatus:        http.StatusOK,
+			ExpectedBalanceChange: "-11",
+		},
+		{
+			Name:           "unknown operation",
+			Query:          "?operation=withdrawal&accountID=" + accountID + "&amount=10",
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "malformed account id",
+			Query:          "?accountID=42&amount=10",
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "negative amount",
+			Query:          "?accountID=" + accountID + "&amount=-10",
+			ExpectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tcase := range cases {
+		t.Run(tcase.Name, func(t *testing.T) {
+			resp := doRequest(t, http.MethodGet, srv.URL+"/v1/fees/quote"+tcase.Query, "")
+			require.Equal(t, tcase.ExpectedStatus, resp.StatusCode)
+
+			if tcase.ExpectedBalanceChange == "" {
+				return
+			}
+
+			var quote entity.FeeQuote
+
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&quote))
+			require.Equal(t, tcase.ExpectedBalanceChange, quote.BalanceChange.String())
+		})
+	}
+}
+
+func TestUpdateAccountTier(t *testing.T) {
+	srv := newTestServer(t, fakeManager{})
+
+	accountID := uuid.NewString()
+
+	cases := []struct {
+		Name           string
+		Path           string
+		Body           string
+		ExpectedStatus int
+		ExpectedTier   string
+	}{
+		{
+			Name:           "premium",
+			Path:           "/v1/admin/accounts/" + accountID + "/tier",
+			Body:           `{"tier": "premium"}`,
+			ExpectedStatus: http.StatusOK,
+			ExpectedTier:   "premium",
+		},
+		{
+			Name:           "invalid tier",
+			Path:           "/v1/admin/accounts/" + accountID + "/tier",
+			Body:           `{"tier": "Gold Plus"}`,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "malformed account id",
+			Path:           "/v1/admin/accounts/42/tier",
+			Body:           `{"tier": "premium"}`,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tcase := range cases {
+		t.Run(tcase.Name, func(t *testing.T) {
+			resp := doOperatorRequest(t, http.MethodPut, srv.URL+tcase.Path, tcase.Body)
+			require.Equal(t, tcase.ExpectedStatus, resp.StatusCode)
+
+			if tcase.ExpectedTier == "" {
+				return
+			}
+
+			var account entity.Account
+
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&account))
+			require.Equal(t, tcase.ExpectedTier, account.Tier)
+		})
+	}
+
+	resp := doRequest(t, http.MethodPut, srv.URL+"/v1/admin/accounts/"+accountID+"/tier", `{"tier": "premium"}`)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
@@ -5,19 +5,28 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
 
 	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/logger"
+	"github.com/aspirin100/finapi/internal/metrics"
+	"github.com/aspirin100/finapi/internal/operator"
+	"github.com/aspirin100/finapi/internal/proxy"
 	"github.com/aspirin100/finapi/internal/service"
+	"github.com/aspirin100/finapi/internal/tracing"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 var (
 	ErrInvalidFormat  = errors.New("invalid user id format")
 	ErrNegativeAmount = errors.New("deposit amount must be positive")
 	ErrSameUser       = errors.New("receiver and sender must be different person")
+	ErrInvalidBody    = errors.New("invalid request body")
 )
 
 type depositRequestParams struct {
@@ -32,26 +41,95 @@ type transferRequestParams struct {
 }
 
 type TransactionManager interface {
-	Deposit(ctx context.Context, userID uuid.UUID, amount decimal.Decimal) (*decimal.Decimal, error)
+	CreateAccount(ctx context.Context, accountType string) (*entity.Account, error)
+	GetAccount(ctx context.Context, userID uuid.UUID) (*entity.Account, error)
+	Deposit(ctx context.Context, userID uuid.UUID, amount decimal.Decimal) (*entity.Deposit, error)
+	GetTransaction(ctx context.Context, transactionID uuid.UUID) (*entity.Transaction, error)
 	GetTransactions(ctx context.Context, userID uuid.UUID) ([]entity.Transaction, error)
+	GetTransactionsAfter(ctx context.Context, userID, afterID uuid.UUID) ([]entity.Transaction, error)
 	Transfer(ctx context.Context, receiverID, senderID uuid.UUID, amount decimal.Decimal) (*entity.Transaction, error)
+	GetReview(ctx context.Context, reviewID uuid.UUID) (*entity.Review, error)
+	GetReviews(ctx context.Context, status string) ([]entity.Review, error)
+	ApproveReview(ctx context.Context, reviewID uuid.UUID) (*entity.Review, error)
+	RejectReview(ctx context.Context, reviewID uuid.UUID) (*entity.Review, error)
+	GetBlockedParties(ctx context.Context) ([]entity.BlockedParty, error)
+	BlockParty(ctx context.Context, partyID uuid.UUID, reason string) (*entity.BlockedParty, error)
+	UnblockParty(ctx context.Context, partyID uuid.UUID) error
+	QuoteFee(ctx context.Context, operation string, accountID uuid.UUID, amount decimal.Decimal) (*entity.FeeQuote, error)
+	UpdateAccountTier(ctx context.Context, userID uuid.UUID, tier string) (*entity.Account, error)
+}
+
+type ReadinessChecker interface {
+	Ready(ctx context.Context) error
 }
 
 type Handler struct {
-	server   *http.Server
-	tmanager TransactionManager
+	server    *http.Server
+	tmanager  TransactionManager
+	readiness ReadinessChecker
+	feed      TransactionFeed
+	validator *SpecValidator
+	limiter   RateLimiter
+	breaker   CircuitBreaker
+	operators operator.Tokens
+	proxies   proxy.Trusted
 }
 
-func New(hostname, port string, tmanager TransactionManager) *Handler {
+type Option func(h *Handler)
+
+func New(hostname, port string,
+	tmanager TransactionManager,
+	readiness ReadinessChecker,
+	feed TransactionFeed,
+	opts ...Option) *Handler {
 	handler := &Handler{
-		tmanager: tmanager,
+		tmanager:  tmanager,
+		readiness: readiness,
+		feed:      feed,
 	}
 
-	router := gin.Default()
+	for _, opt := range opts {
+		opt(handler)
+	}
 
-	router.GET("/:userID/transactions", handler.GetUserTransactions)
-	router.PATCH("/:userID/deposit", handler.Deposit)
-	router.PATCH("/:userID/transfer", handler.TransferMoney)
+	router := gin.New()
+	router.Use(
+		gin.Recovery(),
+		otelgin.Middleware(tracing.ServiceName),
+		requestLogger(),
+		actor(handler.operators, handler.proxies),
+		metrics.GinMiddleware(),
+		jsonErrors("/v1/"))
+
+	if handler.limiter != nil {
+		router.Use(rateLimit(handler.limiter, handler.operators))
+	}
+
+	if handler.breaker != nil {
+		router.Use(unavailable(handler.breaker))
+	}
+
+	if handler.validator != nil {
+		router.Use(handler.validator.middleware())
+	}
+
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+	router.GET("/healthz", handler.Liveness)
+	router.GET("/readyz", handler.Readiness)
+	router.GET("/openapi.yml", handler.OpenAPI)
+	router.GET("/docs", handler.Docs)
+
+	handler.registerV1(router.Group("/v1"))
+
+	// legacy routes, kept until clients move to /v1
+	router.GET("/:userID/transactions",
+		deprecated("/v1/accounts/:userID/transactions"), handler.GetUserTransactions)
+	router.PATCH("/:userID/deposit",
+		deprecated("/v1/accounts/:userID/deposits"), handler.Deposit)
+	router.PATCH("/:userID/transfer",
+		deprecated("/v1/transfers"), handler.TransferMoney)
+	router.GET("/:userID/stream",
+		deprecated("/v1/accounts/:userID/stream"), handler.StreamTransactions)
 
 	srv := &http.Server{ //nolint:gosec
 		Addr:    hostname + ":" + port,
@@ -64,31 +142,64 @@ func New(hostname, port string, tmanager TransactionManager) *Handler {
 }
 
 func (h *Handler) Run() error {
-	err := h.server.ListenAndServe()
+	listener, err := net.Listen("tcp", h.server.Addr)
 	if err != nil {
+		return fmt.Errorf("failed to listen %s: %w", h.server.Addr, err)
+	}
+
+	return h.Serve(listener)
+}
+
+// Serve handles requests accepted by the listener until Shutdown is called.
+func (h *Handler) Serve(listener net.Listener) error {
+	err := h.server.Serve(listener)
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		return fmt.Errorf("failed to start http server: %w", err)
 	}
 
 	return nil
 }
 
+// Shutdown stops accepting new requests and waits for in-flight ones.
+// Connections of requests not finished until ctx is done are closed forcibly.
 func (h *Handler) Shutdown(ctx context.Context) error {
 	err := h.server.Shutdown(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to correctly stop http server: %w", err)
+		return fmt.Errorf("failed to correctly stop http server: %w", errors.Join(err, h.server.Close()))
 	}
 
 	return nil
 }
 
+// Liveness reports that the process is alive.
+func (h *Handler) Liveness(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readiness reports whether the application is ready to serve traffic.
+func (h *Handler) Readiness(ctx *gin.Context) {
+	err := h.readiness.Ready(ctx.Request.Context())
+	if err != nil {
+		logger.FromContext(ctx.Request.Context()).Warn("application is not ready", slog.Any("error", err))
+
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"status": "not ready", "error": err.Error()})
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "ready"})
+}
+
 func (h *Handler) GetUserTransactions(ctx *gin.Context) {
 	userIDarsed, err := uuid.Parse(ctx.Param("userID"))
 	if err != nil {
-		ctx.String(http.StatusNotFound, "user not found")
+		writeError(ctx, http.StatusNotFound, "user not found")
+
+		return
 	}
 
 	transactions, err := h.tmanager.GetTransactions(
-		ctx,
+		ctx.Request.Context(),
 		userIDarsed)
 	if err != nil {
 		responseOnServiceError(ctx, err)
@@ -103,9 +214,11 @@ func (h *Handler) Deposit(ctx *gin.Context) {
 	params, err := validateDepositRequest(ctx.Param("userID"), ctx.Request)
 	if err != nil {
 		responseOnValidationErr(ctx, err)
+
+		return
 	}
 
-	currentBalance, err := h.tmanager.Deposit(ctx, params.UserID, params.Amount)
+	deposit, err := h.tmanager.Deposit(ctx.Request.Context(), params.UserID, params.Amount)
 	if err != nil {
 		responseOnServiceError(ctx, err)
 
@@ -115,7 +228,7 @@ func (h *Handler) Deposit(ctx *gin.Context) {
 	response := struct {
 		Balance decimal.Decimal `json:"balance"`
 	}{
-		Balance: *currentBalance,
+		Balance: deposit.Balance,
 	}
 
 	ctx.JSON(http.StatusOK, response)
@@ -125,14 +238,20 @@ func (h *Handler) TransferMoney(ctx *gin.Context) {
 	params, err := validateTransferRequest(ctx.Param("userID"), ctx.Request)
 	if err != nil {
 		responseOnValidationErr(ctx, err)
+
+		return
 	}
 
 	transaction, err := h.tmanager.Transfer(
-		ctx,
+		ctx.Request.Context(),
 		params.ReceiverID,
 		params.SenderID,
 		params.Amount)
 	if err != nil {
+		if respondHeld(ctx, err) {
+			return
+		}
+
 		responseOnServiceError(ctx, err)
 
 		return
@@ -151,11 +270,9 @@ func validateDepositRequest(
 
 	var params depositRequestParams
 
-	decoder := json.NewDecoder(req.Body)
-
-	err = decoder.Decode(&params)
+	err = decodeBody(req, &params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal request body: %w", err)
+		return nil, err
 	}
 
 	if decimal.Zero.Compare(params.Amount) >= 0 {
@@ -177,53 +294,82 @@ func validateTransferRequest(
 
 	var params transferRequestParams
 
-	decoder := json.NewDecoder(req.Body)
-
-	err = decoder.Decode(&params)
+	err = decodeBody(req, &params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal request body: %w", err)
+		return nil, err
 	}
 
-	receiverIDParsed, err := uuid.Parse(params.ReceiverID.String())
+	params.SenderID = senderIDParsed
+
+	err = validateTransfer(&params)
 	if err != nil {
-		return nil, ErrInvalidFormat
+		return nil, err
 	}
 
-	if receiverIDParsed == senderIDParsed {
-		return nil, ErrSameUser
+	return &params, nil
+}
+
+func validateTransfer(params *transferRequestParams) error {
+	if params.SenderID == uuid.Nil || params.ReceiverID == uuid.Nil {
+		return ErrInvalidFormat
+	}
+
+	if params.ReceiverID == params.SenderID {
+		return ErrSameUser
 	}
 
 	if decimal.Zero.Compare(params.Amount) >= 0 {
-		return nil, ErrNegativeAmount
+		return ErrNegativeAmount
+	}
+
+	return nil
+}
+
+func decodeBody(req *http.Request, params any) error {
+	err := json.NewDecoder(req.Body).Decode(params)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidBody, err)
 	}
 
-	return &transferRequestParams{
-		SenderID:   senderIDParsed,
-		ReceiverID: receiverIDParsed,
-		Amount:     params.Amount,
-	}, nil
+	return nil
 }
 
 func responseOnValidationErr(ctx *gin.Context, err error) {
 	switch {
 	case errors.Is(err, ErrInvalidFormat):
-		ctx.String(http.StatusBadRequest, "wrong user id format")
+		writeError(ctx, http.StatusBadRequest, "wrong user id format")
 	case errors.Is(err, ErrNegativeAmount):
-		ctx.String(http.StatusBadRequest, "amount must be positive")
+		writeError(ctx, http.StatusBadRequest, "amount must be positive")
 	case errors.Is(err, ErrSameUser):
-		ctx.String(http.StatusBadRequest, "can't transfer money to the same account")
+		writeError(ctx, http.StatusBadRequest, "can't transfer money to the same account")
+	case errors.Is(err, ErrInvalidBody):
+		writeError(ctx, http.StatusBadRequest, "invalid request body")
 	default:
-		ctx.Status(http.StatusInternalServerError)
+		writeError(ctx, http.StatusInternalServerError, "")
 	}
 }
 
 func responseOnServiceError(ctx *gin.Context, err error) {
 	switch {
 	case errors.Is(err, service.ErrUserNotFound):
-		ctx.String(http.StatusNotFound, "user not found")
+		writeError(ctx, http.StatusNotFound, "user not found")
+	case errors.Is(err, service.ErrNotFound):
+		writeError(ctx, http.StatusNotFound, "not found")
 	case errors.Is(err, service.ErrNegativeBalance):
-		ctx.String(http.StatusBadRequest, "not enough money on account")
+		writeError(ctx, http.StatusBadRequest, "not enough money on account")
+	case errors.Is(err, service.ErrTransferDenied):
+		writeError(ctx, http.StatusForbidden, "transfer denied by risk rules")
+	case errors.Is(err, service.ErrPartyBlocked):
+		writeError(ctx, http.StatusForbidden, "operation blocked by sanctions screening")
+	case errors.Is(err, service.ErrAccountType):
+		writeError(ctx, http.StatusBadRequest, "unknown account type")
+	case errors.Is(err, service.ErrAccountTier):
+		writeError(ctx, http.StatusBadRequest, "invalid account tier")
+	case errors.Is(err, service.ErrReviewDecided):
+		writeError(ctx, http.StatusConflict, "review is already decided")
 	default:
-		ctx.Status(http.StatusInternalServerError)
+		logger.FromContext(ctx.Request.Context()).Error("request failed", slog.Any("error", err))
+
+		writeError(ctx, http.StatusInternalServerError, "")
 	}
 }
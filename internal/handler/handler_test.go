@@ -0,0 +1,422 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/events"
+	"github.com/aspirin100/finapi/internal/metrics"
+	"github.com/aspirin100/finapi/internal/operator"
+	"github.com/aspirin100/finapi/internal/service"
+	"github.com/aspirin100/finapi/internal/tracing"
+)
+
+// operatorToken authenticates operatorName on test servers.
+const (
+	operatorName  = "alice"
+	operatorToken = "s3cr3t"
+)
+
+type fakeManager struct{}
+
+func (fakeManager) CreateAccount(_ context.Context, accountType string) (*entity.Account, error) {
+	if accountType == "" {
+		accountType = entity.AccountChecking
+	}
+
+	if !entity.ValidAccountType(accountType) {
+		return nil, service.ErrAccountType
+	}
+
+	return &entity.Account{ID: uuid.New(), Type: accountType}, nil
+}
+
+func (fakeManager) GetAccount(_ context.Context, userID uuid.UUID) (*entity.Account, error) {
+	return &entity.Account{ID: userID}, nil
+}
+
+func (fakeManager) Deposit(_ context.Context, userID uuid.UUID, amount decimal.Decimal) (*entity.Deposit, error) {
+	return &entity.Deposit{
+		Transaction: entity.Transaction{
+			ID:         uuid.New(),
+			SenderID:   userID,
+			ReceiverID: userID,
+			Amount:     amount,
+			Operation:  "deposit",
+		},
+		Balance: amount,
+	}, nil
+}
+
+func (fakeManager) GetTransaction(_ context.Context, _ uuid.UUID) (*entity.Transaction, error) {
+	return nil, service.ErrNotFound
+}
+
+func (fakeManager) GetTransactions(_ context.Context, _ uuid.UUID) ([]entity.Transaction, error) {
+	return []entity.Transaction{}, nil
+}
+
+func (fakeManager) GetTransactionsAfter(_ context.Context, _, _ uuid.UUID) ([]entity.Transaction, error) {
+	return []entity.Transaction{}, nil
+}
+
+func (fakeManager) Transfer(_ context.Context, _, _ uuid.UUID, _ decimal.Decimal) (*entity.Transaction, error) {
+	return nil, service.ErrUserNotFound
+}
+
+func (fakeManager) GetReview(_ context.Context, _ uuid.UUID) (*entity.Review, error) {
+	return nil, service.ErrNotFound
+}
+
+func (fakeManager) GetReviews(_ context.Context, _ string) ([]entity.Review, error) {
+	return []entity.Review{}, nil
+}
+
+func (fakeManager) ApproveReview(_ context.Context, _ uuid.UUID) (*entity.Review, error) {
+	return nil, service.ErrNotFound
+}
+
+func (fakeManager) RejectReview(_ context.Context, _ uuid.UUID) (*entity.Review, error) {
+	return nil, service.ErrNotFound
+}
+
+func (fakeManager) GetBlockedParties(_ context.Context) ([]entity.BlockedParty, error) {
+	return []entity.BlockedParty{}, nil
+}
+
+func (fakeManager) BlockParty(_ context.Context, partyID uuid.UUID, reason string) (*entity.BlockedParty, error) {
+	return &entity.BlockedParty{ID: partyID, Reason: reason}, nil
+}
+
+func (fakeManager) UnblockParty(_ context.Context, _ uuid.UUID) error {
+	return service.ErrNotFound
+}
+
+func (fakeManager) QuoteFee(_ context.Context,
+	operation string,
+	accountID uuid.UUID,
+	amount decimal.Decimal) (*entity.FeeQuote, error) {
+	return &entity.FeeQuote{
+		Operation:     operation,
+		AccountID:     accountID,
+		Tier:          entity.TierStandard,
+		Amount:        amount,
+		Fee:           decimal.NewFromInt(1),
+		BalanceChange: decimal.Zero.Sub(amount).Sub(decimal.NewFromInt(1)),
+	}, nil
+}
+
+func (fakeManager) UpdateAccountTier(_ context.Context, userID uuid.UUID, tier string) (*entity.Account, error) {
+	if !entity.ValidTier(tier) {
+		return nil, service.ErrAccountTier
+	}
+
+	return &entity.Account{ID: userID, Tier: tier}, nil
+}
+
+type stubReadiness struct {
+	err error
+}
+
+func (r *stubReadiness) Ready(_ context.Context) error {
+	return r.err
+}
+
+func newTestServer(t *testing.T, tmanager TransactionManager) *httptest.Server {
+	t.Helper()
+
+	return newTestServerWithReadiness(t, tmanager, &stubReadiness{})
+}
+
+func newTestServerWithReadiness(t *testing.T,
+	tmanager TransactionManager,
+	readiness ReadinessChecker) *httptest.Server {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+
+	srv := httptest.NewServer(New("localhost", "0", tmanager, readiness, events.NewBroker(),
+		WithOperators(operator.Tokens{operatorToken: operatorName})).server.Handler)
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func doRequest(t *testing.T, method, url, body string) *http.Response {
+	t.Helper()
+
+	return doRequestWithHeaders(t, method, url, body, nil)
+}
+
+// doOperatorRequest makes the request as the operator of test servers.
+func doOperatorRequest(t *testing.T, method, url, body string) *http.Response {
+	t.Helper()
+
+	return doRequestWithHeaders(t, method, url, body, map[string]string{"Authorization": "Bearer " + operatorToken})
+}
+
+func doRequestWithHeaders(t *testing.T, method, url, body string, headers map[string]string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(context.Background(), method, url, strings.NewReader(body))
+	require.NoError(t, err)
+
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { resp.Body.Close() })
+
+	return resp
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	srv := newTestServer(t, fakeManager{})
+
+	userID := uuid.NewString()
+
+	// collectors are global, so the counters are compared with their values before the requests
+	deposits := metrics.HTTPRequests.WithLabelValues(http.MethodPatch, "/:userID/deposit", "200")
+	transfers := metrics.HTTPRequests.WithLabelValues(http.MethodPatch, "/:userID/transfer", "404")
+	depositsBefore, transfersBefore := testutil.ToFloat64(deposits), testutil.ToFloat64(transfers)
+
+	resp := doRequest(t, http.MethodPatch, srv.URL+"/"+userID+"/deposit", `{"amount": 10}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = doRequest(t, http.MethodPatch, srv.URL+"/"+userID+"/transfer",
+		`{"receiverID": "`+uuid.NewString()+`", "amount": 10}`)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp = doRequest(t, http.MethodGet, srv.URL+"/metrics", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	require.Contains(t, string(body),
+		`finapi_http_requests_total{method="PATCH",route="/:userID/deposit",status="200"}`)
+
+	require.InDelta(t, depositsBefore+1, testutil.ToFloat64(deposits), 0)
+	require.InDelta(t, transfersBefore+1, testutil.ToFloat64(transfers), 0)
+}
+
+func TestRequestID(t *testing.T) {
+	srv := newTestServer(t, fakeManager{})
+
+	userID := uuid.NewString()
+
+	req, err := http.NewRequestWithContext(context.Background(),
+		http.MethodGet, srv.URL+"/"+userID+"/transactions", nil)
+	require.NoError(t, err)
+
+	req.Header.Set(RequestIDHeader, "incoming-id")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	require.Equal(t, "incoming-id", resp.Header.Get(RequestIDHeader))
+
+	resp = doRequest(t, http.MethodGet, srv.URL+"/"+userID+"/transactions", "")
+
+	_, err = uuid.Parse(resp.Header.Get(RequestIDHeader))
+	require.NoError(t, err)
+}
+
+func TestTraceContextPropagation(t *testing.T) {
+	provider, err := tracing.Setup(tracing.ExporterMemory, nil)
+	require.NoError(t, err)
+
+	defer provider.Shutdown(context.Background()) //nolint:errcheck
+
+	srv := newTestServer(t, fakeManager{})
+
+	const (
+		traceID      = "4bf92f3577b34da6a3ce929d0e0e4736"
+		parentSpanID = "00f067aa0ba902b7"
+	)
+
+	req, err := http.NewRequestWithContext(context.Background(),
+		http.MethodGet, srv.URL+"/"+uuid.NewString()+"/transactions", nil)
+	require.NoError(t, err)
+
+	req.Header.Set("traceparent", "00-"+traceID+"-"+parentSpanID+"-01")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	spans := provider.Memory.GetSpans()
+	require.Len(t, spans, 1)
+
+	require.Equal(t, "/:userID/transactions", spans[0].Name)
+	require.Equal(t, traceID, spans[0].SpanContext.TraceID().String())
+	require.Equal(t, parentSpanID, spans[0].Parent.SpanID().String())
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	readiness := &stubReadiness{}
+	srv := newTestServerWithReadiness(t, fakeManager{}, readiness)
+
+	resp := doRequest(t, http.MethodGet, srv.URL+"/healthz", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = doRequest(t, http.MethodGet, srv.URL+"/readyz", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	readiness.err = errors.New("application is shutting down")
+
+	resp = doRequest(t, http.MethodGet, srv.URL+"/readyz", "")
+	require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	resp = doRequest(t, http.MethodGet, srv.URL+"/healthz", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+// blockingManager holds transfers until release is closed.
+type blockingManager struct {
+	fakeManager
+
+	started chan struct{}
+	release chan struct{}
+}
+
+func (m *blockingManager) Transfer(_ context.Context,
+	receiverID, senderID uuid.UUID,
+	amount decimal.Decimal) (*entity.Transaction, error) {
+	close(m.started)
+	<-m.release
+
+	return &entity.Transaction{
+		ID:         uuid.New(),
+		ReceiverID: receiverID,
+		SenderID:   senderID,
+		Amount:     amount,
+		Operation:  "transfer",
+	}, nil
+}
+
+func TestShutdownDuringActiveTransfer(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tmanager := &blockingManager{
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+
+	h := New("localhost", "0", tmanager, &stubReadiness{}, events.NewBroker())
+
+	listener, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+
+	url := "http://" + listener.Addr().String()
+
+	served := make(chan error)
+
+	go func() {
+		served <- h.Serve(listener)
+	}()
+
+	transferred := make(chan *http.Response)
+
+	go func() {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodPatch,
+			url+"/"+uuid.NewString()+"/transfer",
+			strings.NewReader(`{"receiverID": "`+uuid.NewString()+`", "amount": 10}`))
+		if err != nil {
+			close(transferred)
+
+			return
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			close(transferred)
+
+			return
+		}
+
+		transferred <- resp
+	}()
+
+	<-tmanager.started
+
+	stopped := make(chan error)
+
+	go func() {
+		stopped <- h.Shutdown(context.Background())
+	}()
+
+	select {
+	case <-stopped:
+		t.Fatal("shutdown finished before in-flight transfer")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// new connections are not accepted anymore
+	_, err = http.Get(url + "/healthz") //nolint:noctx
+	require.Error(t, err)
+
+	close(tmanager.release)
+
+	resp, ok := <-transferred
+	require.True(t, ok, "in-flight transfer failed")
+
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, <-stopped)
+	require.NoError(t, <-served)
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tmanager := &blockingManager{
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	defer close(tmanager.release)
+
+	h := New("localhost", "0", tmanager, &stubReadiness{}, events.NewBroker())
+
+	listener, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+
+	go h.Serve(listener) //nolint:errcheck
+
+	go func() {
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodPatch,
+			"http://"+listener.Addr().String()+"/"+uuid.NewString()+"/transfer",
+			strings.NewReader(`{"receiverID": "`+uuid.NewString()+`", "amount": 10}`))
+
+		resp, err := http.DefaultClient.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+
+	<-tmanager.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	require.ErrorIs(t, h.Shutdown(ctx), context.DeadlineExceeded)
+}
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/aspirin100/finapi/internal/audit"
+	"github.com/aspirin100/finapi/internal/logger"
+	"github.com/aspirin100/finapi/internal/operator"
+	"github.com/aspirin100/finapi/internal/proxy"
+)
+
+const (
+	RequestIDHeader    = "X-Request-ID"
+	maxRequestIDLength = 128
+
+	// ActorHeader names the initiator of the request recorded in the audit log, it is only believed
+	// from trusted proxies, see WithTrustedProxies. Operators are recorded by their tokens instead.
+	ActorHeader = "X-Actor"
+
+	jsonErrorsKey = "finapi.jsonErrors"
+)
+
+// actor puts the initiator of the request into request context for the audit log: the operator
+// of the bearer token, the X-Actor header set by a trusted proxy, or nobody, so the actor is anonymous.
+func actor(operators operator.Tokens, proxies proxy.Trusted) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		reqCtx := ctx.Request.Context()
+
+		if name, ok := authenticateOperator(ctx, operators); ok {
+			reqCtx = audit.WithActor(reqCtx, name)
+		} else if actor := ctx.GetHeader(ActorHeader); actor != "" && proxies.Contains(ctx.Request.RemoteAddr) {
+			reqCtx = audit.WithActor(reqCtx, actor)
+		}
+
+		ctx.Request = ctx.Request.WithContext(reqCtx)
+
+		ctx.Next()
+	}
+}
+
+// requestLogger assigns request id or propagates the incoming one,
+// puts logger with it into request context and logs every handled request.
+func requestLogger() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+
+		requestID := ctx.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = uuid.NewString()
+		}
+
+		ctx.Header(RequestIDHeader, requestID)
+
+		reqCtx := logger.WithRequestID(ctx.Request.Context(), requestID)
+
+		spanCtx := trace.SpanContextFromContext(reqCtx)
+		if spanCtx.HasTraceID() {
+			reqCtx = logger.WithContext(reqCtx,
+				logger.FromContext(reqCtx).With(slog.String("trace_id", spanCtx.TraceID().String())))
+		}
+
+		ctx.Request = ctx.Request.WithContext(reqCtx)
+
+		ctx.Next()
+
+		log := logger.FromContext(ctx.Request.Context())
+
+		level := slog.LevelInfo
+		if ctx.Writer.Status() >= 500 { //nolint:mnd
+			level = slog.LevelError
+		}
+
+		log.LogAttrs(ctx.Request.Context(), level, "request handled",
+			slog.String("method", ctx.Request.Method),
+			slog.String("path", ctx.Request.URL.Path),
+			slog.String("route", ctx.FullPath()),
+			slog.Int("status", ctx.Writer.Status()),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("client_ip", ctx.ClientIP()),
+		)
+	}
+}
+
+// jsonErrors makes error responses of the routes under prefix JSON objects instead of plain text.
+func jsonErrors(prefix string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if strings.HasPrefix(ctx.FullPath(), prefix) {
+			ctx.Set(jsonErrorsKey, true)
+		}
+	}
+}
+
+// deprecated marks the route as deprecated and points to its successor,
+// in which :userID is replaced with the request path parameter.
+func deprecated(successor string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		link := strings.ReplaceAll(successor, ":userID", ctx.Param("userID"))
+
+		ctx.Header("Deprecation", "true")
+		ctx.Header("Link", "<"+link+">; rel=\"successor-version\"")
+	}
+}
+
+type errorResponse struct {
+	Error     string `json:"error"`
+	RequestID string `json:"requestID"` //nolint:tagliatelle
+}
+
+// writeError responds with the error message as JSON object for /v1 routes
+// and as plain text for legacy ones.
+func writeError(ctx *gin.Context, status int, message string) {
+	if !ctx.GetBool(jsonErrorsKey) {
+		if message == "" {
+			ctx.Status(status)
+
+			return
+		}
+
+		ctx.String(status, message)
+
+		return
+	}
+
+	if message == "" {
+		message = http.StatusText(status)
+	}
+
+	ctx.JSON(status, errorResponse{
+		Error:     message,
+		RequestID: logger.RequestID(ctx.Request.Context()),
+	})
+}
@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gin-gonic/gin"
+
+	"github.com/aspirin100/finapi/docs"
+	"github.com/aspirin100/finapi/internal/logger"
+)
+
+// Modes of the api specification validation.
+const (
+	ValidationNone     = "none"
+	ValidationRequests = "requests"
+	ValidationAll      = "all"
+)
+
+var ErrUnknownValidationMode = errors.New("unknown openapi validation mode")
+
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>FINAPI</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({url: "/openapi.yml", dom_id: "#swagger-ui"});
+  </script>
+</body>
+</html>
+`
+
+// SpecValidator checks requests and, optionally, responses against the api specification.
+type SpecValidator struct {
+	router            routers.Router
+	validateResponses bool
+}
+
+// NewSpecValidator creates validator of the given mode, nil is returned for ValidationNone.
+func NewSpecValidator(spec *openapi3.T, mode string) (*SpecValidator, error) {
+	switch mode {
+	case ValidationNone:
+		return nil, nil //nolint:nilnil
+	case ValidationRequests, ValidationAll:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownValidationMode, mode)
+	}
+
+	// servers are dropped so that routes match regardless of the host the api is served on
+	hostless := *spec
+	hostless.Servers = nil
+
+	router, err := gorillamux.NewRouter(&hostless)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create openapi router: %w", err)
+	}
+
+	return &SpecValidator{
+		router:            router,
+		validateResponses: mode == ValidationAll,
+	}, nil
+}
+
+// WithSpecValidator enables validation of the traffic against the api specification.
+func WithSpecValidator(validator *SpecValidator) Option {
+	return func(h *Handler) {
+		h.validator = validator
+	}
+}
+
+// middleware rejects requests not matching the specification with 400.
+// Responses not matching it are only logged, since they are already sent.
+func (v *SpecValidator) middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		route, pathParams, err := v.router.FindRoute(ctx.Request)
+		if err != nil {
+			// unknown routes are left to the gin router
+			ctx.Next()
+
+			return
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    ctx.Request,
+			PathParams: pathParams,
+			Route:      route,
+			Options: &openapi3filter.Options{
+				AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+				MultiError:         false,
+			},
+		}
+
+		err = openapi3filter.ValidateRequest(ctx.Request.Context(), input)
+		if err != nil {
+			writeError(ctx, http.StatusBadRequest, "request doesn't match api specification: "+err.Error())
+			ctx.Abort()
+
+			return
+		}
+
+		if !v.validateResponses {
+			ctx.Next()
+
+			return
+		}
+
+		writer := &capturingWriter{ResponseWriter: ctx.Writer}
+		ctx.Writer = writer
+
+		ctx.Next()
+
+		if writer.streaming {
+			return
+		}
+
+		err = openapi3filter.ValidateResponse(ctx.Request.Context(), &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: input,
+			Status:                 writer.Status(),
+			Header:                 writer.Header(),
+			Body:                   io.NopCloser(bytes.NewReader(writer.body.Bytes())),
+			Options:                input.Options,
+		})
+		if err != nil {
+			logger.FromContext(ctx.Request.Context()).Error("response doesn't match api specification",
+				slog.String("route", ctx.FullPath()),
+				slog.Int("status", writer.Status()),
+				slog.Any("error", err))
+		}
+	}
+}
+
+// capturingWriter keeps a copy of the response body for validation.
+// Event streams are not captured as they never end.
+type capturingWriter struct {
+	gin.ResponseWriter
+
+	body      bytes.Buffer
+	streaming bool
+}
+
+func (w *capturingWriter) Write(data []byte) (int, error) {
+	w.capture(data)
+
+	return w.ResponseWriter.Write(data) //nolint:wrapcheck
+}
+
+func (w *capturingWriter) WriteString(data string) (int, error) {
+	w.capture([]byte(data))
+
+	return w.ResponseWriter.WriteString(data) //nolint:wrapcheck
+}
+
+func (w *capturingWriter) capture(data []byte) {
+	if strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream") {
+		w.streaming = true
+		w.body.Reset()
+	}
+
+	if !w.streaming {
+		w.body.Write(data)
+	}
+}
+
+// OpenAPI serves the embedded api specification.
+func (h *Handler) OpenAPI(ctx *gin.Context) {
+	ctx.Data(http.StatusOK, "application/yaml", docs.OpenAPI)
+}
+
+// Docs serves the interactive documentation page of the api specification.
+func (h *Handler) Docs(ctx *gin.Context) {
+	ctx.Data(http.StatusOK, "text/html; charset=utf-8", []byte(docsPage))
+}
@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aspirin100/finapi/docs"
+	"github.com/aspirin100/finapi/internal/events"
+)
+
+var ginPathParam = regexp.MustCompile(`:(\w+)`)
+
+func TestRoutesDocumented(t *testing.T) {
+	spec, err := docs.LoadOpenAPI()
+	require.NoError(t, err)
+
+	engine, ok := New("localhost", "0", fakeManager{}, &stubReadiness{}, events.NewBroker()).server.Handler.(*gin.Engine)
+	require.True(t, ok)
+
+	registered := make(map[string]bool)
+
+	for _, route := range engine.Routes() {
+		path := ginPathParam.ReplaceAllString(route.Path, "{$1}")
+		registered[route.Method+" "+path] = true
+
+		item := spec.Paths.Value(path)
+		require.NotNil(t, item, "route %s %s is missing in the api specification", route.Method, path)
+		require.NotNil(t, item.GetOperation(route.Method),
+			"route %s %s is missing in the api specification", route.Method, path)
+	}
+
+	for path, item := range spec.Paths.Map() {
+		for method := range item.Operations() {
+			require.True(t, registered[method+" "+path],
+				"route %s %s from the api specification is not registered", method, path)
+		}
+	}
+}
+
+func TestOpenAPIEndpoints(t *testing.T) {
+	srv := newTestServer(t, fakeManager{})
+
+	resp := doRequest(t, http.MethodGet, srv.URL+"/openapi.yml", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, docs.OpenAPI, body)
+
+	resp = doRequest(t, http.MethodGet, srv.URL+"/docs", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.True(t, strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html"))
+}
+
+func TestNewSpecValidator(t *testing.T) {
+	spec, err := docs.LoadOpenAPI()
+	require.NoError(t, err)
+
+	validator, err := NewSpecValidator(spec, ValidationNone)
+	require.NoError(t, err)
+	require.Nil(t, validator)
+
+	_, err = NewSpecValidator(spec, "strict")
+	require.ErrorIs(t, err, ErrUnknownValidationMode)
+}
+
+func TestSpecValidation(t *testing.T) {
+	spec, err := docs.LoadOpenAPI()
+	require.NoError(t, err)
+
+	validator, err := NewSpecValidator(spec, ValidationAll)
+	require.NoError(t, err)
+
+	var logs bytes.Buffer
+
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+	gin.SetMode(gin.TestMode)
+
+	srv := httptest.NewServer(New("localhost", "0", transferringManager{}, &stubReadiness{}, events.NewBroker(),
+		WithSpecValidator(validator)).server.Handler)
+	t.Cleanup(srv.Close)
+
+	userID := uuid.NewString()
+
+	cases := []struct {
+		Name           string
+		Method         string
+		Path           string
+		Body           string
+		ExpectedStatus int
+	}{
+		{
+			Name:           "create account",
+			Method:         http.MethodPost,
+			Path:           "/v1/accounts",
+			ExpectedStatus: http.StatusCreated,
+		},
+		{
+			Name:           "create account of unknown type",
+			Method:         http.MethodPost,
+			Path:           "/v1/accounts",
+			Body:           `{"type": "gold"}`,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "deposit",
+			Method:         http.MethodPost,
+			Path:           "/v1/accounts/" + userID + "/deposits",
+			Body:           `{"amount": 10}`,
+			ExpectedStatus: http.StatusCreated,
+		},
+		{
+			Name:           "deposit with zero amount",
+			Method:         http.MethodPost,
+			Path:           "/v1/accounts/" + userID + "/deposits",
+			Body:           `{"amount": 0}`,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "transfer",
+			Method:         http.MethodPost,
+			Path:           "/v1/transfers",
+			Body:           `{"senderID": "` + userID + `", "receiverID": "` + uuid.NewString() + `", "amount": "0.5"}`,
+			ExpectedStatus: http.StatusCreated,
+		},
+		{
+			Name:           "transfer without receiver",
+			Method:         http.MethodPost,
+			Path:           "/v1/transfers",
+			Body:           `{"senderID": "` + userID + `", "amount": 1}`,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "transfer with malformed amount",
+			Method:         http.MethodPost,
+			Path:           "/v1/transfers",
+			Body:           `{"senderID": "` + userID + `", "receiverID": "` + uuid.NewString() + `", "amount": "ten"}`,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "account with malformed id",
+			Method:         http.MethodGet,
+			Path:           "/v1/accounts/42",
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "account transactions",
+			Method:         http.MethodGet,
+			Path:           "/v1/accounts/" + userID + "/transactions",
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "unknown transaction",
+			Method:         http.MethodGet,
+			Path:           "/v1/transactions/" + uuid.NewString(),
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "legacy deposit",
+			Method:         http.MethodPatch,
+			Path:           "/" + userID + "/deposit",
+			Body:           `{"amount": 10}`,
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "legacy deposit with negative amount",
+			Method:         http.MethodPatch,
+			Path:           "/" + userID + "/deposit",
+			Body:           `{"amount": -10}`,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "liveness",
+			Method:         http.MethodGet,
+			Path:           "/healthz",
+			ExpectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tcase := range cases {
+		t.Run(tcase.Name, func(t *testing.T) {
+			req, err := http.NewRequestWithContext(context.Background(), tcase.Method, srv.URL+tcase.Path,
+				strings.NewReader(tcase.Body))
+			require.NoError(t, err)
+
+			if tcase.Body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+
+			defer resp.Body.Close()
+
+			require.Equal(t, tcase.ExpectedStatus, resp.StatusCode)
+
+			if strings.HasPrefix(tcase.Path, "/v1/") && resp.StatusCode >= http.StatusBadRequest {
+				var body errorResponse
+
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+				require.NotEmpty(t, body.Error)
+			}
+		})
+	}
+
+	require.NotContains(t, logs.String(), "response doesn't match api specification")
+}
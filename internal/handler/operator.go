@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/aspirin100/finapi/internal/audit"
+	"github.com/aspirin100/finapi/internal/operator"
+	"github.com/aspirin100/finapi/internal/proxy"
+)
+
+// WithOperators lets the operators of the tokens use the admin routes,
+// nobody can use them without operators.
+func WithOperators(tokens operator.Tokens) Option {
+	return func(h *Handler) {
+		h.operators = tokens
+	}
+}
+
+// WithTrustedProxies believes the X-Actor header of requests from the proxies,
+// the header of other clients is ignored.
+func WithTrustedProxies(proxies proxy.Trusted) Option {
+	return func(h *Handler) {
+		h.proxies = proxies
+	}
+}
+
+// operatorAuth rejects requests without a bearer token of an operator with 401 and records
+// the operator as the actor of the request, overriding the X-Actor header.
+func operatorAuth(tokens operator.Tokens) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		name, ok := authenticateOperator(ctx, tokens)
+		if !ok {
+			ctx.Header("WWW-Authenticate", `Bearer realm="finapi operators"`)
+			writeError(ctx, http.StatusUnauthorized, "operator token required")
+			ctx.Abort()
+
+			return
+		}
+
+		ctx.Request = ctx.Request.WithContext(audit.WithActor(ctx.Request.Context(), name))
+	}
+}
+
+// authenticateOperator returns the operator of the bearer token of the request.
+func authenticateOperator(ctx *gin.Context, tokens operator.Tokens) (string, bool) {
+	token, bearer := strings.CutPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+	if !bearer {
+		return "", false
+	}
+
+	return tokens.Authenticate(token)
+}
@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aspirin100/finapi/internal/audit"
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/events"
+	"github.com/aspirin100/finapi/internal/operator"
+	"github.com/aspirin100/finapi/internal/proxy"
+)
+
+// actorManager blocks parties with the audit actor of the request as the reason.
+type actorManager struct {
+	fakeManager
+}
+
+// CreateAccount opens the account with the audit actor of the request as the tier.
+func (actorManager) CreateAccount(ctx context.Context, accountType string) (*entity.Account, error) {
+	return &entity.Account{ID: uuid.New(), Tier: audit.Actor(ctx), Type: accountType}, nil
+}
+
+func (actorManager) BlockParty(ctx context.Context, partyID uuid.UUID, _ string) (*entity.BlockedParty, error) {
+	return &entity.BlockedParty{ID: partyID, Reason: audit.Actor(ctx)}, nil
+}
+
+func TestOperatorAuth(t *testing.T) {
+	srv := newTestServer(t, actorManager{})
+
+	body := `{"id": "` + uuid.NewString() + `"}`
+
+	cases := []struct {
+		Name           string
+		Headers        map[string]string
+		ExpectedStatus int
+		ExpectedActor  string
+	}{
+		{
+			Name:           "without token",
+			ExpectedStatus: http.StatusUnauthorized,
+		},
+		{
+			Name:           "actor header instead of token",
+			Headers:        map[string]string{ActorHeader: operatorName},
+			ExpectedStatus: http.StatusUnauthorized,
+		},
+		{
+			Name:           "unknown token",
+			Headers:        map[string]string{"Authorization": "Bearer guess"},
+			ExpectedStatus: http.StatusUnauthorized,
+		},
+		{
+			Name:           "token without scheme",
+			Headers:        map[string]string{"Authorization": operatorToken},
+			ExpectedStatus: http.StatusUnauthorized,
+		},
+		{
+			Name:           "operator",
+			Headers:        map[string]string{"Authorization": "Bearer " + operatorToken},
+			ExpectedStatus: http.StatusCreated,
+			ExpectedActor:  operatorName,
+		},
+		{
+			Name: "operator overrides actor header",
+			Headers: map[string]string{
+				"Authorization": "Bearer " + operatorToken,
+				ActorHeader:     "mallory",
+			},
+			ExpectedStatus: http.StatusCreated,
+			ExpectedActor:  operatorName,
+		},
+	}
+
+	for _, tcase := range cases {
+		t.Run(tcase.Name, func(t *testing.T) {
+			resp := doRequestWithHeaders(t, http.MethodPost, srv.URL+"/v1/admin/blocklist", body, tcase.Headers)
+			require.Equal(t, tcase.ExpectedStatus, resp.StatusCode)
+
+			if tcase.ExpectedActor == "" {
+				require.NotEmpty(t, resp.Header.Get("WWW-Authenticate"))
+
+				return
+			}
+
+			var party entity.BlockedParty
+
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&party))
+			require.Equal(t, tcase.ExpectedActor, party.Reason)
+		})
+	}
+}
+
+func TestWithoutOperators(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	srv := httptest.NewServer(New("localhost", "0", fakeManager{}, &stubReadiness{}, events.NewBroker()).server.Handler)
+	t.Cleanup(srv.Close)
+
+	resp := doOperatorRequest(t, http.MethodGet, srv.URL+"/v1/admin/blocklist", "")
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode, "admin routes are closed without operators")
+}
+
+func TestActor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	trusted, err := proxy.ParseTrusted("127.0.0.1,::1")
+	require.NoError(t, err)
+
+	newServer := func(opts ...Option) *httptest.Server {
+		opts = append(opts, WithOperators(operator.Tokens{operatorToken: operatorName}))
+
+		srv := httptest.NewServer(New("localhost", "0", actorManager{}, &stubReadiness{}, events.NewBroker(),
+			opts...).server.Handler)
+		t.Cleanup(srv.Close)
+
+		return srv
+	}
+
+	direct, proxied := newServer(), newServer(WithTrustedProxies(trusted))
+
+	cases := []struct {
+		Name          string
+		Server        *httptest.Server
+		Headers       map[string]string
+		ExpectedActor string
+	}{
+		{
+			Name:          "nobody",
+			Server:        direct,
+			ExpectedActor: audit.ActorAnonymous,
+		},
+		{
+			Name:          "actor header of untrusted client",
+			Server:        direct,
+			Headers:       map[string]string{ActorHeader: "mallory"},
+			ExpectedActor: audit.ActorAnonymous,
+		},
+		{
+			Name:          "actor header of trusted proxy",
+			Server:        proxied,
+			Headers:       map[string]string{ActorHeader: "bob"},
+			ExpectedActor: "bob",
+		},
+		{
+			Name:          "operator",
+			Server:        direct,
+			Headers:       map[string]string{"Authorization": "Bearer " + operatorToken, ActorHeader: "mallory"},
+			ExpectedActor: operatorName,
+		},
+		{
+			Name:          "unknown token",
+			Server:        direct,
+			Headers:       map[string]string{"Authorization": "Bearer guess"},
+			ExpectedActor: audit.ActorAnonymous,
+		},
+	}
+
+	for _, tcase := range cases {
+		t.Run(tcase.Name, func(t *testing.T) {
+			resp := doRequestWithHeaders(t, http.MethodPost, tcase.Server.URL+"/v1/accounts", "", tcase.Headers)
+			require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+			var account entity.Account
+
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&account))
+			require.Equal(t, tcase.ExpectedActor, account.Tier)
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/aspirin100/finapi/internal/logger"
+	"github.com/aspirin100/finapi/internal/metrics"
+	"github.com/aspirin100/finapi/internal/operator"
+	"github.com/aspirin100/finapi/internal/ratelimit"
+)
+
+type RateLimiter interface {
+	Allow(ctx context.Context, req ratelimit.Request) (*ratelimit.Result, error)
+}
+
+// WithRateLimiter enables rate limiting of requests.
+func WithRateLimiter(limiter RateLimiter) Option {
+	return func(h *Handler) {
+		h.limiter = limiter
+	}
+}
+
+// unlimitedRoutes are never limited, so that probes and scrapes always get through.
+var unlimitedRoutes = map[string]bool{
+	"/healthz": true,
+	"/readyz":  true,
+	"/metrics": true,
+}
+
+// rateLimit rejects requests exceeding limits with 429. Requests are let through
+// if the limiter fails, as the limits aren't worth an outage.
+func rateLimit(limiter RateLimiter, operators operator.Tokens) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if unlimitedRoutes[ctx.FullPath()] {
+			return
+		}
+
+		res, err := limiter.Allow(ctx.Request.Context(), ratelimit.Request{
+			IP:     ctx.ClientIP(),
+			User:   limitedUser(ctx, operators),
+			Method: ctx.Request.Method,
+			Route:  ctx.FullPath(),
+		})
+		if err != nil {
+			logger.FromContext(ctx.Request.Context()).Warn("rate limiter failed", slog.Any("error", err))
+
+			return
+		}
+
+		if res == nil {
+			return
+		}
+
+		ctx.Header("RateLimit-Limit", strconv.Itoa(res.Limit))
+		ctx.Header("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		ctx.Header("RateLimit-Reset", seconds(res.Reset))
+
+		if res.Allowed {
+			return
+		}
+
+		metrics.HTTPRateLimited.WithLabelValues(ctx.Request.Method, ctx.FullPath()).Inc()
+
+		ctx.Header("Retry-After", seconds(res.RetryAfter))
+		writeError(ctx, http.StatusTooManyRequests, "rate limit exceeded")
+		ctx.Abort()
+	}
+}
+
+// limitedUser returns the authenticated operator of the request. Accounts of paths and bodies
+// aren't authenticated, so anybody could drain buckets of other accounts by them: such requests
+// are limited per ip and route only.
+func limitedUser(ctx *gin.Context, operators operator.Tokens) string {
+	name, _ := authenticateOperator(ctx, operators)
+
+	return name
+}
+
+// seconds formats duration as whole seconds rounded up.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
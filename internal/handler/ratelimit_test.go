@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aspirin100/finapi/internal/events"
+	"github.com/aspirin100/finapi/internal/operator"
+	"github.com/aspirin100/finapi/internal/ratelimit"
+)
+
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(_ context.Context, _ ratelimit.Request) (*ratelimit.Result, error) {
+	return nil, errors.New("store is unavailable")
+}
+
+func newRateLimitedServer(t *testing.T, limiter RateLimiter) *httptest.Server {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+
+	srv := httptest.NewServer(New("localhost", "0", transferringManager{}, &stubReadiness{}, events.NewBroker(),
+		WithRateLimiter(limiter), WithOperators(operator.Tokens{operatorToken: operatorName})).server.Handler)
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestRateLimit(t *testing.T) {
+	srv := newRateLimitedServer(t, ratelimit.New(ratelimit.NewMemoryStore(), ratelimit.Limits{
+		IP:   ratelimit.Limit{Count: 10, Period: time.Hour},
+		User: ratelimit.Limit{Count: 1, Period: time.Hour},
+	}))
+
+	senderID := uuid.NewString()
+	transfer := `{"senderID": "` + senderID + `", "receiverID": "` + uuid.NewString() + `", "amount": 1}`
+
+	resp := doRequest(t, http.MethodPost, srv.URL+"/v1/transfers", transfer)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.Equal(t, "10", resp.Header.Get("RateLimit-Limit"))
+	require.Equal(t, "9", resp.Header.Get("RateLimit-Remaining"))
+	require.Equal(t, "360", resp.Header.Get("RateLimit-Reset"))
+
+	// accounts in requests aren't authenticated, so they don't spend user buckets
+	resp = doRequest(t, http.MethodPost, srv.URL+"/v1/transfers", transfer)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp = doRequest(t, http.MethodGet, srv.URL+"/"+senderID+"/transactions", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = doOperatorRequest(t, http.MethodGet, srv.URL+"/v1/admin/blocklist", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "1", resp.Header.Get("RateLimit-Limit"))
+	require.Equal(t, "0", resp.Header.Get("RateLimit-Remaining"))
+	require.Equal(t, "3600", resp.Header.Get("RateLimit-Reset"))
+
+	resp = doOperatorRequest(t, http.MethodGet, srv.URL+"/v1/admin/blocklist", "")
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode, "operator limit exceeded")
+	require.Equal(t, "3600", resp.Header.Get("Retry-After"))
+
+	var body errorResponse
+
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	require.Equal(t, "rate limit exceeded", body.Error)
+
+	// 5 of 10 requests allowed per ip are spent
+	for range 5 {
+		resp = doRequest(t, http.MethodPost, srv.URL+"/v1/accounts", "")
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	resp = doRequest(t, http.MethodPost, srv.URL+"/v1/accounts", "")
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode, "ip limit exceeded")
+
+	resp = doRequest(t, http.MethodGet, srv.URL+"/healthz", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode, "probes are never limited")
+	require.Empty(t, resp.Header.Get("RateLimit-Limit"))
+}
+
+func TestRateLimitFailOpen(t *testing.T) {
+	srv := newRateLimitedServer(t, failingLimiter{})
+
+	resp := doRequest(t, http.MethodPost, srv.URL+"/v1/accounts", "")
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.Empty(t, resp.Header.Get("RateLimit-Limit"))
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/service"
+)
+
+// respondHeld responds with 202 and the review if the transfer is held by risk rules.
+func respondHeld(ctx *gin.Context, err error) bool {
+	var held *service.HeldError
+	if !errors.As(err, &held) {
+		return false
+	}
+
+	ctx.Header("Location", "/v1/reviews/"+held.Review.ID.String())
+	ctx.JSON(http.StatusAccepted, held.Review)
+
+	return true
+}
+
+func (h *Handler) ListReviews(ctx *gin.Context) {
+	status := ctx.DefaultQuery("status", entity.ReviewPending)
+
+	switch status {
+	case entity.ReviewPending, entity.ReviewApproved, entity.ReviewRejected:
+	default:
+		writeError(ctx, http.StatusBadRequest, "unknown review status")
+
+		return
+	}
+
+	reviews, err := h.tmanager.GetReviews(ctx.Request.Context(), status)
+	if err != nil {
+		responseOnServiceError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, reviews)
+}
+
+func (h *Handler) GetReview(ctx *gin.Context) {
+	h.review(ctx, h.tmanager.GetReview)
+}
+
+func (h *Handler) ApproveReview(ctx *gin.Context) {
+	h.review(ctx, h.tmanager.ApproveReview)
+}
+
+func (h *Handler) RejectReview(ctx *gin.Context) {
+	h.review(ctx, h.tmanager.RejectReview)
+}
+
+func (h *Handler) review(ctx *gin.Context,
+	action func(ctx context.Context, reviewID uuid.UUID) (*entity.Review, error)) {
+	reviewID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		writeError(ctx, http.StatusBadRequest, "wrong review id format")
+
+		return
+	}
+
+	review, err := action(ctx.Request.Context(), reviewID)
+	if err != nil {
+		responseOnServiceError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, review)
+}
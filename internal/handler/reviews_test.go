@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/service"
+)
+
+var (
+	pendingReviewID = uuid.New()
+	decidedReviewID = uuid.New()
+)
+
+// riskyManager denies transfers above 100 and holds ones above 10,
+// it knows one pending and one decided review.
+type riskyManager struct {
+	transferringManager
+}
+
+func (m riskyManager) Transfer(ctx context.Context,
+	receiverID, senderID uuid.UUID,
+	amount decimal.Decimal) (*entity.Transaction, error) {
+	switch {
+	case amount.GreaterThan(decimal.NewFromInt(100)):
+		return nil, service.ErrTransferDenied
+	case amount.GreaterThan(decimal.NewFromInt(10)):
+		return nil, &service.HeldError{Review: &entity.Review{
+			ID:         pendingReviewID,
+			SenderID:   senderID,
+			ReceiverID: receiverID,
+			Amount:     amount,
+			Rule:       "limit",
+			Reason:     "a lot",
+			Status:     entity.ReviewPending,
+		}}
+	default:
+		return m.transferringManager.Transfer(ctx, receiverID, senderID, amount)
+	}
+}
+
+func (riskyManager) GetReview(_ context.Context, reviewID uuid.UUID) (*entity.Review, error) {
+	return decide(reviewID, entity.ReviewPending)
+}
+
+func (riskyManager) ApproveReview(_ context.Context, reviewID uuid.UUID) (*entity.Review, error) {
+	return decide(reviewID, entity.ReviewApproved)
+}
+
+func (riskyManager) RejectReview(_ context.Context, reviewID uuid.UUID) (*entity.Review, error) {
+	return decide(reviewID, entity.ReviewRejected)
+}
+
+func decide(reviewID uuid.UUID, status string) (*entity.Review, error) {
+	switch reviewID {
+	case pendingReviewID:
+		return &entity.Review{ID: reviewID, Status: status}, nil
+	case decidedReviewID:
+		return nil, service.ErrReviewDecided
+	default:
+		return nil, service.ErrNotFound
+	}
+}
+
+func TestHeldTransfers(t *testing.T) {
+	srv := newTestServer(t, riskyManager{})
+
+	userID := uuid.NewString()
+
+	cases := []struct {
+		Name           string
+		Method         string
+		Path           string
+		Body           string
+		ExpectedStatus int
+	}{
+		{
+			Name:           "allowed",
+			Method:         http.MethodPost,
+			Path:           "/v1/transfers",
+			Body:           `{"senderID": "` + userID + `", "receiverID": "` + uuid.NewString() + `", "amount": 10}`,
+			ExpectedStatus: http.StatusCreated,
+		},
+		{
+			Name:           "held",
+			Method:         http.MethodPost,
+			Path:           "/v1/transfers",
+			Body:           `{"senderID": "` + userID + `", "receiverID": "` + uuid.NewString() + `", "amount": 11}`,
+			ExpectedStatus: http.StatusAccepted,
+		},
+		{
+			Name:           "held on legacy route",
+			Method:         http.MethodPatch,
+			Path:           "/" + userID + "/transfer",
+			Body:           `{"receiverID": "` + uuid.NewString() + `", "amount": 11}`,
+			ExpectedStatus: http.StatusAccepted,
+		},
+		{
+			Name:           "denied",
+			Method:         http.MethodPost,
+			Path:           "/v1/transfers",
+			Body:           `{"senderID": "` + userID + `", "receiverID": "` + uuid.NewString() + `", "amount": 101}`,
+			ExpectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tcase := range cases {
+		t.Run(tcase.Name, func(t *testing.T) {
+			resp := doRequest(t, tcase.Method, srv.URL+tcase.Path, tcase.Body)
+			require.Equal(t, tcase.ExpectedStatus, resp.StatusCode)
+
+			if resp.StatusCode != http.StatusAccepted {
+				return
+			}
+
+			require.Equal(t, "/v1/reviews/"+pendingReviewID.String(), resp.Header.Get("Location"))
+
+			var review entity.Review
+
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&review))
+			require.Equal(t, entity.ReviewPending, review.Status)
+			require.Equal(t, "a lot", review.Reason)
+		})
+	}
+}
+
+func TestReviews(t *testing.T) {
+	srv := newTestServer(t, riskyManager{})
+
+	cases := []struct {
+		Name           string
+		Method         string
+		Path           string
+		ExpectedStatus int
+		ExpectedReview string
+	}{
+		{
+			Name:           "list pending",
+			Method:         http.MethodGet,
+			Path:           "/v1/reviews",
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "list approved",
+			Method:         http.MethodGet,
+			Path:           "/v1/reviews?status=approved",
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "list unknown status",
+			Method:         http.MethodGet,
+			Path:           "/v1/reviews?status=lost",
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "get",
+			Method:         http.MethodGet,
+			Path:           "/v1/reviews/" + pendingReviewID.String(),
+			ExpectedStatus: http.StatusOK,
+			ExpectedReview: entity.ReviewPending,
+		},
+		{
+			Name:           "approve",
+			Method:         http.MethodPost,
+			Path:           "/v1/reviews/" + pendingReviewID.String() + "/approve",
+			ExpectedStatus: http.StatusOK,
+			ExpectedReview: entity.ReviewApproved,
+		},
+		{
+			Name:           "reject",
+			Method:         http.MethodPost,
+			Path:           "/v1/reviews/" + pendingReviewID.String() + "/reject",
+			ExpectedStatus: http.StatusOK,
+			ExpectedReview: entity.ReviewRejected,
+		},
+		{
+			Name:           "approve decided",
+			Method:         http.MethodPost,
+			Path:           "/v1/reviews/" + decidedReviewID.String() + "/approve",
+			ExpectedStatus: http.StatusConflict,
+		},
+		{
+			Name:           "reject unknown",
+			Method:         http.MethodPost,
+			Path:           "/v1/reviews/" + uuid.NewString() + "/reject",
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "malformed id",
+			Method:         http.MethodPost,
+			Path:           "/v1/reviews/42/approve",
+			ExpectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tcase := range cases {
+		t.Run(tcase.Name, func(t *testing.T) {
+			resp := doOperatorRequest(t, tcase.Method, srv.URL+tcase.Path, "")
+			require.Equal(t, tcase.ExpectedStatus, resp.StatusCode)
+
+			if tcase.ExpectedReview == "" {
+				return
+			}
+
+			var review entity.Review
+
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&review))
+			require.Equal(t, tcase.ExpectedReview, review.Status)
+		})
+	}
+
+	resp := doRequest(t, http.MethodPost, srv.URL+"/v1/reviews/"+pendingReviewID.String()+"/approve", "")
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode, "only operators decide reviews")
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/logger"
+)
+
+const (
+	LastEventIDHeader = "Last-Event-ID"
+
+	streamHeartbeat  = 15 * time.Second
+	streamRetryDelay = 3 * time.Second
+)
+
+// TransactionFeed delivers committed transactions involving the user.
+type TransactionFeed interface {
+	Subscribe(userID uuid.UUID) (<-chan entity.Transaction, func())
+}
+
+// StreamTransactions sends transactions of the user as server-sent events as soon as they are committed.
+// Event id is the transaction id, so reconnecting clients passing Last-Event-ID
+// receive transactions committed while they were disconnected, transactions created shortly
+// before the last event may be sent again.
+func (h *Handler) StreamTransactions(ctx *gin.Context) {
+	h.streamTransactions(ctx, ctx.Param("userID"))
+}
+
+func (h *Handler) streamTransactions(ctx *gin.Context, rawUserID string) {
+	userID, err := uuid.Parse(rawUserID)
+	if err != nil {
+		responseOnValidationErr(ctx, ErrInvalidFormat)
+
+		return
+	}
+
+	reqCtx := ctx.Request.Context()
+
+	_, err = h.tmanager.GetAccount(reqCtx, userID)
+	if err != nil {
+		responseOnServiceError(ctx, err)
+
+		return
+	}
+
+	// subscribe before reading missed transactions, so nothing committed in between is lost
+	feed, unsubscribe := h.feed.Subscribe(userID)
+	defer unsubscribe()
+
+	var missed []entity.Transaction
+
+	if lastEventID := ctx.GetHeader(LastEventIDHeader); lastEventID != "" {
+		afterID, err := uuid.Parse(lastEventID)
+		if err != nil {
+			writeError(ctx, http.StatusBadRequest, "wrong last event id format")
+
+			return
+		}
+
+		missed, err = h.tmanager.GetTransactionsAfter(reqCtx, userID, afterID)
+		if err != nil {
+			responseOnServiceError(ctx, err)
+
+			return
+		}
+	}
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+
+	fmt.Fprintf(ctx.Writer, "retry: %d\n\n", streamRetryDelay.Milliseconds())
+
+	// transactions already sent from history may be delivered by the feed once more
+	sent := make(map[uuid.UUID]struct{}, len(missed))
+
+	for i := range missed {
+		err = writeTransactionEvent(ctx.Writer, &missed[i])
+		if err != nil {
+			return
+		}
+
+		sent[missed[i].ID] = struct{}{}
+	}
+
+	ctx.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-reqCtx.Done():
+			return
+		case <-heartbeat.C:
+			_, err = io.WriteString(ctx.Writer, ": heartbeat\n\n")
+		case transaction, ok := <-feed:
+			if !ok {
+				// client reconnects and catches up using Last-Event-ID
+				return
+			}
+
+			if _, dup := sent[transaction.ID]; dup {
+				continue
+			}
+
+			err = writeTransactionEvent(ctx.Writer, &transaction)
+		}
+
+		if err != nil {
+			logger.FromContext(reqCtx).Debug("transactions stream closed", slog.Any("error", err))
+
+			return
+		}
+
+		ctx.Writer.Flush()
+	}
+}
+
+func writeTransactionEvent(w io.Writer, transaction *entity.Transaction) error {
+	data, err := json.Marshal(transaction)
+	if err != nil {
+		return fmt.Errorf("failed to marshal transaction: %w", err)
+	}
+
+	_, err = fmt.Fprintf(w, "id: %s\nevent: transaction\ndata: %s\n\n", transaction.ID, data)
+	if err != nil {
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/events"
+	"github.com/aspirin100/finapi/internal/service"
+)
+
+// historyManager knows the only user with the given transactions history.
+type historyManager struct {
+	fakeManager
+
+	userID  uuid.UUID
+	history []entity.Transaction
+}
+
+func (m historyManager) GetAccount(_ context.Context, userID uuid.UUID) (*entity.Account, error) {
+	if userID != m.userID {
+		return nil, service.ErrUserNotFound
+	}
+
+	return &entity.Account{ID: userID}, nil
+}
+
+func (m historyManager) GetTransactionsAfter(_ context.Context,
+	_, afterID uuid.UUID) ([]entity.Transaction, error) {
+	for i := range m.history {
+		if m.history[i].ID == afterID {
+			return m.history[i+1:], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// readEvent returns id of the next event skipping comments and retry field.
+func readEvent(t *testing.T, reader *bufio.Reader) string {
+	t.Helper()
+
+	var id string
+
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+
+		line = strings.TrimSuffix(line, "\n")
+
+		switch {
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case line == "" && id != "":
+			return id
+		}
+	}
+}
+
+func TestStreamTransactions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	userID := uuid.New()
+
+	history := make([]entity.Transaction, 3)
+	for i := range history {
+		history[i] = entity.Transaction{
+			ID:         uuid.New(),
+			SenderID:   userID,
+			ReceiverID: userID,
+			Amount:     decimal.NewFromInt(int64(i + 1)),
+			Operation:  "deposit",
+		}
+	}
+
+	broker := events.NewBroker()
+	tmanager := historyManager{userID: userID, history: history}
+
+	srv := httptest.NewServer(New("localhost", "0", tmanager, &stubReadiness{}, broker).server.Handler)
+	defer srv.Close()
+
+	resp := doRequest(t, http.MethodGet, srv.URL+"/"+uuid.NewString()+"/stream", "")
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	req, err := http.NewRequestWithContext(context.Background(),
+		http.MethodGet, srv.URL+"/"+userID.String()+"/stream", nil)
+	require.NoError(t, err)
+
+	req.Header.Set(LastEventIDHeader, history[0].ID.String())
+
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+
+	// missed transactions are sent first
+	require.Equal(t, history[1].ID.String(), readEvent(t, reader))
+	require.Equal(t, history[2].ID.String(), readEvent(t, reader))
+
+	// already sent transaction is skipped
+	broker.Publish(history[2])
+
+	live := entity.Transaction{ID: uuid.New(), SenderID: uuid.New(), ReceiverID: userID, Operation: "transfer"}
+	broker.Publish(live)
+
+	require.Equal(t, live.ID.String(), readEvent(t, reader))
+
+	broker.Close()
+
+	_, err = reader.ReadString('\n')
+	require.Error(t, err)
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CircuitBreaker interface {
+	Allow() error
+	RetryAfter() time.Duration
+}
+
+// WithCircuitBreaker makes requests fail fast while the database is unreachable.
+func WithCircuitBreaker(breaker CircuitBreaker) Option {
+	return func(h *Handler) {
+		h.breaker = breaker
+	}
+}
+
+// noDatabaseRoutes don't need the database or report its state themselves.
+var noDatabaseRoutes = map[string]bool{
+	"/healthz":     true,
+	"/readyz":      true,
+	"/metrics":     true,
+	"/openapi.yml": true,
+	"/docs":        true,
+}
+
+// unavailable rejects requests with 503 while the circuit breaker is open,
+// instead of letting them wait for the database timeout.
+func unavailable(breaker CircuitBreaker) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.FullPath() == "" || noDatabaseRoutes[ctx.FullPath()] || breaker.Allow() == nil {
+			return
+		}
+
+		ctx.Header("Retry-After", seconds(breaker.RetryAfter()))
+		writeError(ctx, http.StatusServiceUnavailable, "database is unavailable")
+		ctx.Abort()
+	}
+}
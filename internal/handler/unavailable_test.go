@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aspirin100/finapi/internal/breaker"
+	"github.com/aspirin100/finapi/internal/events"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	b := breaker.New(1, time.Minute)
+
+	srv := httptest.NewServer(New("localhost", "0", transferringManager{}, &stubReadiness{}, events.NewBroker(),
+		WithCircuitBreaker(b)).server.Handler)
+	t.Cleanup(srv.Close)
+
+	accountURL := srv.URL + "/v1/accounts/" + uuid.NewString()
+
+	resp := doRequest(t, http.MethodGet, accountURL, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	b.Failure()
+
+	resp = doRequest(t, http.MethodGet, accountURL, "")
+	require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	require.Equal(t, "60", resp.Header.Get("Retry-After"))
+
+	var body errorResponse
+
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	require.Equal(t, "database is unavailable", body.Error)
+
+	resp = doRequest(t, http.MethodGet, srv.URL+"/healthz", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode, "probes don't need the database")
+
+	b.Success()
+
+	resp = doRequest(t, http.MethodGet, accountURL, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
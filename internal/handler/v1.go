@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+type transferRequestBody struct {
+	SenderID   uuid.UUID       `json:"senderID"`   //nolint:tagliatelle
+	ReceiverID uuid.UUID       `json:"receiverID"` //nolint:tagliatelle
+	Amount     decimal.Decimal `json:"amount"`
+}
+
+func (h *Handler) registerV1(v1 *gin.RouterGroup) {
+	v1.POST("/accounts", h.CreateAccount)
+	v1.GET("/accounts/:id", h.GetAccount)
+	v1.POST("/accounts/:id/deposits", h.CreateDeposit)
+	v1.GET("/accounts/:id/transactions", h.ListAccountTransactions)
+	v1.GET("/accounts/:id/stream", h.StreamAccountTransactions)
+	v1.POST("/transfers", h.CreateTransfer)
+	v1.GET("/transactions/:id", h.GetTransaction)
+	v1.GET("/fees/quote", h.QuoteFee)
+
+	reviews := v1.Group("/reviews", operatorAuth(h.operators))
+	reviews.GET("", h.ListReviews)
+	reviews.GET("/:id", h.GetReview)
+	reviews.POST("/:id/approve", h.ApproveReview)
+	reviews.POST("/:id/reject", h.RejectReview)
+
+	admin := v1.Group("/admin", operatorAuth(h.operators))
+	admin.GET("/blocklist", h.ListBlockedParties)
+	admin.POST("/blocklist", h.BlockParty)
+	admin.DELETE("/blocklist/:id", h.UnblockParty)
+	admin.PUT("/accounts/:id/tier", h.UpdateAccountTier)
+}
+
+type createAccountRequestBody struct {
+	Type string `json:"type"`
+}
+
+// CreateAccount opens the account of the type in the optional body, checking one by default.
+func (h *Handler) CreateAccount(ctx *gin.Context) {
+	var body createAccountRequestBody
+
+	err := json.NewDecoder(ctx.Request.Body).Decode(&body)
+	if err != nil && !errors.Is(err, io.EOF) {
+		responseOnValidationErr(ctx, fmt.Errorf("%w: %w", ErrInvalidBody, err))
+
+		return
+	}
+
+	account, err := h.tmanager.CreateAccount(ctx.Request.Context(), body.Type)
+	if err != nil {
+		responseOnServiceError(ctx, err)
+
+		return
+	}
+
+	ctx.Header("Location", "/v1/accounts/"+account.ID.String())
+	ctx.JSON(http.StatusCreated, account)
+}
+
+func (h *Handler) GetAccount(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		responseOnValidationErr(ctx, ErrInvalidFormat)
+
+		return
+	}
+
+	account, err := h.tmanager.GetAccount(ctx.Request.Context(), userID)
+	if err != nil {
+		responseOnServiceError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, account)
+}
+
+func (h *Handler) CreateDeposit(ctx *gin.Context) {
+	params, err := validateDepositRequest(ctx.Param("id"), ctx.Request)
+	if err != nil {
+		responseOnValidationErr(ctx, err)
+
+		return
+	}
+
+	deposit, err := h.tmanager.Deposit(ctx.Request.Context(), params.UserID, params.Amount)
+	if err != nil {
+		responseOnServiceError(ctx, err)
+
+		return
+	}
+
+	ctx.Header("Location", "/v1/transactions/"+deposit.Transaction.ID.String())
+	ctx.JSON(http.StatusCreated, deposit)
+}
+
+func (h *Handler) ListAccountTransactions(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		responseOnValidationErr(ctx, ErrInvalidFormat)
+
+		return
+	}
+
+	transactions, err := h.tmanager.GetTransactions(ctx.Request.Context(), userID)
+	if err != nil {
+		responseOnServiceError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, transactions)
+}
+
+func (h *Handler) StreamAccountTransactions(ctx *gin.Context) {
+	h.streamTransactions(ctx, ctx.Param("id"))
+}
+
+func (h *Handler) CreateTransfer(ctx *gin.Context) {
+	var body transferRequestBody
+
+	err := decodeBody(ctx.Request, &body)
+	if err != nil {
+		responseOnValidationErr(ctx, err)
+
+		return
+	}
+
+	params := transferRequestParams(body)
+
+	err = validateTransfer(&params)
+	if err != nil {
+		responseOnValidationErr(ctx, err)
+
+		return
+	}
+
+	transaction, err := h.tmanager.Transfer(
+		ctx.Request.Context(),
+		params.ReceiverID,
+		params.SenderID,
+		params.Amount)
+	if err != nil {
+		if respondHeld(ctx, err) {
+			return
+		}
+
+		responseOnServiceError(ctx, err)
+
+		return
+	}
+
+	ctx.Header("Location", "/v1/transactions/"+transaction.ID.String())
+	ctx.JSON(http.StatusCreated, transaction)
+}
+
+func (h *Handler) GetTransaction(ctx *gin.Context) {
+	transactionID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		writeError(ctx, http.StatusBadRequest, "wrong transaction id format")
+
+		return
+	}
+
+	transaction, err := h.tmanager.GetTransaction(ctx.Request.Context(), transactionID)
+	if err != nil {
+		responseOnServiceError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, transaction)
+}
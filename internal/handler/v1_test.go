@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aspirin100/finapi/internal/entity"
+)
+
+// transferringManager accepts every transfer.
+type transferringManager struct {
+	fakeManager
+}
+
+func (transferringManager) Transfer(_ context.Context,
+	receiverID, senderID uuid.UUID,
+	amount decimal.Decimal) (*entity.Transaction, error) {
+	return &entity.Transaction{
+		ID:         uuid.New(),
+		ReceiverID: receiverID,
+		SenderID:   senderID,
+		Amount:     amount,
+		Operation:  "transfer",
+	}, nil
+}
+
+func TestV1Routes(t *testing.T) {
+	srv := newTestServer(t, transferringManager{})
+
+	userID := uuid.NewString()
+
+	cases := []struct {
+		Name             string
+		Method           string
+		Path             string
+		Body             string
+		ExpectedStatus   int
+		ExpectedLocation string
+	}{
+		{
+			Name:             "create account",
+			Method:           http.MethodPost,
+			Path:             "/v1/accounts",
+			ExpectedStatus:   http.StatusCreated,
+			ExpectedLocation: "/v1/accounts/",
+		},
+		{
+			Name:             "create savings account",
+			Method:           http.MethodPost,
+			Path:             "/v1/accounts",
+			Body:             `{"type": "savings"}`,
+			ExpectedStatus:   http.StatusCreated,
+			ExpectedLocation: "/v1/accounts/",
+		},
+		{
+			Name:           "create account of unknown type",
+			Method:         http.MethodPost,
+			Path:           "/v1/accounts",
+			Body:           `{"type": "gold"}`,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "get account",
+			Method:         http.MethodGet,
+			Path:           "/v1/accounts/" + userID,
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:             "deposit",
+			Method:           http.MethodPost,
+			Path:             "/v1/accounts/" + userID + "/deposits",
+			Body:             `{"amount": 10}`,
+			ExpectedStatus:   http.StatusCreated,
+			ExpectedLocation: "/v1/transactions/",
+		},
+		{
+			Name:           "deposit with negative amount",
+			Method:         http.MethodPost,
+			Path:           "/v1/accounts/" + userID + "/deposits",
+			Body:           `{"amount": -10}`,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:             "transfer",
+			Method:           http.MethodPost,
+			Path:             "/v1/transfers",
+			Body:             `{"senderID": "` + userID + `", "receiverID": "` + uuid.NewString() + `", "amount": "0.5"}`,
+			ExpectedStatus:   http.StatusCreated,
+			ExpectedLocation: "/v1/transactions/",
+		},
+		{
+			Name:           "transfer to the same account",
+			Method:         http.MethodPost,
+			Path:           "/v1/transfers",
+			Body:           `{"senderID": "` + userID + `", "receiverID": "` + userID + `", "amount": 1}`,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "transfer without sender",
+			Method:         http.MethodPost,
+			Path:           "/v1/transfers",
+			Body:           `{"receiverID": "` + userID + `", "amount": 1}`,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "transfer with malformed body",
+			Method:         http.MethodPost,
+			Path:           "/v1/transfers",
+			Body:           `{"senderID": 1`,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "account transactions",
+			Method:         http.MethodGet,
+			Path:           "/v1/accounts/" + userID + "/transactions",
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "unknown transaction",
+			Method:         http.MethodGet,
+			Path:           "/v1/transactions/" + uuid.NewString(),
+			ExpectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tcase := range cases {
+		t.Run(tcase.Name, func(t *testing.T) {
+			resp := doRequest(t, tcase.Method, srv.URL+tcase.Path, tcase.Body)
+
+			require.Equal(t, tcase.ExpectedStatus, resp.StatusCode)
+			require.Empty(t, resp.Header.Get("Deprecation"))
+
+			if tcase.ExpectedLocation != "" {
+				location := resp.Header.Get("Location")
+				require.True(t, strings.HasPrefix(location, tcase.ExpectedLocation), location)
+
+				_, err := uuid.Parse(strings.TrimPrefix(location, tcase.ExpectedLocation))
+				require.NoError(t, err)
+			}
+
+			if resp.StatusCode >= http.StatusBadRequest {
+				var body errorResponse
+
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+				require.NotEmpty(t, body.Error)
+				require.Equal(t, resp.Header.Get(RequestIDHeader), body.RequestID)
+			}
+		})
+	}
+}
+
+func TestLegacyRoutesDeprecation(t *testing.T) {
+	srv := newTestServer(t, transferringManager{})
+
+	userID := uuid.NewString()
+
+	cases := []struct {
+		Name              string
+		Method            string
+		Path              string
+		Body              string
+		ExpectedSuccessor string
+	}{
+		{
+			Name:              "deposit",
+			Method:            http.MethodPatch,
+			Path:              "/" + userID + "/deposit",
+			Body:              `{"amount": 10}`,
+			ExpectedSuccessor: "/v1/accounts/" + userID + "/deposits",
+		},
+		{
+			Name:              "transfer",
+			Method:            http.MethodPatch,
+			Path:              "/" + userID + "/transfer",
+			Body:              `{"receiverID": "` + uuid.NewString() + `", "amount": 10}`,
+			ExpectedSuccessor: "/v1/transfers",
+		},
+		{
+			Name:              "transactions",
+			Method:            http.MethodGet,
+			Path:              "/" + userID + "/transactions",
+			ExpectedSuccessor: "/v1/accounts/" + userID + "/transactions",
+		},
+	}
+
+	for _, tcase := range cases {
+		t.Run(tcase.Name, func(t *testing.T) {
+			resp := doRequest(t, tcase.Method, srv.URL+tcase.Path, tcase.Body)
+
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			require.Equal(t, "true", resp.Header.Get("Deprecation"))
+			require.Equal(t, `<`+tcase.ExpectedSuccessor+`>; rel="successor-version"`, resp.Header.Get("Link"))
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+var (
+	ErrShuttingDown   = errors.New("application is shutting down")
+	ErrSchemaMismatch = errors.New("database schema version mismatch")
+)
+
+type Database interface {
+	Ping(ctx context.Context) error
+	SchemaVersion(ctx context.Context) (int64, error)
+}
+
+// Checker decides whether the application is ready to serve traffic.
+type Checker struct {
+	db              Database
+	expectedVersion int64
+	draining        atomic.Bool
+}
+
+func New(db Database, expectedVersion int64) *Checker {
+	return &Checker{
+		db:              db,
+		expectedVersion: expectedVersion,
+	}
+}
+
+// Ready returns nil if database is reachable, its schema matches the binary
+// and the application is not shutting down.
+func (c *Checker) Ready(ctx context.Context) error {
+	if c.draining.Load() {
+		return ErrShuttingDown
+	}
+
+	err := c.db.Ping(ctx)
+	if err != nil {
+		return fmt.Errorf("database is unreachable: %w", err)
+	}
+
+	version, err := c.db.SchemaVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check schema version: %w", err)
+	}
+
+	if version != c.expectedVersion {
+		return fmt.Errorf("%w: expected %d, got %d", ErrSchemaMismatch, c.expectedVersion, version)
+	}
+
+	return nil
+}
+
+// Drain marks the application as not ready, so load balancers stop routing new traffic to it.
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
@@ -0,0 +1,70 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/aspirin100/finapi/internal/health"
+)
+
+var errUnreachable = errors.New("connection refused")
+
+type stubDatabase struct {
+	pingErr error
+	version int64
+}
+
+func (db stubDatabase) Ping(_ context.Context) error {
+	return db.pingErr
+}
+
+func (db stubDatabase) SchemaVersion(_ context.Context) (int64, error) {
+	return db.version, nil
+}
+
+func TestReady(t *testing.T) {
+	const expectedVersion = 20250203095804
+
+	cases := []struct {
+		Name        string
+		DB          stubDatabase
+		Drain       bool
+		ExpectedErr error
+	}{
+		{
+			Name: "ok case",
+			DB:   stubDatabase{version: expectedVersion},
+		},
+		{
+			Name:        "database unreachable",
+			DB:          stubDatabase{pingErr: errUnreachable},
+			ExpectedErr: errUnreachable,
+		},
+		{
+			Name:        "schema is behind",
+			DB:          stubDatabase{version: 20250202180858},
+			ExpectedErr: health.ErrSchemaMismatch,
+		},
+		{
+			Name:        "draining",
+			DB:          stubDatabase{version: expectedVersion},
+			Drain:       true,
+			ExpectedErr: health.ErrShuttingDown,
+		},
+	}
+
+	for _, tcase := range cases {
+		t.Run(tcase.Name, func(t *testing.T) {
+			checker := health.New(tcase.DB, expectedVersion)
+
+			if tcase.Drain {
+				checker.Drain()
+			}
+
+			require.ErrorIs(t, checker.Ready(context.Background()), tcase.ExpectedErr)
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package interest
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gopkg.in/yaml.v3"
+)
+
+type productConfig struct {
+	AccountType string          `yaml:"accountType"`
+	Rate        decimal.Decimal `yaml:"rate"`
+	DayCount    string          `yaml:"dayCount"`
+}
+
+type config struct {
+	PayerAccount uuid.UUID       `yaml:"payerAccount"`
+	Precision    *int32          `yaml:"precision"`
+	Products     []productConfig `yaml:"products"`
+}
+
+// Load reads interest products from a YAML or JSON file.
+func Load(path string) (*Engine, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read interest products: %w", err)
+	}
+
+	return Parse(data)
+}
+
+// Parse parses interest products in YAML or JSON, e.g.
+//
+//	payerAccount: 6a1f0c52-0b7e-4f7e-8d8c-2f3c4d5e6f70
+//	products:
+//	  - accountType: savings
+//	    rate: "3.5"
+//	    dayCount: act/365
+//
+// The day count convention is act/365 if it is omitted.
+func Parse(data []byte) (*Engine, error) {
+	var cfg config
+
+	err := yaml.Unmarshal(data, &cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse interest products: %w", err)
+	}
+
+	precision := int32(DefaultPrecision)
+	if cfg.Precision != nil {
+		precision = *cfg.Precision
+	}
+
+	products := make([]Product, 0, len(cfg.Products))
+
+	for _, productCfg := range cfg.Products {
+		if productCfg.DayCount == "" {
+			productCfg.DayCount = DayCountActual365
+		}
+
+		products = append(products, Product(productCfg))
+	}
+
+	return NewEngine(cfg.PayerAccount, precision, products)
+}
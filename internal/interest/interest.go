@@ -0,0 +1,186 @@
+// Package interest accrues interest on end of day balances and defines monthly payout periods.
+package interest
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/aspirin100/finapi/internal/entity"
+)
+
+// Day count conventions, they define the fraction of the annual rate accrued per day.
+const (
+	// DayCountActual365 accrues 1/365 of the rate every day, also in leap years.
+	DayCountActual365 = "act/365"
+	// DayCountActual360 accrues 1/360 of the rate every day.
+	DayCountActual360 = "act/360"
+	// DayCountActualActual accrues 1/365 or 1/366 of the rate by the length of the year.
+	DayCountActualActual = "act/act"
+	// DayCount30360 treats every month as 30 days of a 360 days year: the 31st accrues nothing
+	// and the last day of February accrues the missing days.
+	DayCount30360 = "30/360"
+)
+
+// DefaultPrecision is the number of decimal places payouts are rounded to.
+const DefaultPrecision = 2
+
+var (
+	ErrInvalidProduct = errors.New("invalid interest product")
+	ErrNoPayerAccount = errors.New("payer account is required to pay interest")
+)
+
+var hundred = decimal.NewFromInt(100)
+
+// Product is the annual Rate in percent paid on positive balances of accounts of the type.
+type Product struct {
+	AccountType string
+	Rate        decimal.Decimal
+	DayCount    string
+}
+
+func (p Product) validate() error {
+	if !entity.ValidAccountType(p.AccountType) {
+		return fmt.Errorf("%w: unknown account type %q", ErrInvalidProduct, p.AccountType)
+	}
+
+	if p.Rate.IsNegative() {
+		return fmt.Errorf("%w: rate must not be negative", ErrInvalidProduct)
+	}
+
+	switch p.DayCount {
+	case DayCountActual365, DayCountActual360, DayCountActualActual, DayCount30360:
+	default:
+		return fmt.Errorf("%w: unknown day count convention %q", ErrInvalidProduct, p.DayCount)
+	}
+
+	return nil
+}
+
+// Daily returns the not rounded interest accrued on the end of day balance.
+func (p Product) Daily(day time.Time, balance decimal.Decimal) decimal.Decimal {
+	if !balance.IsPositive() {
+		return decimal.Zero
+	}
+
+	days, year := dayFraction(p.DayCount, day)
+
+	return balance.Mul(p.Rate).Mul(decimal.NewFromInt(days)).Div(hundred.Mul(decimal.NewFromInt(year)))
+}
+
+// dayFraction returns the days accrued for the day and the days in year by the convention.
+func dayFraction(dayCount string, day time.Time) (int64, int64) {
+	switch dayCount {
+	case DayCountActual360:
+		return 1, 360
+	case DayCountActualActual:
+		return 1, int64(time.Date(day.Year(), time.December, 31, 0, 0, 0, 0, time.UTC).YearDay())
+	case DayCount30360:
+		last := lastDayOfMonth(day)
+
+		switch {
+		case day.Day() > 30:
+			return 0, 360
+		case day.Day() == last:
+			return int64(30 - last + 1), 360
+		default:
+			return 1, 360
+		}
+	default:
+		return 1, 365
+	}
+}
+
+func lastDayOfMonth(day time.Time) int {
+	return time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// Engine accrues interest by the product of the account type.
+type Engine struct {
+	products  map[string]Product
+	payer     uuid.UUID
+	precision int32
+}
+
+// NewEngine validates the products, interest is paid from the payer account.
+func NewEngine(payerAccount uuid.UUID, precision int32, products []Product) (*Engine, error) {
+	if payerAccount == uuid.Nil && len(products) > 0 {
+		return nil, ErrNoPayerAccount
+	}
+
+	engine := &Engine{
+		products:  make(map[string]Product, len(products)),
+		payer:     payerAccount,
+		precision: precision,
+	}
+
+	for i, product := range products {
+		err := product.validate()
+		if err != nil {
+			return nil, fmt.Errorf("product #%d: %w", i+1, err)
+		}
+
+		if _, ok := engine.products[product.AccountType]; ok {
+			return nil, fmt.Errorf("product #%d: %w: duplicate of %s accounts",
+				i+1, ErrInvalidProduct, product.AccountType)
+		}
+
+		engine.products[product.AccountType] = product
+	}
+
+	return engine, nil
+}
+
+// Accrue sums interest accrued daily on the end of day balances, the sum is rounded
+// to the engine precision only once, so fractions of cents accrued daily are not lost.
+// It is zero if accounts of the type earn no interest.
+func (e *Engine) Accrue(accountType string, balances []entity.DailyBalance) decimal.Decimal {
+	product, ok := e.products[accountType]
+	if !ok {
+		return decimal.Zero
+	}
+
+	accrued := decimal.Zero
+
+	for _, balance := range balances {
+		accrued = accrued.Add(product.Daily(balance.Day, balance.Balance))
+	}
+
+	return accrued.RoundDown(e.precision)
+}
+
+// AccountTypes returns sorted types of accounts earning interest.
+func (e *Engine) AccountTypes() []string {
+	types := make([]string, 0, len(e.products))
+	for accountType := range e.products {
+		types = append(types, accountType)
+	}
+
+	slices.Sort(types)
+
+	return types
+}
+
+// PayerAccount returns the account interest is paid from.
+func (e *Engine) PayerAccount() uuid.UUID {
+	return e.payer
+}
+
+// Month returns the month of the day as [from, to) in UTC, months are payout periods.
+func Month(day time.Time) (time.Time, time.Time) {
+	day = day.UTC()
+	from := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	return from, from.AddDate(0, 1, 0)
+}
+
+// LastPeriod returns the last month completed before now, interest of the month is paid once it is over.
+func LastPeriod(now time.Time) (time.Time, time.Time) {
+	from, _ := Month(now)
+
+	return Month(from.AddDate(0, 0, -1))
+}
@@ -0,0 +1,220 @@
+package interest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/interest"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// monthBalances returns the same end of day balance for every day of the month.
+func monthBalances(year int, month time.Month, balance string) []entity.DailyBalance {
+	from, to := interest.Month(date(year, month, 1))
+
+	var balances []entity.DailyBalance
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		balances = append(balances, entity.DailyBalance{Day: day, Balance: decimal.RequireFromString(balance)})
+	}
+
+	return balances
+}
+
+func TestProductDaily(t *testing.T) {
+	cases := []struct {
+		Name          string
+		DayCount      string
+		Day           time.Time
+		Balance       string
+		ExpectedDaily string
+	}{
+		{Name: "act/365", DayCount: interest.DayCountActual365, Day: date(2024, 3, 1), Balance: "36500", ExpectedDaily: "1"},
+		{Name: "act/365 in leap year", DayCount: interest.DayCountActual365, Day: date(2024, 2, 29), Balance: "36500", ExpectedDaily: "1"},
+		{Name: "act/360", DayCount: interest.DayCountActual360, Day: date(2024, 3, 1), Balance: "36000", ExpectedDaily: "1"},
+		{Name: "act/act", DayCount: interest.DayCountActualActual, Day: date(2023, 3, 1), Balance: "36500", ExpectedDaily: "1"},
+		{Name: "act/act in leap year", DayCount: interest.DayCountActualActual, Day: date(2024, 3, 1), Balance: "36600", ExpectedDaily: "1"},
+		{Name: "30/360", DayCount: interest.DayCount30360, Day: date(2024, 1, 15), Balance: "36000", ExpectedDaily: "1"},
+		{Name: "30/360 on the 31st", DayCount: interest.DayCount30360, Day: date(2024, 1, 31), Balance: "36000", ExpectedDaily: "0"},
+		{Name: "30/360 end of february", DayCount: interest.DayCount30360, Day: date(2023, 2, 28), Balance: "36000", ExpectedDaily: "3"},
+		{Name: "30/360 end of leap february", DayCount: interest.DayCount30360, Day: date(2024, 2, 29), Balance: "36000", ExpectedDaily: "2"},
+		{Name: "negative balance", DayCount: interest.DayCountActual365, Day: date(2024, 3, 1), Balance: "-36500", ExpectedDaily: "0"},
+	}
+
+	for _, tcase := range cases {
+		t.Run(tcase.Name, func(t *testing.T) {
+			product := interest.Product{
+				AccountType: entity.AccountSavings,
+				Rate:        decimal.NewFromInt(1),
+				DayCount:    tcase.DayCount,
+			}
+
+			got := product.Daily(tcase.Day, decimal.RequireFromString(tcase.Balance))
+			require.Equal(t, tcase.ExpectedDaily, got.String())
+		})
+	}
+}
+
+func TestEngineAccrue(t *testing.T) {
+	engine, err := interest.Parse([]byte(`
+payerAccount: 6a1f0c52-0b7e-4f7e-8d8c-2f3c4d5e6f70
+products:
+  - accountType: savings
+    rate: "1"
+`))
+	require.NoError(t, err)
+	require.Equal(t, []string{entity.AccountSavings}, engine.AccountTypes())
+
+	thirty, err := interest.NewEngine(uuid.New(), interest.DefaultPrecision, []interest.Product{
+		{AccountType: entity.AccountSavings, Rate: decimal.NewFromInt(1), DayCount: interest.DayCount30360},
+	})
+	require.NoError(t, err)
+
+	cases := []struct {
+		Name            string
+		Engine          *interest.Engine
+		AccountType     string
+		Balances        []entity.DailyBalance
+		ExpectedAccrued string
+	}{
+		{
+			Name:            "every day accrues",
+			Engine:          engine,
+			AccountType:     entity.AccountSavings,
+			Balances:        monthBalances(2025, time.January, "36500"),
+			ExpectedAccrued: "31",
+		},
+		{
+			Name:            "fractions of cents are summed before rounding down",
+			Engine:          engine,
+			AccountType:     entity.AccountSavings,
+			Balances:        monthBalances(2025, time.January, "100"),
+			ExpectedAccrued: "0.08",
+		},
+		{
+			Name:        "balance changes",
+			Engine:      engine,
+			AccountType: entity.AccountSavings,
+			Balances: []entity.DailyBalance{
+				{Day: date(2025, 1, 1), Balance: decimal.NewFromInt(36500)},
+				{Day: date(2025, 1, 2), Balance: decimal.Zero},
+				{Day: date(2025, 1, 3), Balance: decimal.NewFromInt(73000)},
+			},
+			ExpectedAccrued: "3",
+		},
+		{
+			Name:            "30/360 month of 31 days",
+			Engine:          thirty,
+			AccountType:     entity.AccountSavings,
+			Balances:        monthBalances(2025, time.January, "36000"),
+			ExpectedAccrued: "30",
+		},
+		{
+			Name:            "30/360 february",
+			Engine:          thirty,
+			AccountType:     entity.AccountSavings,
+			Balances:        monthBalances(2025, time.February, "36000"),
+			ExpectedAccrued: "30",
+		},
+		{
+			Name:            "no product",
+			Engine:          engine,
+			AccountType:     entity.AccountChecking,
+			Balances:        monthBalances(2025, time.January, "36500"),
+			ExpectedAccrued: "0",
+		},
+	}
+
+	for _, tcase := range cases {
+		t.Run(tcase.Name, func(t *testing.T) {
+			got := tcase.Engine.Accrue(tcase.AccountType, tcase.Balances)
+			require.Equal(t, tcase.ExpectedAccrued, got.String())
+		})
+	}
+}
+
+func TestNewEngine(t *testing.T) {
+	savings := interest.Product{
+		AccountType: entity.AccountSavings,
+		Rate:        decimal.NewFromInt(2),
+		DayCount:    interest.DayCountActual360,
+	}
+
+	cases := []struct {
+		Name          string
+		Payer         uuid.UUID
+		Products      []interest.Product
+		ExpectedError error
+	}{
+		{Name: "ok", Payer: uuid.New(), Products: []interest.Product{savings}},
+		{Name: "no products", Payer: uuid.Nil},
+		{Name: "no payer", Payer: uuid.Nil, Products: []interest.Product{savings}, ExpectedError: interest.ErrNoPayerAccount},
+		{
+			Name:          "unknown account type",
+			Payer:         uuid.New(),
+			Products:      []interest.Product{{AccountType: "gold", DayCount: interest.DayCountActual365}},
+			ExpectedError: interest.ErrInvalidProduct,
+		},
+		{
+			Name:          "unknown day count",
+			Payer:         uuid.New(),
+			Products:      []interest.Product{{AccountType: entity.AccountSavings, DayCount: "act/364"}},
+			ExpectedError: interest.ErrInvalidProduct,
+		},
+		{
+			Name:  "negative rate",
+			Payer: uuid.New(),
+			Products: []interest.Product{
+				{AccountType: entity.AccountSavings, Rate: decimal.NewFromInt(-1), DayCount: interest.DayCountActual365},
+			},
+			ExpectedError: interest.ErrInvalidProduct,
+		},
+		{
+			Name:          "duplicate product",
+			Payer:         uuid.New(),
+			Products:      []interest.Product{savings, savings},
+			ExpectedError: interest.ErrInvalidProduct,
+		},
+	}
+
+	for _, tcase := range cases {
+		t.Run(tcase.Name, func(t *testing.T) {
+			_, err := interest.NewEngine(tcase.Payer, interest.DefaultPrecision, tcase.Products)
+			require.ErrorIs(t, err, tcase.ExpectedError)
+		})
+	}
+}
+
+func TestLastPeriod(t *testing.T) {
+	cases := []struct {
+		Name         string
+		Now          time.Time
+		ExpectedFrom time.Time
+		ExpectedTo   time.Time
+	}{
+		{Name: "end of month", Now: date(2025, 3, 31), ExpectedFrom: date(2025, 2, 1), ExpectedTo: date(2025, 3, 1)},
+		{Name: "first day", Now: date(2025, 3, 1), ExpectedFrom: date(2025, 2, 1), ExpectedTo: date(2025, 3, 1)},
+		{Name: "new year", Now: date(2025, 1, 15), ExpectedFrom: date(2024, 12, 1), ExpectedTo: date(2025, 1, 1)},
+		{
+			Name:         "local time",
+			Now:          time.Date(2025, 3, 1, 1, 0, 0, 0, time.FixedZone("UTC+3", 3*60*60)),
+			ExpectedFrom: date(2025, 1, 1),
+			ExpectedTo:   date(2025, 2, 1),
+		},
+	}
+
+	for _, tcase := range cases {
+		t.Run(tcase.Name, func(t *testing.T) {
+			from, to := interest.LastPeriod(tcase.Now)
+			require.Equal(t, tcase.ExpectedFrom, from)
+			require.Equal(t, tcase.ExpectedTo, to)
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+var ErrUnknownFormat = errors.New("unknown log format")
+
+type loggerCtxKey struct{}
+type requestIDCtxKey struct{}
+
+// New creates structured logger writing to w with the given level and format.
+func New(w io.Writer, level, format string) (*slog.Logger, error) {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewWithLevel(w, lvl, format)
+}
+
+// NewWithLevel creates structured logger writing to w with the given format, level may be
+// a slog.LevelVar to change it while the logger is used.
+func NewWithLevel(w io.Writer, level slog.Leveler, format string) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{
+		Level: level,
+	}
+
+	switch strings.ToLower(format) {
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	case FormatText:
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+}
+
+// ParseLevel parses debug, info, warn or error level.
+func ParseLevel(level string) (slog.Level, error) {
+	var lvl slog.Level
+
+	err := lvl.UnmarshalText([]byte(level))
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse log level: %w", err)
+	}
+
+	return lvl, nil
+}
+
+// WithContext returns a copy of ctx carrying the logger.
+func WithContext(ctx context.Context, log *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerCtxKey{}, log)
+}
+
+// FromContext returns the logger carried by ctx or the default one.
+func FromContext(ctx context.Context) *slog.Logger {
+	log, ok := ctx.Value(loggerCtxKey{}).(*slog.Logger)
+	if !ok {
+		return slog.Default()
+	}
+
+	return log
+}
+
+// WithRequestID returns a copy of ctx carrying the request id
+// and a logger which adds it to every record.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	ctx = context.WithValue(ctx, requestIDCtxKey{}, requestID)
+
+	return WithContext(ctx, FromContext(ctx).With(slog.String("request_id", requestID)))
+}
+
+// RequestID returns the request id carried by ctx or an empty string.
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDCtxKey{}).(string)
+
+	return requestID
+}
@@ -0,0 +1,52 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/aspirin100/finapi/internal/logger"
+)
+
+func TestNew(t *testing.T) {
+	cases := []struct {
+		Name        string
+		Level       string
+		Format      string
+		ExpectedErr bool
+	}{
+		{Name: "json", Level: "info", Format: "json"},
+		{Name: "text", Level: "debug", Format: "text"},
+		{Name: "unknown format", Level: "info", Format: "xml", ExpectedErr: true},
+		{Name: "unknown level", Level: "loud", Format: "json", ExpectedErr: true},
+	}
+
+	for _, tcase := range cases {
+		t.Run(tcase.Name, func(t *testing.T) {
+			_, err := logger.New(&bytes.Buffer{}, tcase.Level, tcase.Format)
+			require.Equal(t, tcase.ExpectedErr, err != nil)
+		})
+	}
+}
+
+func TestWithRequestID(t *testing.T) {
+	var buf bytes.Buffer
+
+	log, err := logger.New(&buf, "info", "json")
+	require.NoError(t, err)
+
+	ctx := logger.WithRequestID(logger.WithContext(context.Background(), log), "req-1")
+
+	require.Equal(t, "req-1", logger.RequestID(ctx))
+
+	logger.FromContext(ctx).Info("hello")
+
+	var record map[string]any
+
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	require.Equal(t, "req-1", record["request_id"])
+	require.Equal(t, "hello", record["msg"])
+}
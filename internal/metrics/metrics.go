@@ -0,0 +1,233 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/shopspring/decimal"
+)
+
+const namespace = "finapi"
+
+const (
+	OutcomeSuccess         = "success"
+	OutcomeUserNotFound    = "user_not_found"
+	OutcomeNegativeBalance = "negative_balance"
+	OutcomeDenied          = "denied"
+	OutcomeHeld            = "held"
+	OutcomeBlocked         = "blocked"
+	OutcomeError           = "error"
+)
+
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Total number of handled http requests.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of handled http requests.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	HTTPRateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "rate_limited_total",
+		Help:      "Total number of http requests rejected by rate limits.",
+	}, []string{"method", "route"})
+
+	GRPCRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "requests_total",
+		Help:      "Total number of handled rpc calls.",
+	}, []string{"method", "code"})
+
+	GRPCRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of handled rpc calls.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "code"})
+
+	Operations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "service",
+		Name:      "operations_total",
+		Help:      "Total number of money operations by outcome.",
+	}, []string{"operation", "outcome"})
+
+	AmountMoved = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "service",
+		Name:      "amount_moved_total",
+		Help:      "Total amount of money moved by successful operations.",
+	}, []string{"operation"})
+
+	FeesCharged = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "service",
+		Name:      "fees_charged_total",
+		Help:      "Total amount of fees charged by committed operations.",
+	}, []string{"operation"})
+
+	RiskRuleHits = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "risk",
+		Name:      "rule_hits_total",
+		Help:      "Total number of transfers denied or held for review by risk rules.",
+	}, []string{"rule", "decision"})
+
+	ScreeningDecisions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "screening",
+		Name:      "decisions_total",
+		Help:      "Total number of parties screened against the blocklist by result.",
+	}, []string{"operation", "result"})
+
+	TxRetries = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "tx_retries_total",
+		Help:      "Total number of db transactions retried after a conflict.",
+	})
+
+	TxRollbacks = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "tx_rollbacks_total",
+		Help:      "Total number of rolled back db transactions.",
+	})
+
+	TxCommitFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "tx_commit_failures_total",
+		Help:      "Total number of db transactions failed to commit.",
+	})
+
+	DBCircuitOpen = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "circuit_open",
+		Help:      "Whether requests fail fast as the database is unreachable, 1 if they do.",
+	})
+)
+
+// Handler returns http handler exposing registered metrics
+// in the prometheus text exposition format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// GinMiddleware counts requests and observes their latency per route and status.
+func GinMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+
+		ctx.Next()
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		status := strconv.Itoa(ctx.Writer.Status())
+
+		HTTPRequests.WithLabelValues(ctx.Request.Method, route, status).Inc()
+		HTTPRequestDuration.WithLabelValues(ctx.Request.Method, route, status).
+			Observe(time.Since(start).Seconds())
+	}
+}
+
+// ObserveOperation records the outcome of a money operation
+// and the moved amount if it succeeded.
+func ObserveOperation(operation, outcome string, amount decimal.Decimal) {
+	Operations.WithLabelValues(operation, outcome).Inc()
+
+	if outcome == OutcomeSuccess {
+		AmountMoved.WithLabelValues(operation).Add(amount.InexactFloat64())
+	}
+}
+
+type poolStater interface {
+	Stat() *pgxpool.Stat
+}
+
+// PoolCollector exports pgxpool statistics on every scrape.
+type PoolCollector struct {
+	pool poolStater
+
+	acquiredConns     *prometheus.Desc
+	idleConns         *prometheus.Desc
+	totalConns        *prometheus.Desc
+	maxConns          *prometheus.Desc
+	acquireCount      *prometheus.Desc
+	emptyAcquireCount *prometheus.Desc
+	acquireDuration   *prometheus.Desc
+}
+
+func NewPoolCollector(pool poolStater) *PoolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+	}
+
+	return &PoolCollector{
+		pool:              pool,
+		acquiredConns:     desc("acquired_connections", "Number of currently acquired connections."),
+		idleConns:         desc("idle_connections", "Number of currently idle connections."),
+		totalConns:        desc("total_connections", "Total number of connections in the pool."),
+		maxConns:          desc("max_connections", "Maximum size of the pool."),
+		acquireCount:      desc("acquires_total", "Total number of successful connection acquires."),
+		emptyAcquireCount: desc("empty_acquires_total", "Total number of acquires waited for a free connection."),
+		acquireDuration:   desc("acquire_wait_seconds_total", "Total time spent waiting for a connection."),
+	}
+}
+
+func (c *PoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.acquiredConns
+	ch <- c.idleConns
+	ch <- c.totalConns
+	ch <- c.maxConns
+	ch <- c.acquireCount
+	ch <- c.emptyAcquireCount
+	ch <- c.acquireDuration
+}
+
+func (c *PoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+
+	ch <- prometheus.MustNewConstMetric(c.acquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquireCount, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquireCount, prometheus.CounterValue,
+		float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireDuration, prometheus.CounterValue,
+		stat.AcquireDuration().Seconds())
+}
+
+// RegisterPool registers pool statistics collector in the default registry.
+func RegisterPool(pool poolStater) error {
+	err := prometheus.Register(NewPoolCollector(pool))
+	if err != nil {
+		return fmt.Errorf("failed to register pool collector: %w", err)
+	}
+
+	return nil
+}
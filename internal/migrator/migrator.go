@@ -0,0 +1,328 @@
+// Package migrator applies, inspects and validates the goose migrations of the postgres and sqlite schemas.
+package migrator
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+)
+
+const (
+	annotationPrefix = "-- +goose "
+	versionLayout    = "20060102150405"
+	template         = `-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+`
+)
+
+var (
+	ErrInvalid        = errors.New("invalid migration")
+	ErrNotMirrored    = errors.New("sqlite migrations don't mirror postgres ones")
+	ErrInvalidName    = errors.New("migration name must consist of lowercase letters, digits and underscores")
+	ErrExists         = errors.New("migration already exists")
+	ErrUnknownVersion = errors.New("unknown migration version")
+)
+
+var namePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// Migration is a parsed SQL migration, Up and Down hold the SQL of the sections without annotations.
+type Migration struct {
+	Version int64
+	File    string
+	Up      string
+	Down    string
+}
+
+// NewProvider creates the postgres migrations provider. A session advisory lock is held while migrating,
+// so concurrent providers wait for each other up to lockTimeout instead of colliding.
+func NewProvider(db *sql.DB, fsys fs.FS, lockTimeout time.Duration) (*goose.Provider, error) {
+	locker, err := newLocker(lockTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := goose.NewProvider(goose.DialectPostgres, db, fsys, goose.WithSessionLocker(locker))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migrations provider: %w", err)
+	}
+
+	return provider, nil
+}
+
+// Locked runs fn holding the lock of NewProvider providers for the whole call, so several steps of fn,
+// e.g. rolling back and applying again a migration, aren't interleaved with other migrators.
+// The provider passed to fn doesn't take the lock itself.
+func Locked(ctx context.Context, db *sql.DB, fsys fs.FS, lockTimeout time.Duration,
+	fn func(ctx context.Context, provider *goose.Provider) error) (err error) {
+	locker, err := newLocker(lockTimeout)
+	if err != nil {
+		return err
+	}
+
+	provider, err := goose.NewProvider(goose.DialectPostgres, db, fsys)
+	if err != nil {
+		return fmt.Errorf("failed to create migrations provider: %w", err)
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection of migrations lock: %w", err)
+	}
+	defer conn.Close()
+
+	err = locker.SessionLock(ctx, conn)
+	if err != nil {
+		return fmt.Errorf("failed to take migrations lock: %w", err)
+	}
+
+	defer func() {
+		unlockErr := locker.SessionUnlock(context.WithoutCancel(ctx), conn)
+		if unlockErr != nil && err == nil {
+			err = fmt.Errorf("failed to release migrations lock: %w", unlockErr)
+		}
+	}()
+
+	return fn(ctx, provider)
+}
+
+func newLocker(lockTimeout time.Duration) (lock.SessionLocker, error) {
+	locker, err := lock.NewPostgresSessionLocker(
+		lock.WithLockTimeout(1, uint64(max(lockTimeout/time.Second, 1))))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migrations lock: %w", err)
+	}
+
+	return locker, nil
+}
+
+// Load parses all migrations of fsys, oldest first.
+func Load(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	migrations := make([]Migration, 0, len(files))
+	versions := make(map[int64]string, len(files))
+
+	for _, file := range files {
+		migration, err := Parse(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+
+		if other, ok := versions[migration.Version]; ok {
+			return nil, fmt.Errorf("%w: %s and %s have the same version", ErrInvalid, other, file)
+		}
+
+		versions[migration.Version] = file
+
+		migrations = append(migrations, migration)
+	}
+
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+
+	return migrations, nil
+}
+
+// Parse reads the migration file and checks its goose annotations: one Up section followed by
+// an optional Down one, with statement blocks closed within their section.
+func Parse(fsys fs.FS, file string) (Migration, error) {
+	migration := Migration{File: file}
+
+	version, err := goose.NumericComponent(file)
+	if err != nil {
+		return migration, fmt.Errorf("%w: %s: %w", ErrInvalid, file, err)
+	}
+
+	migration.Version = version
+
+	content, err := fs.ReadFile(fsys, file)
+	if err != nil {
+		return migration, fmt.Errorf("failed to read migration %s: %w", file, err)
+	}
+
+	var (
+		section   *strings.Builder
+		up, down  strings.Builder
+		seenUp    bool
+		seenDown  bool
+		statement bool
+		lineNo    int
+	)
+
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: %s:%d: %s", ErrInvalid, file, lineNo, fmt.Sprintf(format, args...))
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(string(content)))
+
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+
+		annotation, ok := strings.CutPrefix(strings.TrimSpace(line), annotationPrefix)
+		if !ok {
+			if section == nil {
+				if strings.TrimSpace(line) != "" && !strings.HasPrefix(strings.TrimSpace(line), "--") {
+					return migration, invalid("statement outside of up and down sections")
+				}
+
+				continue
+			}
+
+			section.WriteString(line)
+			section.WriteByte('\n')
+
+			continue
+		}
+
+		switch strings.TrimSpace(annotation) {
+		case "Up":
+			if seenUp || seenDown || statement {
+				return migration, invalid("unexpected up section")
+			}
+
+			seenUp, section = true, &up
+		case "Down":
+			if !seenUp || seenDown || statement {
+				return migration, invalid("unexpected down section")
+			}
+
+			seenDown, section = true, &down
+		case "StatementBegin":
+			if section == nil || statement {
+				return migration, invalid("unexpected statement begin")
+			}
+
+			statement = true
+		case "StatementEnd":
+			if !statement {
+				return migration, invalid("statement end without begin")
+			}
+
+			statement = false
+		case "NO TRANSACTION", "ENVSUB ON", "ENVSUB OFF":
+		default:
+			return migration, invalid("unknown annotation %q", annotation)
+		}
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return migration, fmt.Errorf("failed to read migration %s: %w", file, err)
+	}
+
+	switch {
+	case !seenUp:
+		return migration, invalid("no up section")
+	case statement:
+		return migration, invalid("statement isn't ended")
+	}
+
+	migration.Up = strings.TrimSpace(up.String())
+	migration.Down = strings.TrimSpace(down.String())
+
+	return migration, nil
+}
+
+// Find returns the migration of the version.
+func Find(migrations []Migration, version int64) (Migration, error) {
+	i := slices.IndexFunc(migrations, func(m Migration) bool { return m.Version == version })
+	if i < 0 {
+		return Migration{}, fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+
+	return migrations[i], nil
+}
+
+// Mirrored checks that every postgres migration has a sqlite one of the same version and vice versa,
+// so the schema versions of both stores match.
+func Mirrored(postgres, sqlite []Migration) error {
+	versions := func(migrations []Migration) []int64 {
+		result := make([]int64, 0, len(migrations))
+
+		for _, m := range migrations {
+			result = append(result, m.Version)
+		}
+
+		return result
+	}
+
+	var (
+		postgresVersions = versions(postgres)
+		sqliteVersions   = versions(sqlite)
+		errs             []error
+	)
+
+	for _, m := range postgres {
+		if !slices.Contains(sqliteVersions, m.Version) {
+			errs = append(errs, fmt.Errorf("%w: %s has no sqlite mirror", ErrNotMirrored, m.File))
+		}
+	}
+
+	for _, m := range sqlite {
+		if !slices.Contains(postgresVersions, m.Version) {
+			errs = append(errs, fmt.Errorf("%w: %s has no postgres original", ErrNotMirrored, m.File))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Create writes an empty migration of the same version named after name to each of dirs
+// and returns the paths of the created files.
+func Create(name string, now time.Time, dirs ...string) ([]string, error) {
+	if !namePattern.MatchString(name) {
+		return nil, ErrInvalidName
+	}
+
+	file := now.UTC().Format(versionLayout) + "_" + name + ".sql"
+	paths := make([]string, 0, len(dirs))
+
+	for _, dir := range dirs {
+		matches, err := fs.Glob(os.DirFS(dir), "*_"+name+".sql")
+		if err != nil {
+			return nil, fmt.Errorf("failed to list migrations of %s: %w", dir, err)
+		}
+
+		if len(matches) > 0 {
+			return nil, fmt.Errorf("%w: %s", ErrExists, filepath.Join(dir, matches[0]))
+		}
+	}
+
+	for _, dir := range dirs {
+		filePath := filepath.Join(dir, file)
+
+		err := os.WriteFile(filePath, []byte(template), 0o644) //nolint:gosec,mnd
+		if err != nil {
+			return nil, fmt.Errorf("failed to create migration: %w", err)
+		}
+
+		paths = append(paths, filePath)
+	}
+
+	return paths, nil
+}
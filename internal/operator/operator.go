@@ -0,0 +1,56 @@
+// Package operator authenticates operators of the admin api, e.g. reviewers of held transfers
+// and maintainers of the blocklist, by their static bearer tokens.
+package operator
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidTokens = errors.New("invalid operator tokens")
+
+// Tokens maps bearer tokens to names of their operators.
+type Tokens map[string]string
+
+// ParseTokens parses comma separated "<operator>=<token>" pairs, e.g. "alice=s3cr3t,bob=t0k3n".
+// Errors never contain the tokens.
+func ParseTokens(raw string) (Tokens, error) {
+	tokens := make(Tokens)
+
+	for _, pair := range strings.Split(raw, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		name, token, ok := strings.Cut(pair, "=")
+		name, token = strings.TrimSpace(name), strings.TrimSpace(token)
+
+		switch {
+		case !ok || name == "" || token == "":
+			return nil, fmt.Errorf("%w: expected <operator>=<token>", ErrInvalidTokens)
+		case tokens[token] != "":
+			return nil, fmt.Errorf("%w: %s and %s share the token", ErrInvalidTokens, tokens[token], name)
+		}
+
+		tokens[token] = name
+	}
+
+	return tokens, nil
+}
+
+// Authenticate returns the operator of the token. The token is compared with every known one
+// in constant time, so timing doesn't tell how much of it matches.
+func (t Tokens) Authenticate(token string) (string, bool) {
+	var operator string
+
+	for known, name := range t {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(known)) == 1 {
+			operator = name
+		}
+	}
+
+	return operator, operator != ""
+}
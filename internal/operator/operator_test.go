@@ -0,0 +1,76 @@
+package operator_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/aspirin100/finapi/internal/operator"
+)
+
+func TestParseTokens(t *testing.T) {
+	cases := []struct {
+		Name        string
+		Raw         string
+		Expected    operator.Tokens
+		ExpectedErr error
+	}{
+		{
+			Name:     "empty",
+			Raw:      "",
+			Expected: operator.Tokens{},
+		},
+		{
+			Name:     "operators",
+			Raw:      "alice=s3cr3t, bob = t0k3n,",
+			Expected: operator.Tokens{"s3cr3t": "alice", "t0k3n": "bob"},
+		},
+		{
+			Name:        "missing token",
+			Raw:         "alice=",
+			ExpectedErr: operator.ErrInvalidTokens,
+		},
+		{
+			Name:        "missing operator",
+			Raw:         "s3cr3t",
+			ExpectedErr: operator.ErrInvalidTokens,
+		},
+		{
+			Name:        "shared token",
+			Raw:         "alice=s3cr3t,bob=s3cr3t",
+			ExpectedErr: operator.ErrInvalidTokens,
+		},
+	}
+
+	for _, tcase := range cases {
+		t.Run(tcase.Name, func(t *testing.T) {
+			tokens, err := operator.ParseTokens(tcase.Raw)
+			if tcase.ExpectedErr != nil {
+				require.ErrorIs(t, err, tcase.ExpectedErr)
+				require.NotContains(t, err.Error(), "s3cr3t")
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tcase.Expected, tokens)
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	tokens := operator.Tokens{"s3cr3t": "alice"}
+
+	name, ok := tokens.Authenticate("s3cr3t")
+	require.True(t, ok)
+	require.Equal(t, "alice", name)
+
+	_, ok = tokens.Authenticate("s3cr3")
+	require.False(t, ok)
+
+	_, ok = tokens.Authenticate("")
+	require.False(t, ok)
+
+	_, ok = operator.Tokens{}.Authenticate("")
+	require.False(t, ok, "nobody is authenticated without operators")
+}
@@ -0,0 +1,65 @@
+// Package proxy decides whether a peer is a trusted proxy, whose identity headers, e.g. the actor
+// of the audit log, are believed. Headers of other peers are set by the client and are ignored.
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+var ErrInvalidNetworks = errors.New("invalid trusted proxy networks")
+
+// Trusted are networks of trusted proxies, none is trusted if it is empty.
+type Trusted []netip.Prefix
+
+// ParseTrusted parses comma separated CIDRs or addresses, e.g. "10.0.0.0/8,127.0.0.1".
+func ParseTrusted(raw string) (Trusted, error) {
+	var trusted Trusted
+
+	for _, network := range strings.Split(raw, ",") {
+		network = strings.TrimSpace(network)
+		if network == "" {
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(network)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(network)
+			if addrErr != nil {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidNetworks, network)
+			}
+
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+
+		trusted = append(trusted, prefix.Masked())
+	}
+
+	return trusted, nil
+}
+
+// Contains reports whether the peer address, with or without a port, is in a trusted network.
+func (t Trusted) Contains(peer string) bool {
+	host, _, err := net.SplitHostPort(peer)
+	if err != nil {
+		host = peer
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+
+	addr = addr.Unmap()
+
+	for _, prefix := range t {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
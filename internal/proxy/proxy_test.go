@@ -0,0 +1,66 @@
+package proxy_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/aspirin100/finapi/internal/proxy"
+)
+
+func TestParseTrusted(t *testing.T) {
+	cases := []struct {
+		Name        string
+		Raw         string
+		ExpectedLen int
+		ExpectedErr error
+	}{
+		{
+			Name: "empty",
+			Raw:  "",
+		},
+		{
+			Name:        "networks and addresses",
+			Raw:         "10.0.0.0/8, 127.0.0.1,::1",
+			ExpectedLen: 3,
+		},
+		{
+			Name:        "invalid network",
+			Raw:         "10.0.0.0/8,proxy.local",
+			ExpectedErr: proxy.ErrInvalidNetworks,
+		},
+	}
+
+	for _, tcase := range cases {
+		t.Run(tcase.Name, func(t *testing.T) {
+			trusted, err := proxy.ParseTrusted(tcase.Raw)
+			require.ErrorIs(t, err, tcase.ExpectedErr)
+			require.Len(t, trusted, tcase.ExpectedLen)
+		})
+	}
+}
+
+func TestContains(t *testing.T) {
+	trusted, err := proxy.ParseTrusted("10.0.0.0/8,127.0.0.1")
+	require.NoError(t, err)
+
+	cases := []struct {
+		Name     string
+		Peer     string
+		Expected bool
+	}{
+		{Name: "network with port", Peer: "10.1.2.3:51234", Expected: true},
+		{Name: "address", Peer: "127.0.0.1", Expected: true},
+		{Name: "mapped ipv4", Peer: "[::ffff:10.1.2.3]:443", Expected: true},
+		{Name: "untrusted", Peer: "192.168.1.1:51234"},
+		{Name: "malformed", Peer: "proxy.local:80"},
+	}
+
+	for _, tcase := range cases {
+		t.Run(tcase.Name, func(t *testing.T) {
+			require.Equal(t, tcase.Expected, trusted.Contains(tcase.Peer))
+		})
+	}
+
+	require.False(t, proxy.Trusted(nil).Contains("127.0.0.1"), "nothing is trusted by default")
+}
@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/aspirin100/finapi/internal/logger"
+)
+
+// PostgresStore keeps buckets in postgres, so limits are shared by all replicas.
+type PostgresStore struct {
+	db *pgxpool.Pool
+}
+
+func NewPostgresStore(db *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	var (
+		tokens  float64
+		allowed bool
+	)
+
+	err := s.db.QueryRow(ctx, TakeTokenQuery, key, limit.rate(), float64(limit.Count)).Scan(&tokens, &allowed)
+	if err != nil {
+		return Result{}, fmt.Errorf("take token query fail: %w", err)
+	}
+
+	return limit.result(allowed, tokens), nil
+}
+
+// Prune removes buckets which are full by now, as they are the same as missing ones.
+func (s *PostgresStore) Prune(ctx context.Context) error {
+	_, err := s.db.Exec(ctx, PruneBucketsQuery)
+	if err != nil {
+		return fmt.Errorf("failed to prune rate limit buckets: %w", err)
+	}
+
+	return nil
+}
+
+// RunPruning prunes buckets every interval until ctx is done.
+func (s *PostgresStore) RunPruning(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := s.Prune(ctx)
+			if err != nil && ctx.Err() == nil {
+				logger.FromContext(ctx).Warn("rate limit buckets pruning failed", slog.Any("error", err))
+			}
+		}
+	}
+}
+
+const (
+	// TakeTokenQuery refills the bucket locked for update, or creates a full one,
+	// and takes a token from it if there is any.
+	TakeTokenQuery = `with bucket as (
+		select coalesce((
+			select least($3::float8, tokens + greatest(0, extract(epoch from now() - updatedAt)::float8) * $2::float8)
+			from rate_limit_buckets where key = $1 for update
+		), $3::float8) as tokens
+	), taken as (
+		select case when tokens >= 1 then tokens - 1 else tokens end as tokens from bucket
+	)
+	insert into rate_limit_buckets as b (key, tokens, updatedAt, fullAt)
+	select $1, tokens, now(), now() + make_interval(secs => ($3::float8 - tokens) / $2::float8) from taken
+	on conflict (key) do update
+	set tokens = excluded.tokens, updatedAt = excluded.updatedAt, fullAt = excluded.fullAt
+	returning b.tokens, (select tokens from bucket) >= 1`
+	PruneBucketsQuery = `delete from rate_limit_buckets where fullAt < now()`
+)
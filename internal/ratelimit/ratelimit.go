@@ -0,0 +1,246 @@
+// Package ratelimit implements token bucket rate limiting with pluggable bucket stores.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Kinds of bucket stores.
+const (
+	StoreNone     = "none"
+	StoreMemory   = "memory"
+	StorePostgres = "postgres"
+)
+
+var (
+	ErrInvalidLimit = errors.New("invalid rate limit")
+	ErrUnknownStore = errors.New("unknown rate limit store")
+)
+
+// Limit allows Count requests per Period, bursts of up to Count requests included.
+// Zero limit means no limit.
+type Limit struct {
+	Count  int
+	Period time.Duration
+}
+
+// ParseLimit parses limit in the "<count>/<period>" format, e.g. "20/s", "600/m" or "100/10s".
+// Empty string is parsed as no limit.
+func ParseLimit(raw string) (Limit, error) {
+	if raw == "" {
+		return Limit{}, nil
+	}
+
+	rawCount, rawPeriod, found := strings.Cut(raw, "/")
+	if !found {
+		return Limit{}, fmt.Errorf("%w %q: expected <count>/<period>", ErrInvalidLimit, raw)
+	}
+
+	count, err := strconv.Atoi(rawCount)
+	if err != nil || count <= 0 {
+		return Limit{}, fmt.Errorf("%w %q: count must be a positive integer", ErrInvalidLimit, raw)
+	}
+
+	if rawPeriod != "" && (rawPeriod[0] < '0' || rawPeriod[0] > '9') {
+		rawPeriod = "1" + rawPeriod
+	}
+
+	period, err := time.ParseDuration(rawPeriod)
+	if err != nil || period <= 0 {
+		return Limit{}, fmt.Errorf("%w %q: period must be a positive duration", ErrInvalidLimit, raw)
+	}
+
+	return Limit{Count: count, Period: period}, nil
+}
+
+// ParseRouteLimits parses comma separated "<method> <route>=<limit>" pairs,
+// e.g. "POST /v1/transfers=5/s,PATCH /:userID/transfer=5/s".
+func ParseRouteLimits(raw string) (map[string]Limit, error) {
+	limits := make(map[string]Limit)
+
+	for _, pair := range strings.Split(raw, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		route, rawLimit, found := strings.Cut(pair, "=")
+		if !found {
+			return nil, fmt.Errorf("%w %q: expected <method> <route>=<limit>", ErrInvalidLimit, pair)
+		}
+
+		limit, err := ParseLimit(strings.TrimSpace(rawLimit))
+		if err != nil {
+			return nil, err
+		}
+
+		limits[strings.Join(strings.Fields(route), " ")] = limit
+	}
+
+	return limits, nil
+}
+
+// ParseLimits parses ip, user and route limits in the ParseLimit and ParseRouteLimits formats.
+func ParseLimits(ip, user, routes string) (Limits, error) {
+	var (
+		limits Limits
+		err    error
+	)
+
+	limits.IP, err = ParseLimit(ip)
+	if err != nil {
+		return Limits{}, fmt.Errorf("ip limit: %w", err)
+	}
+
+	limits.User, err = ParseLimit(user)
+	if err != nil {
+		return Limits{}, fmt.Errorf("user limit: %w", err)
+	}
+
+	limits.Routes, err = ParseRouteLimits(routes)
+	if err != nil {
+		return Limits{}, fmt.Errorf("route limits: %w", err)
+	}
+
+	return limits, nil
+}
+
+func (l Limit) unlimited() bool {
+	return l.Count <= 0 || l.Period <= 0
+}
+
+// rate returns number of tokens added to the bucket per second.
+func (l Limit) rate() float64 {
+	return float64(l.Count) / l.Period.Seconds()
+}
+
+// Result is the state of the bucket after taking a token from it.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter is the time until the next token is available, zero if the request is allowed.
+	RetryAfter time.Duration
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+}
+
+// result builds Result from the amount of tokens left in the bucket.
+func (l Limit) result(allowed bool, tokens float64) Result {
+	res := Result{
+		Allowed:   allowed,
+		Limit:     l.Count,
+		Remaining: int(math.Max(0, math.Floor(tokens))),
+		Reset:     secondsToDuration((float64(l.Count) - tokens) / l.rate()),
+	}
+
+	if !allowed {
+		res.RetryAfter = secondsToDuration((1 - tokens) / l.rate())
+	}
+
+	return res
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(math.Ceil(seconds * float64(time.Second)))
+}
+
+// Store keeps token buckets. Take refills the bucket of key according to the limit
+// and takes one token from it if there is any.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// Limits are the limits applied to every request. Routes are keyed by "<method> <route>".
+type Limits struct {
+	IP     Limit
+	User   Limit
+	Routes map[string]Limit
+}
+
+// Request identifies the client and the route of the limited request.
+// Empty User means the request doesn't act on behalf of a user.
+type Request struct {
+	IP     string
+	User   string
+	Method string
+	Route  string
+}
+
+type Limiter struct {
+	store  Store
+	limits atomic.Pointer[Limits]
+}
+
+func New(store Store, limits Limits) *Limiter {
+	limiter := &Limiter{store: store}
+	limiter.SetLimits(limits)
+
+	return limiter
+}
+
+// SetLimits replaces limits of the limiter, buckets are kept.
+func (l *Limiter) SetLimits(limits Limits) {
+	l.limits.Store(&limits)
+}
+
+// Allow takes a token from every bucket the request falls into: route limits are applied
+// per client ip, user limits per user and ip limits per ip. The request is allowed if all
+// buckets have tokens, the most restrictive result is returned. Nil result means the request isn't limited.
+func (l *Limiter) Allow(ctx context.Context, req Request) (*Result, error) {
+	limits := l.limits.Load()
+
+	type bucket struct {
+		key   string
+		limit Limit
+	}
+
+	route := req.Method + " " + req.Route
+
+	buckets := []bucket{
+		{key: "route:" + route + ":" + req.IP, limit: limits.Routes[route]},
+		{key: "ip:" + req.IP, limit: limits.IP},
+	}
+
+	if req.User != "" {
+		buckets = append(buckets, bucket{key: "user:" + req.User, limit: limits.User})
+	}
+
+	var strictest *Result
+
+	for _, b := range buckets {
+		if b.limit.unlimited() {
+			continue
+		}
+
+		res, err := l.store.Take(ctx, b.key, b.limit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to take token of %s: %w", b.key, err)
+		}
+
+		if strictest == nil || stricter(res, *strictest) {
+			strictest = &res
+		}
+	}
+
+	return strictest, nil
+}
+
+func stricter(a, b Result) bool {
+	if a.Allowed != b.Allowed {
+		return !a.Allowed
+	}
+
+	if !a.Allowed {
+		return a.RetryAfter > b.RetryAfter
+	}
+
+	return a.Remaining < b.Remaining
+}
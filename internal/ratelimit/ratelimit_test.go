@@ -0,0 +1,151 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLimit(t *testing.T) {
+	cases := []struct {
+		Name          string
+		Raw           string
+		ExpectedLimit Limit
+		ExpectedErr   error
+	}{
+		{
+			Name:          "per second",
+			Raw:           "20/s",
+			ExpectedLimit: Limit{Count: 20, Period: time.Second},
+		},
+		{
+			Name:          "per minute",
+			Raw:           "600/m",
+			ExpectedLimit: Limit{Count: 600, Period: time.Minute},
+		},
+		{
+			Name:          "custom period",
+			Raw:           "100/10s",
+			ExpectedLimit: Limit{Count: 100, Period: 10 * time.Second},
+		},
+		{
+			Name: "no limit",
+			Raw:  "",
+		},
+		{
+			Name:        "missing period",
+			Raw:         "20",
+			ExpectedErr: ErrInvalidLimit,
+		},
+		{
+			Name:        "negative count",
+			Raw:         "-1/s",
+			ExpectedErr: ErrInvalidLimit,
+		},
+		{
+			Name:        "unknown unit",
+			Raw:         "1/day",
+			ExpectedErr: ErrInvalidLimit,
+		},
+	}
+
+	for _, tcase := range cases {
+		t.Run(tcase.Name, func(t *testing.T) {
+			limit, err := ParseLimit(tcase.Raw)
+			require.ErrorIs(t, err, tcase.ExpectedErr)
+			require.Equal(t, tcase.ExpectedLimit, limit)
+		})
+	}
+}
+
+func TestParseRouteLimits(t *testing.T) {
+	limits, err := ParseRouteLimits("POST  /v1/transfers=5/s, PATCH /:userID/transfer = 1/m,")
+	require.NoError(t, err)
+	require.Equal(t, map[string]Limit{
+		"POST /v1/transfers":      {Count: 5, Period: time.Second},
+		"PATCH /:userID/transfer": {Count: 1, Period: time.Minute},
+	}, limits)
+
+	_, err = ParseRouteLimits("POST /v1/transfers")
+	require.ErrorIs(t, err, ErrInvalidLimit)
+}
+
+func TestMemoryStore(t *testing.T) {
+	now := time.Now()
+
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	limit := Limit{Count: 2, Period: time.Second}
+
+	res, err := store.Take(context.Background(), "key", limit)
+	require.NoError(t, err)
+	require.Equal(t, Result{Allowed: true, Limit: 2, Remaining: 1, Reset: 500 * time.Millisecond}, res)
+
+	res, err = store.Take(context.Background(), "key", limit)
+	require.NoError(t, err)
+	require.True(t, res.Allowed)
+	require.Equal(t, 0, res.Remaining)
+
+	res, err = store.Take(context.Background(), "key", limit)
+	require.NoError(t, err)
+	require.False(t, res.Allowed)
+	require.Equal(t, 500*time.Millisecond, res.RetryAfter)
+	require.Equal(t, time.Second, res.Reset)
+
+	// other keys have their own buckets
+	res, err = store.Take(context.Background(), "other", limit)
+	require.NoError(t, err)
+	require.True(t, res.Allowed)
+
+	now = now.Add(500 * time.Millisecond)
+
+	res, err = store.Take(context.Background(), "key", limit)
+	require.NoError(t, err)
+	require.True(t, res.Allowed)
+
+	// full buckets are swept
+	now = now.Add(sweepInterval)
+
+	_, err = store.Take(context.Background(), "key", limit)
+	require.NoError(t, err)
+	require.Len(t, store.buckets, 1)
+}
+
+func TestLimiter(t *testing.T) {
+	limiter := New(NewMemoryStore(), Limits{
+		IP:     Limit{Count: 3, Period: time.Hour},
+		User:   Limit{Count: 2, Period: time.Hour},
+		Routes: map[string]Limit{"POST /v1/transfers": {Count: 1, Period: time.Hour}},
+	})
+
+	ctx := context.Background()
+
+	transfer := Request{IP: "10.0.0.1", User: "user", Method: "POST", Route: "/v1/transfers"}
+	deposit := Request{IP: "10.0.0.1", User: "user", Method: "POST", Route: "/v1/accounts/:id/deposits"}
+
+	res, err := limiter.Allow(ctx, transfer)
+	require.NoError(t, err)
+	require.True(t, res.Allowed)
+	require.Equal(t, 0, res.Remaining, "the strictest bucket is reported")
+
+	res, err = limiter.Allow(ctx, transfer)
+	require.NoError(t, err)
+	require.False(t, res.Allowed, "route limit exceeded")
+
+	res, err = limiter.Allow(ctx, deposit)
+	require.NoError(t, err)
+	require.False(t, res.Allowed, "user limit exceeded")
+
+	res, err = limiter.Allow(ctx, Request{IP: "10.0.0.2", Method: "GET", Route: "/healthz"})
+	require.NoError(t, err)
+	require.True(t, res.Allowed, "other ip")
+
+	limiter.SetLimits(Limits{})
+
+	res, err = limiter.Allow(ctx, transfer)
+	require.NoError(t, err)
+	require.Nil(t, res, "limits are removed")
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/aspirin100/finapi/internal/entity"
+)
+
+// archiveExt is the extension of archive files, one JSON encoded transaction per line compressed with gzip.
+const archiveExt = ".ndjson.gz"
+
+// writeArchive writes transactions returned by next to the file at path until next returns false
+// and returns their count. The file is replaced only when all transactions are written.
+func writeArchive(path string, next func(transaction *entity.Transaction) (bool, error)) (int64, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".archive-*")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create archive file: %w", err)
+	}
+
+	defer os.Remove(tmp.Name()) //nolint:errcheck
+
+	count, err := encodeArchive(tmp, next)
+	if err == nil {
+		err = tmp.Sync()
+	}
+
+	err = errors.Join(err, tmp.Close())
+	if err != nil {
+		return 0, fmt.Errorf("failed to write archive file: %w", err)
+	}
+
+	err = os.Rename(tmp.Name(), path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save archive file: %w", err)
+	}
+
+	return count, nil
+}
+
+func encodeArchive(w io.Writer, next func(transaction *entity.Transaction) (bool, error)) (int64, error) {
+	zw := gzip.NewWriter(w)
+	encoder := json.NewEncoder(zw)
+
+	var count int64
+
+	for {
+		var transaction entity.Transaction
+
+		ok, err := next(&transaction)
+		if err != nil {
+			return 0, err
+		}
+
+		if !ok {
+			break
+		}
+
+		err = encoder.Encode(transaction)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode transaction: %w", err)
+		}
+
+		count++
+	}
+
+	err := zw.Close()
+	if err != nil {
+		return 0, fmt.Errorf("failed to compress: %w", err)
+	}
+
+	return count, nil
+}
+
+// readArchive returns transactions of the archive file at path matching the filter.
+func readArchive(path string, match func(transaction entity.Transaction) bool) ([]entity.Transaction, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer file.Close()
+
+	zr, err := gzip.NewReader(bufio.NewReader(file))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress archive %s: %w", path, err)
+	}
+
+	decoder := json.NewDecoder(zr)
+
+	var transactions []entity.Transaction
+
+	for {
+		var transaction entity.Transaction
+
+		err = decoder.Decode(&transaction)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode archive %s: %w", path, err)
+		}
+
+		if match(transaction) {
+			transactions = append(transactions, transaction)
+		}
+	}
+
+	return transactions, nil
+}
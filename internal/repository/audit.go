@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/aspirin100/finapi/internal/audit"
+	"github.com/aspirin100/finapi/internal/entity"
+)
+
+// auditLogLockKey is the transaction level advisory lock serializing appends to the audit log,
+// so concurrent transactions can't link their entries to the same previous one.
+const auditLogLockKey = 7_283_104_561
+
+// AppendAudit links the entry to the last one in the log and saves it. Inside a transaction the entry
+// is committed or rolled back together with the audited change and the log is locked until then,
+// so audited transactions commit one at a time: append as late as possible, right before the commit.
+// The lock is taken right before reading the head the entry links to, which must not change until
+// the entry is committed.
+func (r *Repository) AppendAudit(ctx context.Context, entry entity.AuditEntry) error {
+	tx, ok := ctx.Value(txContextKey).(pgx.Tx)
+	if ok {
+		return appendAudit(ctx, tx, entry)
+	}
+
+	err := pgx.BeginFunc(ctx, r.DB, func(tx pgx.Tx) error {
+		return appendAudit(ctx, tx, entry)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to append audit entry: %w", err)
+	}
+
+	return nil
+}
+
+func appendAudit(ctx context.Context, tx pgx.Tx, entry entity.AuditEntry) error {
+	_, err := tx.Exec(ctx, LockAuditLogQuery, auditLogLockKey)
+	if err != nil {
+		return fmt.Errorf("failed to lock audit log: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, GetAuditHeadQuery)
+	if err != nil {
+		return fmt.Errorf("failed to get last audit entry: %w", err)
+	}
+
+	heads, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return fmt.Errorf("failed to read last audit entry: %w", err)
+	}
+
+	var prevHash string
+	if len(heads) > 0 {
+		prevHash = heads[0]
+	}
+
+	err = audit.Chain(&entry, prevHash)
+	if err != nil {
+		return fmt.Errorf("failed to chain audit entry: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, NewAuditEntryQuery,
+		entry.ID,
+		entry.Actor,
+		entry.Action,
+		entry.Resource,
+		[]byte(entry.Before),
+		[]byte(entry.After),
+		entry.RequestID,
+		entry.CreatedAt,
+		entry.PrevHash,
+		entry.Hash)
+	if err != nil {
+		return fmt.Errorf("failed to save audit entry: %w", err)
+	}
+
+	return nil
+}
+
+// WalkAuditLog calls fn for every entry of the log in the chain order until fn returns an error.
+func (r *Repository) WalkAuditLog(ctx context.Context, fn func(entry entity.AuditEntry) error) error {
+	rows, err := r.DB.Query(ctx, GetAuditLogQuery)
+	if err != nil {
+		return fmt.Errorf("failed to get audit log: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			entry         entity.AuditEntry
+			before, after []byte
+		)
+
+		err = rows.Scan(
+			&entry.Seq,
+			&entry.ID,
+			&entry.Actor,
+			&entry.Action,
+			&entry.Resource,
+			&before,
+			&after,
+			&entry.RequestID,
+			&entry.CreatedAt,
+			&entry.PrevHash,
+			&entry.Hash)
+		if err != nil {
+			return fmt.Errorf("failed to read audit entry: %w", err)
+		}
+
+		entry.Before, entry.After = before, after
+
+		err = fn(entry)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	return nil
+}
+
+const (
+	LockAuditLogQuery  = `select pg_advisory_xact_lock($1)`
+	GetAuditHeadQuery  = `select hash from audit_log order by seq desc limit 1`
+	NewAuditEntryQuery = `insert into audit_log(id, actor, action, resource, before, after, requestID, createdAt, prevHash, hash)
+	values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	GetAuditLogQuery = `select
+	seq, id, actor, action, resource, before, after, requestID, createdAt, prevHash, hash
+	from audit_log
+	order by seq`
+)
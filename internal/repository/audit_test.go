@@ -0,0 +1,53 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/aspirin100/finapi/internal/audit"
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/repository"
+)
+
+var errRollback = errors.New("rollback")
+
+func TestAuditLog(t *testing.T) {
+	ctx := context.Background()
+
+	repo, err := repository.NewConnection(ctx, PostgresDSN)
+	require.NoError(t, err)
+
+	defer repo.DB.Close()
+
+	entry, err := audit.NewEntry(ctx, audit.ActionDeposit, "account/"+UserIDs[0], nil, map[string]int{"balance": 1})
+	require.NoError(t, err)
+
+	require.NoError(t, repo.AppendAudit(ctx, entry))
+
+	// the entry of rolled back transaction doesn't break the chain
+	txCtx, commitOrRollback, err := repo.BeginTx(ctx)
+	require.NoError(t, err)
+
+	entry, err = audit.NewEntry(ctx, audit.ActionDeposit, "account/"+UserIDs[0], nil, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, repo.AppendAudit(txCtx, entry))
+	require.ErrorIs(t, commitOrRollback(errRollback), errRollback)
+
+	var verifier audit.Verifier
+
+	err = repo.WalkAuditLog(ctx, func(entry entity.AuditEntry) error {
+		return verifier.Check(entry)
+	})
+	require.NoError(t, err)
+	require.Positive(t, verifier.Entries())
+
+	_, err = repo.DB.Exec(ctx, `update audit_log set actor = 'someone'`)
+	require.Error(t, err, "audit log is append-only")
+
+	_, err = repo.DB.Exec(ctx, `delete from audit_log`)
+	require.Error(t, err, "audit log is append-only")
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/aspirin100/finapi/internal/audit"
+	"github.com/aspirin100/finapi/internal/entity"
+)
+
+func (r *Repository) GetBlockedParties(ctx context.Context) ([]entity.BlockedParty, error) {
+	rows, err := r.checkTx(ctx).Query(ctx, GetBlockedPartiesQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blocked parties: %w", err)
+	}
+
+	parties, err := pgx.CollectRows(rows, scanBlockedParty)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blocked parties: %w", err)
+	}
+
+	return parties, nil
+}
+
+// BlockParty adds the party to the blocklist or updates the reason if it is already blocked.
+func (r *Repository) BlockParty(ctx context.Context, partyID uuid.UUID, reason string) (*entity.BlockedParty, error) {
+	rows, err := r.checkTx(ctx).Query(ctx, BlockPartyQuery, partyID, reason)
+	if err != nil {
+		return nil, fmt.Errorf("failed to block party: %w", err)
+	}
+
+	party, err := pgx.CollectExactlyOneRow(rows, scanBlockedParty)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blocked party: %w", err)
+	}
+
+	return &party, nil
+}
+
+func (r *Repository) UnblockParty(ctx context.Context, partyID uuid.UUID) error {
+	tag, err := r.checkTx(ctx).Exec(ctx, UnblockPartyQuery, partyID)
+	if err != nil {
+		return fmt.Errorf("failed to unblock party: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// ImportBlockedParties blocks all the parties at once, with replace the rest of the blocklist is removed.
+// The import is recorded in the audit log.
+func (r *Repository) ImportBlockedParties(ctx context.Context,
+	parties []entity.BlockedParty,
+	replace bool) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	if replace {
+		_, err = tx.Exec(ctx, ClearBlocklistQuery)
+		if err != nil {
+			return fmt.Errorf("failed to clear blocklist: %w", err)
+		}
+	}
+
+	batch := &pgx.Batch{}
+	for _, party := range parties {
+		batch.Queue(BlockPartyQuery, party.ID, party.Reason)
+	}
+
+	err = tx.SendBatch(ctx, batch).Close()
+	if err != nil {
+		return fmt.Errorf("failed to import blocklist: %w", err)
+	}
+
+	entry, err := audit.NewEntry(ctx, audit.ActionBlocklistImport, "blocklist", nil, struct {
+		Parties int  `json:"parties"`
+		Replace bool `json:"replace"`
+	}{len(parties), replace})
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	err = appendAudit(ctx, tx, entry)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to commit blocklist import: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) SaveScreeningDecisions(ctx context.Context, decisions []entity.ScreeningDecision) error {
+	batch := &pgx.Batch{}
+	for _, decision := range decisions {
+		batch.Queue(NewScreeningDecisionQuery,
+			decision.ID,
+			decision.Operation,
+			decision.PartyID,
+			decision.Blocked,
+			decision.Reason,
+			decision.RequestID)
+	}
+
+	err := r.checkTx(ctx).SendBatch(ctx, batch).Close()
+	if err != nil {
+		return fmt.Errorf("failed to save screening decisions: %w", err)
+	}
+
+	return nil
+}
+
+func scanBlockedParty(row pgx.CollectableRow) (entity.BlockedParty, error) {
+	var party entity.BlockedParty
+
+	err := row.Scan(&party.ID, &party.Reason, &party.CreatedAt)
+
+	return party, err //nolint:wrapcheck
+}
+
+const (
+	GetBlockedPartiesQuery = `select id, reason, createdAt from blocked_parties order by createdAt`
+	BlockPartyQuery        = `insert into blocked_parties(id, reason) values ($1, $2)
+	on conflict (id) do update set reason = excluded.reason
+	returning id, reason, createdAt`
+	UnblockPartyQuery         = `delete from blocked_parties where id = $1`
+	ClearBlocklistQuery       = `delete from blocked_parties`
+	NewScreeningDecisionQuery = `insert into screening_decisions(id, operation, partyID, blocked, reason, requestID)
+	values ($1, $2, $3, $4, $5, $6)`
+)
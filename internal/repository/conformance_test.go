@@ -0,0 +1,20 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/aspirin100/finapi/internal/repository"
+	"github.com/aspirin100/finapi/internal/repository/repotest"
+)
+
+func TestConformance(t *testing.T) {
+	repo, err := repository.NewConnection(context.Background(), PostgresDSN)
+	require.NoError(t, err)
+
+	defer repo.DB.Close()
+
+	repotest.Run(t, repo)
+}
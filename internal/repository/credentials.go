@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/aspirin100/finapi/internal/logger"
+)
+
+// PasswordFunc returns the current postgres password, e.g. from a rotated secret.
+type PasswordFunc func(ctx context.Context) (string, error)
+
+// WithPassword connects the primary and replica pools with the password instead of the one of the DSN,
+// RunPasswordRotation asks for it again to pick up rotated passwords.
+func WithPassword(password PasswordFunc) Option {
+	return func(r *Repository) {
+		r.passwordFunc = password
+	}
+}
+
+// loadPassword asks for the password to connect with, it's a no-op without WithPassword.
+func (r *Repository) loadPassword(ctx context.Context) error {
+	if r.passwordFunc == nil {
+		return nil
+	}
+
+	password, err := r.passwordFunc(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get postgres password: %w", err)
+	}
+
+	r.password.Store(&password)
+
+	return nil
+}
+
+// setPassword makes new connections of the pool use the current password, connections
+// are authenticated only once, so the open ones aren't affected by rotation.
+func (r *Repository) setPassword(connConfig *pgx.ConnConfig) {
+	if r.passwordFunc == nil {
+		return
+	}
+
+	connConfig.Password = *r.password.Load()
+}
+
+// RunPasswordRotation asks for the password every interval until ctx is done. A changed password
+// is checked with a new connection to the primary first and the pools connect with it from then on.
+// A password failing the check is tried again on the next tick, so the secret may be rotated
+// before or after the database.
+func (r *Repository) RunPasswordRotation(ctx context.Context, interval time.Duration) {
+	if r.passwordFunc == nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.rotatePassword(ctx, interval)
+		}
+	}
+}
+
+func (r *Repository) rotatePassword(ctx context.Context, timeout time.Duration) {
+	log := logger.FromContext(ctx)
+
+	password, err := r.passwordFunc(ctx)
+	if err != nil {
+		log.Error("failed to get postgres password", slog.Any("error", err))
+
+		return
+	}
+
+	if password == *r.password.Load() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	connConfig := r.DB.Config().ConnConfig.Copy()
+	connConfig.Password = password
+
+	conn, err := pgx.ConnectConfig(ctx, connConfig)
+	if err != nil {
+		log.Error("rotated postgres password is rejected, keeping the current one", slog.Any("error", err))
+
+		return
+	}
+
+	err = conn.Close(ctx)
+	if err != nil {
+		log.Warn("failed to close password check connection", slog.Any("error", err))
+	}
+
+	r.password.Store(&password)
+
+	log.Info("postgres password rotated")
+}
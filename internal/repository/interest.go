@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/aspirin100/finapi/internal/entity"
+)
+
+// GetAccountsByType returns all accounts of the type.
+func (r *Repository) GetAccountsByType(ctx context.Context, accountType string) ([]entity.Account, error) {
+	rows, err := r.checkTx(ctx).Query(ctx, GetAccountsByTypeQuery, accountType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounts: %w", err)
+	}
+
+	accounts, err := pgx.CollectRows(rows, scanAccount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read accounts: %w", err)
+	}
+
+	return accounts, nil
+}
+
+// GetDailyBalances returns end of day balances of the account for every day in [from, to),
+// both are expected to be UTC midnights. A balance is the current one less all the changes made after the day,
+// so it doesn't depend on any job running daily.
+func (r *Repository) GetDailyBalances(ctx context.Context,
+	accountID uuid.UUID,
+	from, to time.Time) ([]entity.DailyBalance, error) {
+	rows, err := r.checkTx(ctx).Query(ctx, GetDailyBalancesQuery, accountID, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily balances: %w", err)
+	}
+
+	balances, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.DailyBalance, error) {
+		var balance entity.DailyBalance
+
+		err := row.Scan(&balance.Day, &balance.Balance)
+
+		return balance, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read daily balances: %w", err)
+	}
+
+	if len(balances) == 0 && from.Before(to) {
+		return nil, ErrUserNotFound
+	}
+
+	return balances, nil
+}
+
+// SaveInterestPayout records the payout, ErrAlreadyPaid is returned if interest of the period
+// is already paid to the account, so the db transaction paying it again must be rolled back.
+func (r *Repository) SaveInterestPayout(ctx context.Context, payout entity.InterestPayout) error {
+	tag, err := r.checkTx(ctx).Exec(ctx, NewInterestPayoutQuery,
+		payout.AccountID,
+		payout.Period.UTC(),
+		payout.Amount,
+		payout.TransactionID)
+	if err != nil {
+		if isConflict(err) {
+			return ErrTxConflict
+		}
+
+		return fmt.Errorf("failed to save interest payout: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrAlreadyPaid
+	}
+
+	return nil
+}
+
+const (
+	GetAccountsByTypeQuery = `select userID, balance, tier, type from bank_accounts where type = $1 order by userID`
+	// deposits have the same sender and receiver and are the only operation adding money from outside
+	GetDailyBalancesQuery = `select day::date, b.balance - coalesce((
+		select sum(case when t.operation = 'deposit' or t.receiverID = $1 then t.amount else -t.amount end)
+		from transactions t
+		where (t.receiverID = $1 or t.senderID = $1)
+		and t.createdAt >= (day + interval '1 day') at time zone 'UTC'), 0)
+	from bank_accounts b,
+	generate_series($2::timestamp, $3::timestamp - interval '1 day', interval '1 day') day
+	where b.userID = $1
+	order by day`
+	NewInterestPayoutQuery = `insert into interest_payouts(accountID, period, amount, transactionID)
+	values ($1, $2, $3, $4)
+	on conflict (accountID, period) do nothing`
+)
@@ -0,0 +1,61 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/repository"
+)
+
+func TestInterest(t *testing.T) {
+	ctx := context.Background()
+
+	repo, err := repository.NewConnection(ctx, PostgresDSN)
+	require.NoError(t, err)
+
+	defer repo.DB.Close()
+
+	account, err := repo.CreateAccount(ctx, uuid.New(), entity.AccountSavings)
+	require.NoError(t, err)
+
+	amount := decimal.NewFromInt(100)
+
+	_, err = repo.UpdateBalance(ctx, account.ID, amount)
+	require.NoError(t, err)
+
+	transaction, err := repo.SaveTransaction(ctx, account.ID, account.ID, amount, "deposit")
+	require.NoError(t, err)
+
+	savings, err := repo.GetAccountsByType(ctx, entity.AccountSavings)
+	require.NoError(t, err)
+	require.Contains(t, savings, entity.Account{
+		ID:      account.ID,
+		Balance: amount,
+		Tier:    entity.TierStandard,
+		Type:    entity.AccountSavings,
+	})
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	balances, err := repo.GetDailyBalances(ctx, account.ID, today.AddDate(0, 0, -1), today.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Len(t, balances, 2)
+	require.True(t, balances[0].Balance.IsZero(), "deposit is made after yesterday")
+	require.True(t, balances[1].Balance.Equal(amount))
+
+	payout := entity.InterestPayout{
+		AccountID:     account.ID,
+		Period:        today.AddDate(0, -1, 0),
+		Amount:        decimal.RequireFromString("0.5"),
+		TransactionID: transaction.ID,
+	}
+
+	require.NoError(t, repo.SaveInterestPayout(ctx, payout))
+	require.ErrorIs(t, repo.SaveInterestPayout(ctx, payout), repository.ErrAlreadyPaid)
+}
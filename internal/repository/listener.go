@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/logger"
+)
+
+const (
+	TransactionsChannel = "finapi_transactions"
+
+	listenRetryWait = time.Second
+)
+
+// Publisher receives transactions committed by any finapi replica.
+type Publisher interface {
+	Publish(transaction entity.Transaction)
+}
+
+// ListenTransactions listens notifications sent by the transactions insert trigger
+// and publishes committed transactions until ctx is done. Notifications are delivered
+// only after commit, so rolled back transactions are never published.
+// Lost connection is reestablished, notifications sent meanwhile are lost.
+func (r *Repository) ListenTransactions(ctx context.Context, publisher Publisher) {
+	log := logger.FromContext(ctx)
+
+	for {
+		err := r.listen(ctx, publisher)
+		if ctx.Err() != nil {
+			return
+		}
+
+		log.Error("transactions listener failed, reconnecting", slog.Any("error", err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(listenRetryWait):
+		}
+	}
+}
+
+func (r *Repository) listen(ctx context.Context, publisher Publisher) error {
+	pooled, err := r.DB.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+
+	// connection in LISTEN state must not be returned to the pool
+	conn := pooled.Hijack()
+	defer conn.Close(context.WithoutCancel(ctx))
+
+	_, err = conn.Exec(ctx, "listen "+TransactionsChannel)
+	if err != nil {
+		return fmt.Errorf("failed to listen channel: %w", err)
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to wait for notification: %w", err)
+		}
+
+		transaction, err := decodeNotification(notification)
+		if err != nil {
+			logger.FromContext(ctx).Error("failed to decode notification", slog.Any("error", err))
+
+			continue
+		}
+
+		publisher.Publish(*transaction)
+	}
+}
+
+var ErrUnexpectedChannel = errors.New("notification from unexpected channel")
+
+func decodeNotification(notification *pgconn.Notification) (*entity.Transaction, error) {
+	if notification.Channel != TransactionsChannel {
+		return nil, fmt.Errorf("%w: %s", ErrUnexpectedChannel, notification.Channel)
+	}
+
+	var transaction entity.Transaction
+
+	err := json.Unmarshal([]byte(notification.Payload), &transaction)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal transaction: %w", err)
+	}
+
+	return &transaction, nil
+}
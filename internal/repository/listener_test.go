@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeNotification(t *testing.T) {
+	// payload as built by notify_transaction trigger
+	payload := `{"id" : "0b8a1c35-5ac4-4f4b-8d2a-4bd1f3a0e7c1", ` +
+		`"senderID" : "4178f61f-2ff9-4ab5-afa5-f30dc16e6ad9", ` +
+		`"receiverID" : "3fec06e9-29cc-4ff4-9ae7-fb0e7c757b61", ` +
+		`"amount" : "100.10", "operation" : "transfer", ` +
+		`"createdAt" : "2025-03-01T12:00:00.123456+00:00"}`
+
+	transaction, err := decodeNotification(&pgconn.Notification{
+		Channel: TransactionsChannel,
+		Payload: payload,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, uuid.MustParse("0b8a1c35-5ac4-4f4b-8d2a-4bd1f3a0e7c1"), transaction.ID)
+	require.Equal(t, uuid.MustParse("4178f61f-2ff9-4ab5-afa5-f30dc16e6ad9"), transaction.SenderID)
+	require.Equal(t, uuid.MustParse("3fec06e9-29cc-4ff4-9ae7-fb0e7c757b61"), transaction.ReceiverID)
+	require.True(t, decimal.RequireFromString("100.10").Equal(transaction.Amount))
+	require.Equal(t, "transfer", transaction.Operation)
+	require.Equal(t, time.Date(2025, 3, 1, 12, 0, 0, 123456000, time.UTC), transaction.CreatedAt.UTC())
+
+	_, err = decodeNotification(&pgconn.Notification{Channel: "other", Payload: payload})
+	require.ErrorIs(t, err, ErrUnexpectedChannel)
+}
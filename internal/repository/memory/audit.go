@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aspirin100/finapi/internal/audit"
+	"github.com/aspirin100/finapi/internal/entity"
+)
+
+// AppendAudit links the entry to the last one in the log and saves it. Inside a transaction the entry
+// is committed or rolled back together with the audited change.
+func (s *Store) AppendAudit(ctx context.Context, entry entity.AuditEntry) error {
+	return s.do(ctx, func(tx *tx) error {
+		var prevHash string
+		if len(s.auditLog) > 0 {
+			prevHash = s.auditLog[len(s.auditLog)-1].Hash
+		}
+
+		err := audit.Chain(&entry, prevHash)
+		if err != nil {
+			return fmt.Errorf("failed to chain audit entry: %w", err)
+		}
+
+		entry.Seq = int64(len(s.auditLog) + 1)
+		s.auditLog = append(s.auditLog, entry)
+
+		tx.onRollback(func() {
+			s.auditLog = s.auditLog[:len(s.auditLog)-1]
+		})
+
+		return nil
+	})
+}
+
+// WalkAuditLog calls fn for every entry of the log in the chain order until fn returns an error.
+func (s *Store) WalkAuditLog(ctx context.Context, fn func(entry entity.AuditEntry) error) error {
+	var entries []entity.AuditEntry
+
+	err := s.do(ctx, func(_ *tx) error {
+		entries = append(entries, s.auditLog...)
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		err = fn(entry)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package memory
+
+import (
+	"context"
+	"slices"
+
+	"github.com/google/uuid"
+
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/repository"
+)
+
+func (s *Store) GetBlockedParties(ctx context.Context) ([]entity.BlockedParty, error) {
+	parties := []entity.BlockedParty{}
+
+	err := s.do(ctx, func(_ *tx) error {
+		for _, party := range s.blocked {
+			parties = append(parties, party)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(parties, func(a, b entity.BlockedParty) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	return parties, nil
+}
+
+// BlockParty adds the party to the blocklist or updates the reason if it is already blocked.
+func (s *Store) BlockParty(ctx context.Context, partyID uuid.UUID, reason string) (*entity.BlockedParty, error) {
+	var party entity.BlockedParty
+
+	err := s.do(ctx, func(tx *tx) error {
+		before, blocked := s.blocked[partyID]
+
+		party = entity.BlockedParty{ID: partyID, Reason: reason, CreatedAt: tx.now}
+		if blocked {
+			party.CreatedAt = before.CreatedAt
+		}
+
+		s.blocked[partyID] = party
+
+		tx.onRollback(func() {
+			if blocked {
+				s.blocked[partyID] = before
+			} else {
+				delete(s.blocked, partyID)
+			}
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &party, nil
+}
+
+func (s *Store) UnblockParty(ctx context.Context, partyID uuid.UUID) error {
+	return s.do(ctx, func(tx *tx) error {
+		before, blocked := s.blocked[partyID]
+		if !blocked {
+			return repository.ErrNotFound
+		}
+
+		delete(s.blocked, partyID)
+
+		tx.onRollback(func() {
+			s.blocked[partyID] = before
+		})
+
+		return nil
+	})
+}
+
+func (s *Store) SaveScreeningDecisions(ctx context.Context, decisions []entity.ScreeningDecision) error {
+	return s.do(ctx, func(tx *tx) error {
+		saved := len(s.decisions)
+
+		for _, decision := range decisions {
+			decision.CreatedAt = tx.now
+			s.decisions = append(s.decisions, decision)
+		}
+
+		tx.onRollback(func() {
+			s.decisions = s.decisions[:saved]
+		})
+
+		return nil
+	})
+}
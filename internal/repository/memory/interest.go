@@ -0,0 +1,124 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/repository"
+)
+
+// GetAccountsByType returns all accounts of the type.
+func (s *Store) GetAccountsByType(ctx context.Context, accountType string) ([]entity.Account, error) {
+	accounts := []entity.Account{}
+
+	err := s.do(ctx, func(_ *tx) error {
+		for _, account := range s.accounts {
+			if account.Type == accountType {
+				accounts = append(accounts, account)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(accounts, func(a, b entity.Account) int {
+		return compareIDs(a.ID, b.ID)
+	})
+
+	return accounts, nil
+}
+
+// GetDailyBalances returns end of day balances of the account for every day in [from, to),
+// both are expected to be UTC midnights. A balance is the current one less all the changes made after the day.
+func (s *Store) GetDailyBalances(ctx context.Context,
+	accountID uuid.UUID,
+	from, to time.Time) ([]entity.DailyBalance, error) {
+	balances := []entity.DailyBalance{}
+
+	err := s.do(ctx, func(_ *tx) error {
+		account, ok := s.accounts[accountID]
+		if !ok {
+			if from.Before(to) {
+				return repository.ErrUserNotFound
+			}
+
+			return nil
+		}
+
+		for day := from.UTC(); day.Before(to); day = day.AddDate(0, 0, 1) {
+			end := day.AddDate(0, 0, 1)
+			balance := account.Balance
+
+			for _, transaction := range s.transactions {
+				if transaction.CreatedAt.Before(end) {
+					continue
+				}
+
+				balance = balance.Sub(balanceChange(accountID, transaction))
+			}
+
+			balances = append(balances, entity.DailyBalance{Day: day, Balance: balance})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return balances, nil
+}
+
+// balanceChange is how the transaction changed the balance of the account,
+// deposits have the same sender and receiver and are the only operation adding money from outside.
+func balanceChange(accountID uuid.UUID, transaction entity.Transaction) decimal.Decimal {
+	switch {
+	case transaction.ReceiverID != accountID && transaction.SenderID != accountID:
+		return decimal.Zero
+	case transaction.Operation == "deposit" || transaction.ReceiverID == accountID:
+		return transaction.Amount
+	default:
+		return transaction.Amount.Neg()
+	}
+}
+
+// SaveInterestPayout records the payout, ErrAlreadyPaid is returned if interest of the period
+// is already paid to the account, so the db transaction paying it again must be rolled back.
+func (s *Store) SaveInterestPayout(ctx context.Context, payout entity.InterestPayout) error {
+	return s.do(ctx, func(tx *tx) error {
+		if _, ok := s.accounts[payout.AccountID]; !ok {
+			return repository.ErrUserNotFound
+		}
+
+		if _, ok := s.byID[payout.TransactionID]; !ok {
+			return repository.ErrNotFound
+		}
+
+		period := payout.Period.UTC()
+		// periods are dates as in postgres
+		key := payoutKey{
+			accountID: payout.AccountID,
+			period:    time.Date(period.Year(), period.Month(), period.Day(), 0, 0, 0, 0, time.UTC),
+		}
+
+		if _, paid := s.payouts[key]; paid {
+			return repository.ErrAlreadyPaid
+		}
+
+		s.payouts[key] = payout
+
+		tx.onRollback(func() {
+			delete(s.payouts, key)
+		})
+
+		return nil
+	})
+}
@@ -0,0 +1,490 @@
+// Package memory is an in-memory implementation of the repository for tests and local demos.
+// It follows the postgres repository: the same errors, constraints and transaction semantics,
+// but nothing outlives the process.
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/metrics"
+	"github.com/aspirin100/finapi/internal/repository"
+	"github.com/aspirin100/finapi/internal/repository/migrations"
+)
+
+const transactionsLimit = 10
+
+var ErrTxDone = errors.New("db transaction is already committed or rolled back")
+
+// Store keeps all the data in memory. Db transactions are serialized: a transaction holds the store
+// from BeginTx until it is committed or rolled back, and operations outside of transactions wait for it.
+// So an operation outside of the transaction must not be called by the goroutine running one.
+type Store struct {
+	// sem is held by the running db transaction or a single operation
+	sem chan struct{}
+	// mu guards the data against concurrent operations in the same db transaction
+	mu sync.Mutex
+
+	accounts     map[uuid.UUID]entity.Account
+	transactions []entity.Transaction
+	byID         map[uuid.UUID]int
+	reviews      map[uuid.UUID]entity.Review
+	blocked      map[uuid.UUID]entity.BlockedParty
+	decisions    []entity.ScreeningDecision
+	auditLog     []entity.AuditEntry
+	payouts      map[payoutKey]entity.InterestPayout
+	lastNow      time.Time
+}
+
+type payoutKey struct {
+	accountID uuid.UUID
+	period    time.Time
+}
+
+func New() *Store {
+	return &Store{
+		sem:      make(chan struct{}, 1),
+		accounts: make(map[uuid.UUID]entity.Account),
+		byID:     make(map[uuid.UUID]int),
+		reviews:  make(map[uuid.UUID]entity.Review),
+		blocked:  make(map[uuid.UUID]entity.BlockedParty),
+		payouts:  make(map[payoutKey]entity.InterestPayout),
+	}
+}
+
+type ctxKey struct{}
+
+var txContextKey = ctxKey{}
+
+// tx is a db transaction, undo reverts its changes in reverse order on rollback.
+// now is the time of its start, the same for all rows it saves, like in postgres.
+type tx struct {
+	store *Store
+	now   time.Time
+	undo  []func()
+	done  bool
+}
+
+func (t *tx) onRollback(fn func()) {
+	t.undo = append(t.undo, fn)
+}
+
+func (t *tx) rollback() {
+	for i := len(t.undo) - 1; i >= 0; i-- {
+		t.undo[i]()
+	}
+
+	t.undo = nil
+}
+
+// Ping always succeeds, the store is in the process.
+func (s *Store) Ping(_ context.Context) error {
+	return nil
+}
+
+// SchemaVersion returns the version of the embedded migrations, the store has no schema to migrate.
+func (s *Store) SchemaVersion(_ context.Context) (int64, error) {
+	return migrations.Version() //nolint:wrapcheck
+}
+
+// WaitTx blocks until the db transaction in progress is committed or rolled back.
+func (s *Store) WaitTx(ctx context.Context) error {
+	err := s.acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.release()
+
+	return nil
+}
+
+// Close does nothing, it is there to be closed like the postgres repository.
+func (s *Store) Close() {}
+
+func (s *Store) BeginTx(ctx context.Context) (context.Context, repository.CommitOrRollback, error) {
+	err := s.acquire(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	tx := &tx{store: s, now: s.now()}
+
+	return context.WithValue(ctx, txContextKey, tx), func(err error) error {
+		if tx.done {
+			return ErrTxDone
+		}
+
+		tx.done = true
+		defer s.release()
+
+		if err != nil {
+			metrics.TxRollbacks.Inc()
+
+			s.mu.Lock()
+			tx.rollback()
+			s.mu.Unlock()
+
+			return err
+		}
+
+		return nil
+	}, nil
+}
+
+// do runs fn in the db transaction of ctx, or holding the store if there is none.
+// Changes of a failed fn are reverted outside of a transaction, so a single operation is atomic.
+func (s *Store) do(ctx context.Context, fn func(tx *tx) error) error {
+	if tx, ok := ctx.Value(txContextKey).(*tx); ok && tx.store == s {
+		if tx.done {
+			return ErrTxDone
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		return fn(tx)
+	}
+
+	err := s.acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer s.release()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	single := &tx{store: s, now: s.now()}
+
+	err = fn(single)
+	if err != nil {
+		single.rollback()
+	}
+
+	return err
+}
+
+func (s *Store) acquire(ctx context.Context) error {
+	select {
+	case s.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err() //nolint:wrapcheck
+	}
+}
+
+func (s *Store) release() {
+	<-s.sem
+}
+
+// now is rounded to microseconds as timestamps in postgres. It is called holding the store
+// and always grows, so rows saved one after another are never saved at the same time.
+func (s *Store) now() time.Time {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	if !now.After(s.lastNow) {
+		now = s.lastNow.Add(time.Microsecond)
+	}
+
+	s.lastNow = now
+
+	return now
+}
+
+func (s *Store) UpdateBalance(ctx context.Context,
+	userID uuid.UUID,
+	amount decimal.Decimal) (*decimal.Decimal, error) {
+	var balance decimal.Decimal
+
+	err := s.do(ctx, func(tx *tx) error {
+		account, ok := s.accounts[userID]
+		if !ok {
+			return repository.ErrUserNotFound
+		}
+
+		balance = account.Balance.Add(amount)
+		if balance.IsNegative() {
+			return repository.ErrNegativeBalance
+		}
+
+		s.setAccount(tx, account.ID, func(account *entity.Account) {
+			account.Balance = balance
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &balance, nil
+}
+
+// setAccount updates the account and reverts the update on rollback.
+func (s *Store) setAccount(tx *tx, userID uuid.UUID, update func(account *entity.Account)) {
+	before := s.accounts[userID]
+	after := before
+
+	update(&after)
+	s.accounts[userID] = after
+
+	tx.onRollback(func() {
+		s.accounts[userID] = before
+	})
+}
+
+func (s *Store) SaveTransaction(ctx context.Context,
+	receiverID, senderID uuid.UUID,
+	amount decimal.Decimal,
+	operation string) (*entity.Transaction, error) {
+	return s.saveTransaction(ctx, receiverID, senderID, amount, operation, nil)
+}
+
+// SaveLinkedTransaction saves the transaction linked to the parent one, e.g. the fee of a transfer.
+func (s *Store) SaveLinkedTransaction(ctx context.Context,
+	parentID, receiverID, senderID uuid.UUID,
+	amount decimal.Decimal,
+	operation string) (*entity.Transaction, error) {
+	return s.saveTransaction(ctx, receiverID, senderID, amount, operation, &parentID)
+}
+
+func (s *Store) saveTransaction(ctx context.Context,
+	receiverID, senderID uuid.UUID,
+	amount decimal.Decimal,
+	operation string,
+	parentID *uuid.UUID) (*entity.Transaction, error) {
+	var transaction entity.Transaction
+
+	err := s.do(ctx, func(tx *tx) error {
+		_, receiverExists := s.accounts[receiverID]
+		_, senderExists := s.accounts[senderID]
+
+		if !receiverExists || !senderExists {
+			return repository.ErrUserNotFound
+		}
+
+		if parentID != nil {
+			if _, ok := s.byID[*parentID]; !ok {
+				return fmt.Errorf("failed to save transaction: unknown parent %s", parentID)
+			}
+		}
+
+		transaction = entity.Transaction{
+			ID:         uuid.New(),
+			SenderID:   senderID,
+			ReceiverID: receiverID,
+			Amount:     amount,
+			Operation:  operation,
+			ParentID:   parentID,
+			CreatedAt:  tx.now,
+		}
+
+		s.byID[transaction.ID] = len(s.transactions)
+		s.transactions = append(s.transactions, transaction)
+
+		tx.onRollback(func() {
+			delete(s.byID, transaction.ID)
+			s.transactions = s.transactions[:len(s.transactions)-1]
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &transaction, nil
+}
+
+// CreateAccount opens the account with the id, ErrAccountExists is returned if the id is taken.
+func (s *Store) CreateAccount(ctx context.Context, id uuid.UUID, accountType string) (*entity.Account, error) {
+	if !entity.ValidAccountType(accountType) {
+		return nil, fmt.Errorf("create account query fail: unknown account type %q", accountType)
+	}
+
+	account := entity.Account{
+		ID:      id,
+		Balance: decimal.Zero,
+		Tier:    entity.TierStandard,
+		Type:    accountType,
+	}
+
+	err := s.do(ctx, func(tx *tx) error {
+		if _, ok := s.accounts[account.ID]; ok {
+			return repository.ErrAccountExists
+		}
+
+		s.accounts[account.ID] = account
+
+		tx.onRollback(func() {
+			delete(s.accounts, account.ID)
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &account, nil
+}
+
+// UpdateAccountTier moves the account to the tier, ErrUserNotFound is returned if it doesn't exist.
+func (s *Store) UpdateAccountTier(ctx context.Context, userID uuid.UUID, tier string) (*entity.Account, error) {
+	var account entity.Account
+
+	err := s.do(ctx, func(tx *tx) error {
+		before, ok := s.accounts[userID]
+		if !ok {
+			return repository.ErrUserNotFound
+		}
+
+		account = before
+		account.Tier = tier
+		s.accounts[userID] = account
+
+		tx.onRollback(func() {
+			s.accounts[userID] = before
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &account, nil
+}
+
+func (s *Store) GetAccount(ctx context.Context, userID uuid.UUID) (*entity.Account, error) {
+	var account entity.Account
+
+	err := s.do(ctx, func(_ *tx) error {
+		var ok bool
+
+		account, ok = s.accounts[userID]
+		if !ok {
+			return repository.ErrUserNotFound
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &account, nil
+}
+
+func (s *Store) GetTransaction(ctx context.Context, transactionID uuid.UUID) (*entity.Transaction, error) {
+	var transaction entity.Transaction
+
+	err := s.do(ctx, func(_ *tx) error {
+		i, ok := s.byID[transactionID]
+		if !ok {
+			return repository.ErrNotFound
+		}
+
+		transaction = s.transactions[i]
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &transaction, nil
+}
+
+// GetTransactions returns the oldest 10 transactions of the user.
+func (s *Store) GetTransactions(ctx context.Context, userID uuid.UUID) ([]entity.Transaction, error) {
+	var transactions []entity.Transaction
+
+	err := s.do(ctx, func(_ *tx) error {
+		transactions = s.userTransactions(userID, func(entity.Transaction) bool { return true }, transactionsLimit)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+// GetTransactionsAfter returns a page of up to TransactionsAfterLimit transactions of the user committed
+// after the transaction with afterID, oldest first. The page starts after the transaction with pageAfterID,
+// or at the beginning if it is uuid.Nil. It returns no transactions if afterID is unknown.
+func (s *Store) GetTransactionsAfter(ctx context.Context,
+	userID, afterID, pageAfterID uuid.UUID) ([]entity.Transaction, error) {
+	var transactions []entity.Transaction
+
+	err := s.do(ctx, func(_ *tx) error {
+		i, ok := s.byID[afterID]
+		if !ok {
+			transactions = []entity.Transaction{}
+
+			return nil
+		}
+
+		after := s.transactions[i]
+
+		if j, ok := s.byID[pageAfterID]; ok && compareTransactions(s.transactions[j], after) > 0 {
+			after = s.transactions[j]
+		}
+
+		transactions = s.userTransactions(userID, func(transaction entity.Transaction) bool {
+			return compareTransactions(transaction, after) > 0
+		}, repository.TransactionsAfterLimit)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+// userTransactions returns up to limit matching transactions of the user ordered by (createdAt, id).
+func (s *Store) userTransactions(userID uuid.UUID,
+	match func(transaction entity.Transaction) bool,
+	limit int) []entity.Transaction {
+	transactions := []entity.Transaction{}
+
+	for _, transaction := range s.transactions {
+		if (transaction.ReceiverID == userID || transaction.SenderID == userID) && match(transaction) {
+			transactions = append(transactions, transaction)
+		}
+	}
+
+	slices.SortStableFunc(transactions, compareTransactions)
+
+	if len(transactions) > limit {
+		transactions = transactions[:limit]
+	}
+
+	return transactions
+}
+
+func compareTransactions(a, b entity.Transaction) int {
+	if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+		return c
+	}
+
+	return compareIDs(a.ID, b.ID)
+}
+
+// compareIDs orders uuids as postgres does.
+func compareIDs(a, b uuid.UUID) int {
+	return slices.Compare(a[:], b[:])
+}
@@ -0,0 +1,44 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/repository/memory"
+	"github.com/aspirin100/finapi/internal/repository/repotest"
+)
+
+func TestConformance(t *testing.T) {
+	repotest.Run(t, memory.New())
+}
+
+func TestTxSerialized(t *testing.T) {
+	ctx := context.Background()
+
+	store := memory.New()
+
+	txCtx, commitOrRollback, err := store.BeginTx(ctx)
+	require.NoError(t, err)
+
+	waitCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+
+	_, err = store.CreateAccount(waitCtx, uuid.New(), entity.AccountChecking)
+	require.ErrorIs(t, err, context.DeadlineExceeded, "operations wait for the transaction in progress")
+
+	_, err = store.CreateAccount(txCtx, uuid.New(), entity.AccountChecking)
+	require.NoError(t, err)
+
+	require.NoError(t, commitOrRollback(nil))
+	require.ErrorIs(t, commitOrRollback(nil), memory.ErrTxDone)
+
+	_, err = store.CreateAccount(txCtx, uuid.New(), entity.AccountChecking)
+	require.ErrorIs(t, err, memory.ErrTxDone, "finished transaction can't be used")
+
+	require.NoError(t, store.WaitTx(ctx))
+}
@@ -0,0 +1,173 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/repository"
+	"github.com/aspirin100/finapi/internal/risk"
+)
+
+const reviewsLimit = 100
+
+// TransferStats summarizes transfers of the sender made since the given time,
+// only to the receiver unless receiverID is uuid.Nil.
+func (s *Store) TransferStats(ctx context.Context,
+	senderID, receiverID uuid.UUID,
+	since time.Time) (risk.Stats, error) {
+	stats := risk.Stats{Sum: decimal.Zero}
+
+	err := s.do(ctx, func(_ *tx) error {
+		for _, transaction := range s.transactions {
+			if transaction.SenderID != senderID ||
+				transaction.Operation != "transfer" ||
+				(receiverID != uuid.Nil && transaction.ReceiverID != receiverID) ||
+				transaction.CreatedAt.Before(since) {
+				continue
+			}
+
+			stats.Count++
+			stats.Sum = stats.Sum.Add(transaction.Amount)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return risk.Stats{}, err
+	}
+
+	return stats, nil
+}
+
+func (s *Store) CreateReview(ctx context.Context,
+	receiverID, senderID uuid.UUID,
+	amount decimal.Decimal,
+	rule, reason string) (*entity.Review, error) {
+	var review entity.Review
+
+	err := s.do(ctx, func(tx *tx) error {
+		_, receiverExists := s.accounts[receiverID]
+		_, senderExists := s.accounts[senderID]
+
+		if !receiverExists || !senderExists {
+			return repository.ErrUserNotFound
+		}
+
+		review = entity.Review{
+			ID:         uuid.New(),
+			SenderID:   senderID,
+			ReceiverID: receiverID,
+			Amount:     amount,
+			Rule:       rule,
+			Reason:     reason,
+			Status:     entity.ReviewPending,
+			CreatedAt:  tx.now,
+		}
+
+		s.reviews[review.ID] = review
+
+		tx.onRollback(func() {
+			delete(s.reviews, review.ID)
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &review, nil
+}
+
+// GetReview returns the review, the review is locked by the transaction as the whole store.
+func (s *Store) GetReview(ctx context.Context, reviewID uuid.UUID) (*entity.Review, error) {
+	var review entity.Review
+
+	err := s.do(ctx, func(_ *tx) error {
+		var ok bool
+
+		review, ok = s.reviews[reviewID]
+		if !ok {
+			return repository.ErrNotFound
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &review, nil
+}
+
+// GetReviews returns the oldest 100 reviews with the status.
+func (s *Store) GetReviews(ctx context.Context, status string) ([]entity.Review, error) {
+	reviews := []entity.Review{}
+
+	err := s.do(ctx, func(_ *tx) error {
+		for _, review := range s.reviews {
+			if review.Status == status {
+				reviews = append(reviews, review)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(reviews, func(a, b entity.Review) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+
+		return compareIDs(a.ID, b.ID)
+	})
+
+	if len(reviews) > reviewsLimit {
+		reviews = reviews[:reviewsLimit]
+	}
+
+	return reviews, nil
+}
+
+// DecideReview sets the status of a pending review, transactionID is the executed transfer of approved one.
+func (s *Store) DecideReview(ctx context.Context,
+	reviewID uuid.UUID,
+	status string,
+	transactionID *uuid.UUID) (*entity.Review, error) {
+	var review entity.Review
+
+	err := s.do(ctx, func(tx *tx) error {
+		before, ok := s.reviews[reviewID]
+		if !ok || before.Status != entity.ReviewPending {
+			return repository.ErrNotFound
+		}
+
+		decidedAt := tx.now
+
+		review = before
+		review.Status = status
+		review.TransactionID = transactionID
+		review.DecidedAt = &decidedAt
+
+		s.reviews[reviewID] = review
+
+		tx.onRollback(func() {
+			s.reviews[reviewID] = before
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &review, nil
+}
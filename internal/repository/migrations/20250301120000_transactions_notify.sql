@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION notify_transaction() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('finapi_transactions', json_build_object(
+        'id', NEW.id,
+        'senderID', NEW.senderID,
+        'receiverID', NEW.receiverID,
+        'amount', NEW.amount::TEXT,
+        'operation', NEW.operation,
+        'createdAt', NEW.createdAt
+    )::TEXT);
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER transactions_notify
+    AFTER INSERT ON transactions
+    FOR EACH ROW EXECUTE FUNCTION notify_transaction();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS transactions_notify ON transactions;
+DROP FUNCTION IF EXISTS notify_transaction();
+-- +goose StatementEnd
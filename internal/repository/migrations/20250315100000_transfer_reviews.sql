@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS transfer_reviews (
+    id UUID PRIMARY KEY,
+    senderID UUID NOT NULL REFERENCES bank_accounts(userID),
+    receiverID UUID NOT NULL REFERENCES bank_accounts(userID),
+    amount DECIMAL NOT NULL,
+    rule TEXT NOT NULL,
+    reason TEXT NOT NULL,
+    status VARCHAR(8) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'approved', 'rejected')),
+    transactionID UUID REFERENCES transactions(id),
+    createdAt TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    decidedAt TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS transfer_reviews_status_index ON transfer_reviews (status, createdAt);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS transfer_reviews;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS blocked_parties (
+    id UUID PRIMARY KEY,
+    reason TEXT NOT NULL DEFAULT '',
+    createdAt TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS screening_decisions (
+    id UUID PRIMARY KEY,
+    operation VARCHAR(16) NOT NULL,
+    partyID UUID NOT NULL,
+    blocked BOOLEAN NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    requestID TEXT NOT NULL DEFAULT '',
+    createdAt TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS screening_decisions_party_index ON screening_decisions (partyID, createdAt);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS screening_decisions;
+DROP TABLE IF EXISTS blocked_parties;
+-- +goose StatementEnd
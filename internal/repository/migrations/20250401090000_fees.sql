@@ -0,0 +1,46 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE bank_accounts ADD COLUMN IF NOT EXISTS tier TEXT NOT NULL DEFAULT 'standard';
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS parentID UUID REFERENCES transactions(id);
+
+CREATE INDEX IF NOT EXISTS transactions_parentid_index ON transactions (parentID) WHERE parentID IS NOT NULL;
+
+CREATE OR REPLACE FUNCTION notify_transaction() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('finapi_transactions', json_build_object(
+        'id', NEW.id,
+        'senderID', NEW.senderID,
+        'receiverID', NEW.receiverID,
+        'amount', NEW.amount::TEXT,
+        'operation', NEW.operation,
+        'parentID', NEW.parentID,
+        'createdAt', NEW.createdAt
+    )::TEXT);
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION notify_transaction() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('finapi_transactions', json_build_object(
+        'id', NEW.id,
+        'senderID', NEW.senderID,
+        'receiverID', NEW.receiverID,
+        'amount', NEW.amount::TEXT,
+        'operation', NEW.operation,
+        'createdAt', NEW.createdAt
+    )::TEXT);
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP INDEX IF EXISTS transactions_parentid_index;
+ALTER TABLE transactions DROP COLUMN IF EXISTS parentID;
+ALTER TABLE bank_accounts DROP COLUMN IF EXISTS tier;
+-- +goose StatementEnd
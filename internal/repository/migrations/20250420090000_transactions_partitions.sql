@@ -0,0 +1,132 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the primary key of a partitioned table must include the partition key, so foreign keys
+-- can't reference transactions(id) anymore. Archived transactions are removed from the table as well.
+ALTER TABLE transfer_reviews DROP CONSTRAINT IF EXISTS transfer_reviews_transactionid_fkey;
+ALTER TABLE interest_payouts DROP CONSTRAINT IF EXISTS interest_payouts_transactionid_fkey;
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_parentid_fkey;
+
+ALTER TABLE transactions RENAME TO transactions_unpartitioned;
+ALTER INDEX transactions_pkey RENAME TO transactions_unpartitioned_pkey;
+DROP INDEX IF EXISTS senderid_index;
+DROP INDEX IF EXISTS receiverid_index;
+DROP INDEX IF EXISTS transactions_parentid_index;
+DROP INDEX IF EXISTS transactions_createdat_index;
+
+CREATE TABLE transactions (
+    id UUID NOT NULL,
+    receiverID UUID NOT NULL,
+    senderID UUID NOT NULL,
+    operation VARCHAR(8) NOT NULL,
+    amount DECIMAL NOT NULL,
+    createdAt TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    parentID UUID,
+    PRIMARY KEY (id, createdAt),
+    CONSTRAINT fk_transactions_receiver_id FOREIGN KEY (receiverID) REFERENCES bank_accounts(userID),
+    CONSTRAINT fk_transactions_sender_id FOREIGN KEY (senderID) REFERENCES bank_accounts(userID)
+) PARTITION BY RANGE (createdAt);
+
+CREATE INDEX transactions_sender_createdat_index ON transactions (senderID, createdAt);
+CREATE INDEX transactions_receiver_createdat_index ON transactions (receiverID, createdAt);
+CREATE INDEX transactions_parentid_index ON transactions (parentID) WHERE parentID IS NOT NULL;
+
+-- monthly partitions from the oldest transaction up to two months ahead,
+-- later ones are created by cmd/partitions
+DO $$
+DECLARE
+    month TIMESTAMP;
+BEGIN
+    -- bounds are computed in UTC, the session time zone would shift them on daylight saving changes
+    SELECT date_trunc('month', coalesce(min(createdAt), NOW()) AT TIME ZONE 'UTC') INTO month
+    FROM transactions_unpartitioned;
+
+    WHILE month <= date_trunc('month', NOW() AT TIME ZONE 'UTC') + interval '2 months' LOOP
+        EXECUTE format('CREATE TABLE %I PARTITION OF transactions FOR VALUES FROM (%L) TO (%L)',
+            to_char(month, '"transactions_y"YYYY"m"MM'),
+            month AT TIME ZONE 'UTC',
+            (month + interval '1 month') AT TIME ZONE 'UTC');
+
+        month := month + interval '1 month';
+    END LOOP;
+END;
+$$;
+
+-- keeps inserts working if partitions aren't created in time
+CREATE TABLE transactions_default PARTITION OF transactions DEFAULT;
+
+INSERT INTO transactions (id, receiverID, senderID, operation, amount, createdAt, parentID)
+SELECT id, receiverID, senderID, operation, amount, createdAt, parentID
+FROM transactions_unpartitioned;
+
+DROP TABLE transactions_unpartitioned;
+
+CREATE TRIGGER transactions_notify
+    AFTER INSERT ON transactions
+    FOR EACH ROW EXECUTE FUNCTION notify_transaction();
+
+CREATE TABLE IF NOT EXISTS transaction_archives (
+    partition TEXT PRIMARY KEY,
+    rangeStart TIMESTAMPTZ NOT NULL,
+    rangeEnd TIMESTAMPTZ NOT NULL,
+    file TEXT NOT NULL,
+    rowsCount BIGINT NOT NULL,
+    archivedAt TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- archived transactions aren't restored, foreign keys to them are added NOT VALID
+DROP TABLE IF EXISTS transaction_archives;
+
+ALTER TABLE transactions RENAME TO transactions_partitioned;
+ALTER INDEX transactions_pkey RENAME TO transactions_partitioned_pkey;
+ALTER INDEX transactions_sender_createdat_index RENAME TO transactions_partitioned_sender_createdat_index;
+ALTER INDEX transactions_receiver_createdat_index RENAME TO transactions_partitioned_receiver_createdat_index;
+DROP INDEX IF EXISTS transactions_parentid_index;
+
+CREATE TABLE transactions (
+    id UUID PRIMARY KEY,
+    receiverID UUID NOT NULL,
+    senderID UUID NOT NULL,
+    operation VARCHAR(8) NOT NULL,
+    amount DECIMAL NOT NULL,
+    createdAt TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    parentID UUID
+);
+
+INSERT INTO transactions (id, receiverID, senderID, operation, amount, createdAt, parentID)
+SELECT id, receiverID, senderID, operation, amount, createdAt, parentID
+FROM transactions_partitioned;
+
+DROP TABLE transactions_partitioned;
+
+ALTER TABLE transactions
+    ADD CONSTRAINT fk_receiver_id
+    FOREIGN KEY (receiverID) REFERENCES bank_accounts(userID);
+
+ALTER TABLE transactions
+    ADD CONSTRAINT fk_sender_id
+    FOREIGN KEY (senderID) REFERENCES bank_accounts(userID);
+
+ALTER TABLE transactions
+    ADD CONSTRAINT transactions_parentid_fkey
+    FOREIGN KEY (parentID) REFERENCES transactions(id) NOT VALID;
+
+ALTER TABLE transfer_reviews
+    ADD CONSTRAINT transfer_reviews_transactionid_fkey
+    FOREIGN KEY (transactionID) REFERENCES transactions(id) NOT VALID;
+
+ALTER TABLE interest_payouts
+    ADD CONSTRAINT interest_payouts_transactionid_fkey
+    FOREIGN KEY (transactionID) REFERENCES transactions(id) NOT VALID;
+
+CREATE INDEX IF NOT EXISTS senderid_index ON transactions USING HASH (senderID);
+CREATE INDEX IF NOT EXISTS receiverid_index ON transactions USING HASH (receiverID);
+CREATE INDEX IF NOT EXISTS transactions_parentid_index ON transactions (parentID) WHERE parentID IS NOT NULL;
+CREATE INDEX IF NOT EXISTS transactions_createdat_index ON transactions (createdAt);
+
+CREATE TRIGGER transactions_notify
+    AFTER INSERT ON transactions
+    FOR EACH ROW EXECUTE FUNCTION notify_transaction();
+-- +goose StatementEnd
@@ -0,0 +1,51 @@
+-- +goose Up
+-- +goose StatementBegin
+-- ids of all transactions, archived ones included. Foreign keys can't reference partitioned
+-- transactions(id) and its primary key (id, createdAt) doesn't keep ids unique across partitions,
+-- so ids are recorded here on insert and references point to this table.
+CREATE TABLE IF NOT EXISTS transaction_ids (
+    id UUID PRIMARY KEY
+);
+
+-- archived transactions are only known by the references to them
+INSERT INTO transaction_ids (id)
+SELECT id FROM transactions
+UNION SELECT parentID FROM transactions WHERE parentID IS NOT NULL
+UNION SELECT transactionID FROM transfer_reviews WHERE transactionID IS NOT NULL
+UNION SELECT transactionID FROM interest_payouts;
+
+CREATE OR REPLACE FUNCTION record_transaction_id() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO transaction_ids (id) VALUES (NEW.id);
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER transactions_record_id
+    BEFORE INSERT ON transactions
+    FOR EACH ROW EXECUTE FUNCTION record_transaction_id();
+
+ALTER TABLE transactions
+    ADD CONSTRAINT transactions_parentid_fkey
+    FOREIGN KEY (parentID) REFERENCES transaction_ids(id);
+
+ALTER TABLE transfer_reviews
+    ADD CONSTRAINT transfer_reviews_transactionid_fkey
+    FOREIGN KEY (transactionID) REFERENCES transaction_ids(id);
+
+ALTER TABLE interest_payouts
+    ADD CONSTRAINT interest_payouts_transactionid_fkey
+    FOREIGN KEY (transactionID) REFERENCES transaction_ids(id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE interest_payouts DROP CONSTRAINT IF EXISTS interest_payouts_transactionid_fkey;
+ALTER TABLE transfer_reviews DROP CONSTRAINT IF EXISTS transfer_reviews_transactionid_fkey;
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_parentid_fkey;
+
+DROP TRIGGER IF EXISTS transactions_record_id ON transactions;
+DROP FUNCTION IF EXISTS record_transaction_id();
+DROP TABLE IF EXISTS transaction_ids;
+-- +goose StatementEnd
@@ -2,7 +2,33 @@ package migrations
 
 import (
 	"embed"
+	"fmt"
+	"io/fs"
+
+	"github.com/pressly/goose/v3"
 )
 
 //go:embed *.sql
-var Migrations embed.FS
\ No newline at end of file
+var Migrations embed.FS
+
+// Version returns the version of the latest embedded migration,
+// which is the schema version expected by the binary.
+func Version() (int64, error) {
+	names, err := fs.Glob(Migrations, "*.sql")
+	if err != nil {
+		return 0, fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	var latest int64
+
+	for _, name := range names {
+		version, err := goose.NumericComponent(name)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse migration %s version: %w", name, err)
+		}
+
+		latest = max(latest, version)
+	}
+
+	return latest, nil
+}
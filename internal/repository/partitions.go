@@ -0,0 +1,293 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/aspirin100/finapi/internal/entity"
+)
+
+// partitionNameLayout names the monthly partitions of transactions, e.g. transactions_y2025m05.
+const partitionNameLayout = "transactions_y2006m01"
+
+var ErrPartitionExists = errors.New("partition already exists")
+
+// Partition is a monthly partition of transactions holding the [Start, End) range of createdAt.
+type Partition struct {
+	Name  string
+	Start time.Time
+	End   time.Time
+}
+
+// Archive is a partition moved out of postgres to a compressed NDJSON file.
+type Archive struct {
+	Partition
+	File       string
+	Rows       int64
+	ArchivedAt time.Time
+}
+
+// MonthPartition returns the partition holding transactions created in the month of t in UTC.
+func MonthPartition(t time.Time) Partition {
+	t = t.UTC()
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	return Partition{
+		Name:  start.Format(partitionNameLayout),
+		Start: start,
+		End:   start.AddDate(0, 1, 0),
+	}
+}
+
+// AddMonths returns the partition months after p, before it if months is negative.
+// It is counted from the first day of the month, so month-end dates don't overflow
+// into the next month the way time.Time.AddDate does.
+func (p Partition) AddMonths(months int) Partition {
+	return MonthPartition(p.Start.AddDate(0, months, 0))
+}
+
+// parsePartition returns the partition by its name, false for the default partition.
+func parsePartition(name string) (Partition, bool) {
+	start, err := time.Parse(partitionNameLayout, name)
+	if err != nil {
+		return Partition{}, false
+	}
+
+	return MonthPartition(start), true
+}
+
+// Partitions returns the monthly partitions of transactions, oldest first.
+func (r *Repository) Partitions(ctx context.Context) ([]Partition, error) {
+	rows, err := r.DB.Query(ctx, GetPartitionsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get partitions: %w", err)
+	}
+
+	names, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read partitions: %w", err)
+	}
+
+	partitions := make([]Partition, 0, len(names))
+
+	for _, name := range names {
+		partition, ok := parsePartition(name)
+		if ok {
+			partitions = append(partitions, partition)
+		}
+	}
+
+	slices.SortFunc(partitions, func(a, b Partition) int {
+		return a.Start.Compare(b.Start)
+	})
+
+	return partitions, nil
+}
+
+// CreatePartition creates the partition, transactions of its range which went to the default
+// partition meanwhile are moved to it. ErrPartitionExists is returned if it is created already.
+func (r *Repository) CreatePartition(ctx context.Context, partition Partition) error {
+	partitions, err := r.Partitions(ctx)
+	if err != nil {
+		return err
+	}
+
+	if slices.ContainsFunc(partitions, func(p Partition) bool { return p.Name == partition.Name }) {
+		return ErrPartitionExists
+	}
+
+	table := pgx.Identifier{partition.Name}.Sanitize()
+
+	// postgres refuses to create a partition if the default one has rows of its range,
+	// so the partition is filled first and attached after
+	err = pgx.BeginFunc(ctx, r.DB, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, fmt.Sprintf(CreatePartitionTableQuery, table))
+		if err != nil {
+			return fmt.Errorf("failed to create table: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, fmt.Sprintf(MoveFromDefaultPartitionQuery, table), partition.Start, partition.End)
+		if err != nil {
+			return fmt.Errorf("failed to move transactions from the default partition: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, fmt.Sprintf(AttachPartitionQuery, table,
+			partition.Start.Format(time.RFC3339), partition.End.Format(time.RFC3339)))
+		if err != nil {
+			return fmt.Errorf("failed to attach: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create partition %s: %w", partition.Name, err)
+	}
+
+	return nil
+}
+
+// ArchivePartition writes transactions of the partition to a compressed NDJSON file in dir,
+// then records the archive and drops the partition. Writes to the partition are blocked meanwhile.
+func (r *Repository) ArchivePartition(ctx context.Context, partition Partition, dir string) (*Archive, error) {
+	archive := &Archive{
+		Partition: partition,
+		File:      partition.Name + archiveExt,
+	}
+
+	table := pgx.Identifier{partition.Name}.Sanitize()
+
+	err := pgx.BeginFunc(ctx, r.DB, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, fmt.Sprintf(LockPartitionQuery, table))
+		if err != nil {
+			return fmt.Errorf("failed to lock: %w", err)
+		}
+
+		rows, err := tx.Query(ctx, fmt.Sprintf(GetPartitionTransactionsQuery, table))
+		if err != nil {
+			return fmt.Errorf("failed to read transactions: %w", err)
+		}
+		defer rows.Close()
+
+		archive.Rows, err = writeArchive(filepath.Join(dir, archive.File), func(transaction *entity.Transaction) (bool, error) {
+			if !rows.Next() {
+				return false, rows.Err()
+			}
+
+			return true, scanTransaction(rows, transaction)
+		})
+		if err != nil {
+			return err
+		}
+
+		err = tx.QueryRow(ctx, NewArchiveQuery,
+			partition.Name, partition.Start, partition.End, archive.File, archive.Rows).Scan(&archive.ArchivedAt)
+		if err != nil {
+			return fmt.Errorf("failed to record archive: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, fmt.Sprintf(DetachPartitionQuery, table))
+		if err != nil {
+			return fmt.Errorf("failed to detach: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, fmt.Sprintf(DropPartitionQuery, table))
+		if err != nil {
+			return fmt.Errorf("failed to drop: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to archive partition %s: %w", partition.Name, err)
+	}
+
+	return archive, nil
+}
+
+// Archives returns the archived partitions overlapping the [from, to) range, oldest first.
+func (r *Repository) Archives(ctx context.Context, from, to time.Time) ([]Archive, error) {
+	rows, err := r.DB.Query(ctx, GetArchivesQuery, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get archives: %w", err)
+	}
+
+	archives, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Archive, error) {
+		var archive Archive
+
+		err := row.Scan(&archive.Name, &archive.Start, &archive.End, &archive.File, &archive.Rows, &archive.ArchivedAt)
+
+		return archive, err //nolint:wrapcheck
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archives: %w", err)
+	}
+
+	return archives, nil
+}
+
+// GetTransactionsBetween returns transactions of the user created in the [from, to) range, oldest first.
+// Transactions of archived partitions are read from the archive files in archiveDir
+// if it isn't empty, otherwise they are left out.
+func (r *Repository) GetTransactionsBetween(ctx context.Context,
+	userID uuid.UUID,
+	from, to time.Time,
+	archiveDir string) ([]entity.Transaction, error) {
+	ex, replica := r.reader(ctx, userID)
+
+	transactions, err := r.queryTransactions(ctx, ex, replica, GetTransactionsBetweenQuery, userID, from, to)
+	if err != nil || archiveDir == "" {
+		return transactions, err
+	}
+
+	archives, err := r.Archives(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var archived []entity.Transaction
+
+	for _, archive := range archives {
+		found, err := readArchive(filepath.Join(archiveDir, archive.File), func(transaction entity.Transaction) bool {
+			return (transaction.SenderID == userID || transaction.ReceiverID == userID) &&
+				!transaction.CreatedAt.Before(from) && transaction.CreatedAt.Before(to)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		archived = append(archived, found...)
+	}
+
+	transactions = append(archived, transactions...)
+
+	slices.SortStableFunc(transactions, func(a, b entity.Transaction) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	return transactions, nil
+}
+
+const (
+	GetPartitionsQuery = `select c.relname
+	from pg_inherits i
+	join pg_class c on c.oid = i.inhrelid
+	where i.inhparent = 'transactions'::regclass`
+	CreatePartitionTableQuery     = `create table %s (like transactions including defaults including constraints)`
+	MoveFromDefaultPartitionQuery = `with moved as (
+		delete from transactions_default where createdAt >= $1 and createdAt < $2 returning *
+	)
+	insert into %s select * from moved`
+	AttachPartitionQuery          = `alter table transactions attach partition %s for values from ('%s') to ('%s')`
+	LockPartitionQuery            = `lock table %s in share mode`
+	GetPartitionTransactionsQuery = `select
+	id, receiverID, senderID, amount, operation, parentID, createdAt
+	from %s
+	order by createdAt, id`
+	NewArchiveQuery = `insert into transaction_archives(partition, rangeStart, rangeEnd, file, rowsCount)
+	values ($1, $2, $3, $4, $5)
+	returning archivedAt`
+	DetachPartitionQuery = `alter table transactions detach partition %s`
+	DropPartitionQuery   = `drop table %s`
+	GetArchivesQuery     = `select partition, rangeStart, rangeEnd, file, rowsCount, archivedAt
+	from transaction_archives
+	where rangeStart < $2 and rangeEnd > $1
+	order by rangeStart`
+	// the union lets each branch use its (userID, createdAt) index, deposits are in the first one only
+	GetTransactionsBetweenQuery = `select
+	id, receiverID, senderID, amount, operation, parentID, createdAt
+	from transactions
+	where receiverID = $1 and createdAt >= $2 and createdAt < $3
+	union all
+	select
+	id, receiverID, senderID, amount, operation, parentID, createdAt
+	from transactions
+	where senderID = $1 and receiverID <> $1 and createdAt >= $2 and createdAt < $3
+	order by createdAt, id`
+)
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aspirin100/finapi/internal/entity"
+)
+
+func TestMonthPartition(t *testing.T) {
+	cases := []struct {
+		Name              string
+		Time              time.Time
+		ExpectedPartition Partition
+	}{
+		{
+			Name: "middle of month",
+			Time: time.Date(2025, 5, 17, 13, 0, 0, 0, time.UTC),
+			ExpectedPartition: Partition{
+				Name:  "transactions_y2025m05",
+				Start: time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC),
+				End:   time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			Name: "month in utc",
+			Time: time.Date(2025, 1, 1, 0, 30, 0, 0, time.FixedZone("CET", 3600)),
+			ExpectedPartition: Partition{
+				Name:  "transactions_y2024m12",
+				Start: time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC),
+				End:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			partition := MonthPartition(tc.Time)
+			require.Equal(t, tc.ExpectedPartition, partition)
+
+			parsed, ok := parsePartition(partition.Name)
+			require.True(t, ok)
+			require.Equal(t, tc.ExpectedPartition, parsed)
+		})
+	}
+
+	_, ok := parsePartition("transactions_default")
+	require.False(t, ok)
+}
+
+func TestAddMonths(t *testing.T) {
+	cases := []struct {
+		Name         string
+		Time         time.Time
+		Months       int
+		ExpectedName string
+	}{
+		{
+			Name:         "next after month end",
+			Time:         time.Date(2025, 1, 31, 23, 0, 0, 0, time.UTC),
+			Months:       1,
+			ExpectedName: "transactions_y2025m02",
+		},
+		{
+			Name:         "previous after month end",
+			Time:         time.Date(2025, 3, 31, 12, 0, 0, 0, time.UTC),
+			Months:       -1,
+			ExpectedName: "transactions_y2025m02",
+		},
+		{
+			Name:         "kept months before month end",
+			Time:         time.Date(2025, 5, 31, 12, 0, 0, 0, time.UTC),
+			Months:       -3,
+			ExpectedName: "transactions_y2025m02",
+		},
+		{
+			Name:         "across years",
+			Time:         time.Date(2024, 12, 31, 12, 0, 0, 0, time.UTC),
+			Months:       2,
+			ExpectedName: "transactions_y2025m02",
+		},
+		{
+			Name:         "current",
+			Time:         time.Date(2025, 2, 28, 12, 0, 0, 0, time.UTC),
+			Months:       0,
+			ExpectedName: "transactions_y2025m02",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			require.Equal(t, tc.ExpectedName, MonthPartition(tc.Time).AddMonths(tc.Months).Name)
+		})
+	}
+}
+
+func TestArchive(t *testing.T) {
+	userID := uuid.New()
+	createdAt := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	transactions := []entity.Transaction{
+		{
+			ID:         uuid.New(),
+			SenderID:   userID,
+			ReceiverID: userID,
+			Amount:     decimal.RequireFromString("100.000000000000000001"),
+			Operation:  "deposit",
+			CreatedAt:  createdAt,
+		},
+		{
+			ID:         uuid.New(),
+			SenderID:   uuid.New(),
+			ReceiverID: uuid.New(),
+			Amount:     decimal.RequireFromString("5"),
+			Operation:  "transfer",
+			CreatedAt:  createdAt.Add(time.Hour),
+		},
+	}
+
+	path := filepath.Join(t.TempDir(), "transactions_y2025m05"+archiveExt)
+
+	next := 0
+
+	count, err := writeArchive(path, func(transaction *entity.Transaction) (bool, error) {
+		if next == len(transactions) {
+			return false, nil
+		}
+
+		*transaction = transactions[next]
+		next++
+
+		return true, nil
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, len(transactions), count)
+
+	found, err := readArchive(path, func(transaction entity.Transaction) bool {
+		return transaction.SenderID == userID
+	})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	require.Equal(t, transactions[0].ID, found[0].ID)
+	require.True(t, transactions[0].Amount.Equal(found[0].Amount))
+	require.True(t, transactions[0].CreatedAt.Equal(found[0].CreatedAt))
+
+	entries, err := filepath.Glob(filepath.Join(filepath.Dir(path), "*"))
+	require.NoError(t, err)
+	require.Equal(t, []string{path}, entries, "temporary file is removed")
+}
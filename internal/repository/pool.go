@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/aspirin100/finapi/internal/breaker"
+	"github.com/aspirin100/finapi/internal/logger"
+)
+
+// maxConnectBackoff caps the doubling wait between connection attempts on start.
+const maxConnectBackoff = 30 * time.Second
+
+// PoolConfig tunes the connection pools of the primary and replicas, zero fields keep the pgx defaults.
+type PoolConfig struct {
+	MaxConns          int32
+	MinConns          int32
+	MaxConnLifetime   time.Duration
+	MaxConnIdleTime   time.Duration
+	HealthCheckPeriod time.Duration
+	// StatementTimeout makes postgres cancel statements running longer.
+	StatementTimeout time.Duration
+}
+
+// WithPool tunes the connection pools.
+func WithPool(cfg PoolConfig) Option {
+	return func(r *Repository) {
+		r.poolConfig = cfg
+	}
+}
+
+// WithConnectRetry retries to reach the primary on start the given number of times,
+// the wait between attempts starts with backoff and doubles.
+func WithConnectRetry(retries int, backoff time.Duration) Option {
+	return func(r *Repository) {
+		r.connectRetries = retries
+		r.connectBackoff = backoff
+	}
+}
+
+// WithBreaker reports every round trip to the primary to the circuit breaker,
+// replicas have their own failover.
+func WithBreaker(b *breaker.Breaker) Option {
+	return func(r *Repository) {
+		r.breaker = b
+	}
+}
+
+// connect creates the pool, round trips are reported to the breaker unless it is nil.
+func (r *Repository) connect(ctx context.Context, postgresDSN string, b *breaker.Breaker) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(postgresDSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse postgres dsn: %w", err)
+	}
+
+	if r.poolConfig.MaxConns > 0 {
+		poolConfig.MaxConns = r.poolConfig.MaxConns
+	}
+
+	if r.poolConfig.MinConns > 0 {
+		poolConfig.MinConns = r.poolConfig.MinConns
+	}
+
+	if r.poolConfig.MaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = r.poolConfig.MaxConnLifetime
+	}
+
+	if r.poolConfig.MaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = r.poolConfig.MaxConnIdleTime
+	}
+
+	if r.poolConfig.HealthCheckPeriod > 0 {
+		poolConfig.HealthCheckPeriod = r.poolConfig.HealthCheckPeriod
+	}
+
+	if r.poolConfig.StatementTimeout > 0 {
+		poolConfig.ConnConfig.RuntimeParams["statement_timeout"] =
+			strconv.FormatInt(r.poolConfig.StatementTimeout.Milliseconds(), 10)
+	}
+
+	poolConfig.ConnConfig.Tracer = queryTracer{breaker: b}
+	poolConfig.BeforeConnect = func(_ context.Context, connConfig *pgx.ConnConfig) error {
+		r.setPassword(connConfig)
+
+		return nil
+	}
+
+	conn, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to postgres: %w", err)
+	}
+
+	return conn, nil
+}
+
+// waitReachable pings the pool until postgres answers or the retries are over.
+func (r *Repository) waitReachable(ctx context.Context, pool *pgxpool.Pool) error {
+	backoff := r.connectBackoff
+
+	for attempt := 0; ; attempt++ {
+		err := pool.Ping(ctx)
+		if err == nil {
+			return nil
+		}
+
+		if attempt >= r.connectRetries {
+			return fmt.Errorf("failed to reach postgres: %w", err)
+		}
+
+		logger.FromContext(ctx).Warn("postgres is unreachable, retrying",
+			slog.Int("attempt", attempt+1),
+			slog.Duration("backoff", backoff),
+			slog.Any("error", err))
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed to reach postgres: %w", ctx.Err())
+		case <-time.After(backoff):
+		}
+
+		backoff = min(2*backoff, maxConnectBackoff)
+	}
+}
+
+// reportRoundTrip tells the breaker whether the database answered: any response, an error one too,
+// closes the breaker, while errors of the connection open it. Other errors, e.g. cancellation
+// of the request by the client, tell nothing.
+func reportRoundTrip(b *breaker.Breaker, err error) {
+	if b == nil {
+		return
+	}
+
+	var pgErr *pgconn.PgError
+
+	switch {
+	case err == nil, errors.As(err, &pgErr):
+		b.Success()
+	case unreachable(err):
+		b.Failure()
+	}
+}
+
+// unreachable reports whether err means postgres can't be reached or doesn't answer in time.
+func unreachable(err error) bool {
+	var (
+		connectErr *pgconn.ConnectError
+		netErr     net.Error
+	)
+
+	return errors.As(err, &connectErr) ||
+		errors.As(err, &netErr) ||
+		pgconn.Timeout(err) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
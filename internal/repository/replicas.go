@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/aspirin100/finapi/internal/logger"
+)
+
+type Option func(*Repository)
+
+// WithReplicas routes read-only queries outside of transactions to the read replicas.
+func WithReplicas(dsns ...string) Option {
+	return func(r *Repository) {
+		r.replicaDSNs = append(r.replicaDSNs, dsns...)
+	}
+}
+
+// WithReadYourWrites routes reads of a user to the primary for the window after the user's own write,
+// so the user sees the write even if replicas lag behind. It has no effect without replicas.
+func WithReadYourWrites(window time.Duration) Option {
+	return func(r *Repository) {
+		r.recentWrites.window = window
+	}
+}
+
+// replica is a read replica, it gets no queries while unhealthy.
+type replica struct {
+	pool    *pgxpool.Pool
+	healthy atomic.Bool
+}
+
+// connectReplicas creates pools of the replicas without waiting for them,
+// a replica unreachable on start fails its first query and is excluded until it answers.
+func (r *Repository) connectReplicas(ctx context.Context) ([]*replica, error) {
+	replicas := make([]*replica, 0, len(r.replicaDSNs))
+
+	for _, dsn := range r.replicaDSNs {
+		pool, err := r.connect(ctx, dsn, nil)
+		if err != nil {
+			closeReplicas(replicas)
+
+			return nil, err
+		}
+
+		replica := &replica{pool: pool}
+		replica.healthy.Store(true)
+
+		replicas = append(replicas, replica)
+	}
+
+	return replicas, nil
+}
+
+func closeReplicas(replicas []*replica) {
+	for _, replica := range replicas {
+		replica.pool.Close()
+	}
+}
+
+// reader returns the executor for a read-only query of the users: the transaction of ctx,
+// the next healthy replica or the primary if there are none or one of the users has just written.
+// The returned replica is nil for the primary.
+func (r *Repository) reader(ctx context.Context, userIDs ...uuid.UUID) (executor, *replica) {
+	if _, inTx := ctx.Value(txContextKey).(pgx.Tx); inTx || len(r.replicas) == 0 {
+		return r.checkTx(ctx), nil
+	}
+
+	for _, userID := range userIDs {
+		if r.recentWrites.has(userID) {
+			return r.DB, nil
+		}
+	}
+
+	next := r.nextReplica.Add(1)
+
+	for i := range r.replicas {
+		replica := r.replicas[(int(next)+i)%len(r.replicas)]
+		if replica.healthy.Load() {
+			return replica.pool, replica
+		}
+	}
+
+	return r.DB, nil
+}
+
+// failover marks the replica unhealthy after a failed query, so the query and the following ones
+// go to the primary until the replica passes a health check.
+func (r *Repository) failover(ctx context.Context, replica *replica, err error) {
+	if replica.healthy.CompareAndSwap(true, false) {
+		logger.FromContext(ctx).Warn("read replica failed, reading from primary",
+			slog.String("replica", replica.pool.Config().ConnConfig.Host),
+			slog.Any("error", err))
+	}
+}
+
+// RunReplicaChecks pings the replicas every interval until ctx is done, failed replicas are
+// excluded from reads and included back once they answer again.
+func (r *Repository) RunReplicaChecks(ctx context.Context, interval time.Duration) {
+	if len(r.replicas) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.checkReplicas(ctx, interval)
+			r.recentWrites.prune()
+		}
+	}
+}
+
+func (r *Repository) checkReplicas(ctx context.Context, timeout time.Duration) {
+	log := logger.FromContext(ctx)
+
+	for _, replica := range r.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := replica.pool.Ping(pingCtx)
+
+		cancel()
+
+		host := slog.String("replica", replica.pool.Config().ConnConfig.Host)
+
+		switch {
+		case err != nil && replica.healthy.CompareAndSwap(true, false):
+			log.Warn("read replica is unhealthy, reading from primary", host, slog.Any("error", err))
+		case err == nil && replica.healthy.CompareAndSwap(false, true):
+			log.Info("read replica is healthy again", host)
+		}
+	}
+}
+
+// writeTracker remembers when users wrote last, only if the read your writes window is set.
+type writeTracker struct {
+	window time.Duration
+
+	mu     sync.Mutex
+	writes map[uuid.UUID]time.Time
+}
+
+func (w *writeTracker) record(userIDs ...uuid.UUID) {
+	if w.window <= 0 {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.writes == nil {
+		w.writes = make(map[uuid.UUID]time.Time)
+	}
+
+	now := time.Now()
+	for _, userID := range userIDs {
+		w.writes[userID] = now
+	}
+}
+
+func (w *writeTracker) has(userID uuid.UUID) bool {
+	if w.window <= 0 {
+		return false
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	wroteAt, ok := w.writes[userID]
+
+	return ok && time.Since(wroteAt) < w.window
+}
+
+// prune forgets writes older than the window.
+func (w *writeTracker) prune() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for userID, wroteAt := range w.writes {
+		if time.Since(wroteAt) >= w.window {
+			delete(w.writes, userID)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReader(t *testing.T) {
+	primary := &pgxpool.Pool{}
+	first := &replica{pool: &pgxpool.Pool{}}
+	second := &replica{pool: &pgxpool.Pool{}}
+
+	writer, reader := uuid.New(), uuid.New()
+
+	cases := []struct {
+		Name            string
+		Healthy         []bool
+		UserID          uuid.UUID
+		ExpectedReplica []*replica
+	}{
+		{
+			Name:            "replicas in turn",
+			Healthy:         []bool{true, true},
+			UserID:          reader,
+			ExpectedReplica: []*replica{second, first, second},
+		},
+		{
+			Name:            "unhealthy replica skipped",
+			Healthy:         []bool{false, true},
+			UserID:          reader,
+			ExpectedReplica: []*replica{second, second},
+		},
+		{
+			Name:            "primary without healthy replicas",
+			Healthy:         []bool{false, false},
+			UserID:          reader,
+			ExpectedReplica: []*replica{nil},
+		},
+		{
+			Name:            "primary after own write",
+			Healthy:         []bool{true, true},
+			UserID:          writer,
+			ExpectedReplica: []*replica{nil},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo := &Repository{DB: primary, replicas: []*replica{first, second}}
+			repo.recentWrites.window = time.Minute
+			repo.recentWrites.record(writer)
+
+			first.healthy.Store(tc.Healthy[0])
+			second.healthy.Store(tc.Healthy[1])
+
+			for _, expected := range tc.ExpectedReplica {
+				ex, got := repo.reader(context.Background(), tc.UserID)
+				require.Equal(t, expected, got)
+
+				if expected == nil {
+					require.Same(t, primary, ex)
+				} else {
+					require.Same(t, expected.pool, ex)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteTracker(t *testing.T) {
+	userID := uuid.New()
+
+	var disabled writeTracker
+
+	disabled.record(userID)
+	require.False(t, disabled.has(userID), "read your writes is disabled")
+
+	tracker := writeTracker{window: 10 * time.Millisecond}
+
+	tracker.record(userID)
+	require.True(t, tracker.has(userID))
+	require.False(t, tracker.has(uuid.New()))
+
+	time.Sleep(20 * time.Millisecond)
+
+	require.False(t, tracker.has(userID), "write is older than the window")
+
+	tracker.prune()
+	require.Empty(t, tracker.writes)
+}
@@ -4,43 +4,183 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
 	_ "github.com/lib/pq"
+	"github.com/pressly/goose/v3"
 	"github.com/shopspring/decimal"
 
+	"github.com/aspirin100/finapi/internal/breaker"
 	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/logger"
+	"github.com/aspirin100/finapi/internal/metrics"
+	"github.com/aspirin100/finapi/internal/migrator"
+	"github.com/aspirin100/finapi/internal/repository/migrations"
+	"github.com/aspirin100/finapi/internal/tracing"
 )
 
 const (
 	defaultTransactionsCount = 100
 )
 
+// TransactionsAfterLimit is the page size of GetTransactionsAfter, it matches the limit of the queries.
+const TransactionsAfterLimit = 100
+
 var (
 	ErrUserNotFound    = errors.New("user not found")
 	ErrNegativeBalance = errors.New("not enough money on balance")
+	ErrTxConflict      = errors.New("transaction conflicts with a concurrent one")
+	ErrNotFound        = errors.New("not found")
+	ErrAlreadyPaid     = errors.New("interest is already paid for the period")
+	ErrAccountExists   = errors.New("account already exists")
+)
+
+const (
+	checkViolationCode       = "23514"
+	uniqueViolationCode      = "23505"
+	foreignKeyViolationCode  = "23503"
+	serializationFailureCode = "40001"
+	deadlockDetectedCode     = "40P01"
 )
 
 type Repository struct {
 	DB *pgxpool.Pool
+
+	migrator *goose.Provider
+	activeTx txTracker
+
+	poolConfig     PoolConfig
+	connectRetries int
+	connectBackoff time.Duration
+	breaker        *breaker.Breaker
+
+	passwordFunc PasswordFunc
+	password     atomic.Pointer[string]
+
+	replicaDSNs  []string
+	replicas     []*replica
+	nextReplica  atomic.Uint64
+	recentWrites writeTracker
+
+	resumeOverlap time.Duration
 }
 
 type executor interface {
 	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	SendBatch(ctx context.Context, batch *pgx.Batch) pgx.BatchResults
 }
 
-func NewConnection(ctx context.Context, postgresDSN string) (*Repository, error) {
-	conn, err := pgxpool.New(ctx, postgresDSN)
+func NewConnection(ctx context.Context, postgresDSN string, opts ...Option) (*Repository, error) {
+	r := &Repository{resumeOverlap: defaultResumeOverlap}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	err := r.loadPassword(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to postgres: %w", err)
+		return nil, err
 	}
 
-	return &Repository{
-		DB: conn,
-	}, nil
+	conn, err := r.connect(ctx, postgresDSN, r.breaker)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.waitReachable(ctx, conn)
+	if err != nil {
+		conn.Close()
+
+		return nil, err
+	}
+
+	r.migrator, err = goose.NewProvider(goose.DialectPostgres, stdlib.OpenDBFromPool(conn), migrations.Migrations)
+	if err != nil {
+		conn.Close()
+
+		return nil, fmt.Errorf("failed to create migrations provider: %w", err)
+	}
+
+	r.replicas, err = r.connectReplicas(ctx)
+	if err != nil {
+		conn.Close()
+
+		return nil, fmt.Errorf("failed to connect to read replica: %w", err)
+	}
+
+	// without replicas every read goes to the primary, and writes aren't tracked,
+	// as nothing would prune them
+	if len(r.replicas) == 0 {
+		r.recentWrites.window = 0
+	}
+
+	r.DB = conn
+
+	return r, nil
+}
+
+// Ping checks that the primary postgres is reachable, replicas are checked by RunReplicaChecks.
+func (r *Repository) Ping(ctx context.Context) error {
+	err := r.DB.Ping(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to ping postgres: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes all connections of the primary and replica pools.
+func (r *Repository) Close() {
+	r.DB.Close()
+	closeReplicas(r.replicas)
+}
+
+// WaitTx blocks until all db transactions in progress are committed or rolled back.
+func (r *Repository) WaitTx(ctx context.Context) error {
+	return r.activeTx.wait(ctx)
+}
+
+// SchemaVersion returns the latest applied migration version.
+func (r *Repository) SchemaVersion(ctx context.Context) (int64, error) {
+	version, err := r.migrator.GetDBVersion(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get schema version: %w", err)
+	}
+
+	return version, nil
+}
+
+// Migrate applies the pending embedded migrations and returns their versions. Replicas starting
+// together wait for each other on a postgres advisory lock up to lockTimeout, so one of them migrates
+// and the others find nothing pending.
+func (r *Repository) Migrate(ctx context.Context, lockTimeout time.Duration) ([]int64, error) {
+	db := stdlib.OpenDBFromPool(r.DB)
+	defer db.Close()
+
+	provider, err := migrator.NewProvider(db, migrations.Migrations, lockTimeout)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	results, err := provider.Up(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply migrations: %w", err)
+	}
+
+	versions := make([]int64, 0, len(results))
+	for _, result := range results {
+		versions = append(versions, result.Source.Version)
+	}
+
+	return versions, nil
 }
 
 type ctxKey struct{}
@@ -49,28 +189,57 @@ type CommitOrRollback func(err error) error
 var txContextKey = ctxKey{}
 
 func (r *Repository) BeginTx(ctx context.Context) (context.Context, CommitOrRollback, error) {
+	ctx, span := tracing.Start(ctx, "db.transaction")
+
 	tx, err := r.DB.BeginTx(ctx, pgx.TxOptions{
 		IsoLevel: pgx.ReadCommitted,
 	})
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to start transaction: %w", err)
+		err = fmt.Errorf("failed to start transaction: %w", err)
+		tracing.End(span, err)
+
+		return nil, nil, err
 	}
 
-	return context.WithValue(ctx, txContextKey, tx), func(err error) error {
+	r.activeTx.begin()
+
+	log := logger.FromContext(ctx)
+	log.Debug("db transaction started")
+
+	return context.WithValue(ctx, txContextKey, tx), func(err error) (errTx error) {
+		defer func() {
+			r.activeTx.end()
+			tracing.End(span, errTx)
+		}()
+
 		if err != nil {
+			metrics.TxRollbacks.Inc()
+
 			errRollback := tx.Rollback(ctx)
 			if errRollback != nil {
 				return errors.Join(err, errRollback)
 			}
 
+			log.Debug("db transaction rolled back", slog.Any("reason", err))
+
 			return err
 		}
 
 		errCommit := tx.Commit(ctx)
 		if errCommit != nil {
+			metrics.TxCommitFailures.Inc()
+
+			log.Warn("failed to commit db transaction", slog.Any("error", errCommit))
+
+			if isConflict(errCommit) {
+				return ErrTxConflict
+			}
+
 			return fmt.Errorf("failed to commit transaction: %w", errCommit)
 		}
 
+		log.Debug("db transaction committed")
+
 		return nil
 	}, nil
 }
@@ -100,8 +269,10 @@ func (r *Repository) UpdateBalance(ctx context.Context,
 		var pgErr *pgconn.PgError
 
 		switch {
-		case errors.As(err, &pgErr) && pgErr.Code == "23514":
+		case errors.As(err, &pgErr) && pgErr.Code == checkViolationCode:
 			return nil, ErrNegativeBalance
+		case isConflict(err):
+			return nil, ErrTxConflict
 		default:
 			return nil, fmt.Errorf("update balance query fail: %w", err)
 		}
@@ -111,6 +282,8 @@ func (r *Repository) UpdateBalance(ctx context.Context,
 		return nil, ErrUserNotFound
 	}
 
+	r.recentWrites.record(userID)
+
 	return &currentBalance, nil
 }
 
@@ -118,6 +291,22 @@ func (r *Repository) SaveTransaction(ctx context.Context,
 	receiverID, senderID uuid.UUID,
 	amount decimal.Decimal,
 	operation string) (*entity.Transaction, error) {
+	return r.saveTransaction(ctx, receiverID, senderID, amount, operation, nil)
+}
+
+// SaveLinkedTransaction saves the transaction linked to the parent one, e.g. the fee of a transfer.
+func (r *Repository) SaveLinkedTransaction(ctx context.Context,
+	parentID, receiverID, senderID uuid.UUID,
+	amount decimal.Decimal,
+	operation string) (*entity.Transaction, error) {
+	return r.saveTransaction(ctx, receiverID, senderID, amount, operation, &parentID)
+}
+
+func (r *Repository) saveTransaction(ctx context.Context,
+	receiverID, senderID uuid.UUID,
+	amount decimal.Decimal,
+	operation string,
+	parentID *uuid.UUID) (*entity.Transaction, error) {
 	ex := r.checkTx(ctx)
 
 	transactionID := uuid.New()
@@ -128,7 +317,8 @@ func (r *Repository) SaveTransaction(ctx context.Context,
 		receiverID,
 		senderID,
 		amount,
-		operation)
+		operation,
+		parentID)
 	if err != nil {
 		return nil, fmt.Errorf("save transaction query error: %w", err)
 	}
@@ -147,51 +337,209 @@ func (r *Repository) SaveTransaction(ctx context.Context,
 		var pgErr *pgconn.PgError
 
 		switch {
-		case errors.As(err, &pgErr) && pgErr.Code == "23503":
+		case errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolationCode:
 			return nil, ErrUserNotFound
 		default:
 			return nil, fmt.Errorf("failed to save transaction: %w", err)
 		}
 	}
 
+	r.recentWrites.record(receiverID, senderID)
+
 	transaction.ID = transactionID
 	transaction.ReceiverID = receiverID
 	transaction.SenderID = senderID
 	transaction.Operation = operation
 	transaction.Amount = amount
+	transaction.ParentID = parentID
 
 	return &transaction, nil
 }
 
+// CreateAccount opens the account with the id, ErrAccountExists is returned if the id is taken.
+func (r *Repository) CreateAccount(ctx context.Context, id uuid.UUID, accountType string) (*entity.Account, error) {
+	ex := r.checkTx(ctx)
+
+	account := entity.Account{
+		ID:      id,
+		Balance: decimal.Zero,
+		Tier:    entity.TierStandard,
+		Type:    accountType,
+	}
+
+	rows, err := ex.Query(ctx, NewAccountQuery, account.ID, account.Balance, account.Tier, account.Type)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create account: %w", err)
+	}
+
+	rows.Close()
+
+	err = rows.Err()
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode {
+			return nil, ErrAccountExists
+		}
+
+		return nil, fmt.Errorf("create account query fail: %w", err)
+	}
+
+	return &account, nil
+}
+
+// UpdateAccountTier moves the account to the tier, ErrUserNotFound is returned if it doesn't exist.
+func (r *Repository) UpdateAccountTier(ctx context.Context, userID uuid.UUID, tier string) (*entity.Account, error) {
+	rows, err := r.checkTx(ctx).Query(ctx, UpdateAccountTierQuery, userID, tier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update account tier: %w", err)
+	}
+
+	account, err := pgx.CollectExactlyOneRow(rows, scanAccount)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, ErrUserNotFound
+		case isConflict(err):
+			return nil, ErrTxConflict
+		default:
+			return nil, fmt.Errorf("failed to update account tier: %w", err)
+		}
+	}
+
+	return &account, nil
+}
+
+func (r *Repository) GetAccount(ctx context.Context, userID uuid.UUID) (*entity.Account, error) {
+	ex := r.checkTx(ctx)
+
+	rows, err := ex.Query(ctx, GetAccountQuery, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	account, err := pgx.CollectExactlyOneRow(rows, scanAccount)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+
+		return nil, fmt.Errorf("failed to read account: %w", err)
+	}
+
+	return &account, nil
+}
+
+// GetTransaction returns the transaction, one not found on a replica is looked up on the primary
+// as the replica may lag behind.
+func (r *Repository) GetTransaction(ctx context.Context,
+	transactionID uuid.UUID) (*entity.Transaction, error) {
+	ex, replica := r.reader(ctx)
+
+	transactions, err := r.queryTransactions(ctx, ex, replica, GetTransactionQuery, transactionID)
+	if err == nil && len(transactions) == 0 && replica != nil {
+		transactions, err = r.queryTransactions(ctx, r.DB, nil, GetTransactionQuery, transactionID)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(transactions) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return &transactions[0], nil
+}
+
 func (r *Repository) GetTransactions(ctx context.Context,
 	userID uuid.UUID) ([]entity.Transaction, error) {
-	rows, err := r.DB.Query(
-		ctx,
-		GetTransactionsQuery,
-		userID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get users's transactions: %w", err)
+	ex, replica := r.reader(ctx, userID)
+
+	return r.queryTransactions(ctx, ex, replica, GetTransactionsQuery, userID)
+}
+
+// defaultResumeOverlap is the resume overlap of GetTransactionsAfter unless WithResumeOverlap is set.
+const defaultResumeOverlap = 5 * time.Second
+
+// WithResumeOverlap sets how long transactions may stay uncommitted after their createdAt,
+// which is the start of their db transaction. GetTransactionsAfter returns transactions
+// of the overlap before the given one again, so ones committed out of order aren't missed.
+func WithResumeOverlap(overlap time.Duration) Option {
+	return func(r *Repository) {
+		r.resumeOverlap = overlap
+	}
+}
+
+// GetTransactionsAfter returns a page of up to TransactionsAfterLimit transactions of the user committed
+// after the transaction with afterID, oldest first. Concurrent transactions commit out of createdAt order,
+// so transactions created within the resume overlap before it are returned too, callers skip the ones
+// they have already by id. The page starts after the transaction with pageAfterID, the last one
+// of the previous page, or at the beginning if it is uuid.Nil.
+// It returns no transactions if afterID is unknown, e.g. not replicated yet.
+func (r *Repository) GetTransactionsAfter(ctx context.Context,
+	userID, afterID, pageAfterID uuid.UUID) ([]entity.Transaction, error) {
+	ex, replica := r.reader(ctx, userID)
+
+	return r.queryTransactions(ctx, ex, replica, GetTransactionsAfterQuery,
+		userID, afterID, r.resumeOverlap.Seconds(), pageCursor(pageAfterID))
+}
+
+// pageCursor is the pageAfterID query argument, NULL for the first page.
+func pageCursor(pageAfterID uuid.UUID) any {
+	if pageAfterID == uuid.Nil {
+		return nil
+	}
+
+	return pageAfterID
+}
+
+// queryTransactions runs the read-only query with ex, the query is repeated on the primary
+// if ex is a replica and fails.
+func (r *Repository) queryTransactions(ctx context.Context,
+	ex executor,
+	replica *replica,
+	query string,
+	args ...any) ([]entity.Transaction, error) {
+	rows, err := ex.Query(ctx, query, args...)
+	if err == nil {
+		var transactions []entity.Transaction
+
+		transactions, err = scanTransactions(rows)
+		if err == nil {
+			return transactions, nil
+		}
 	}
 
+	if replica == nil || ctx.Err() != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	r.failover(ctx, replica, err)
+
+	return r.queryTransactions(ctx, r.DB, nil, query, args...)
+}
+
+func scanAccount(row pgx.CollectableRow) (entity.Account, error) {
+	var account entity.Account
+
+	err := row.Scan(&account.ID, &account.Balance, &account.Tier, &account.Type)
+
+	return account, err //nolint:wrapcheck
+}
+
+func scanTransactions(rows pgx.Rows) ([]entity.Transaction, error) {
 	transactions := make([]entity.Transaction, 0, defaultTransactionsCount)
 
 	for i := 0; rows.Next(); i++ {
 		transactions = append(transactions, entity.Transaction{})
 
-		err = rows.Scan(
-			&transactions[i].ID,
-			&transactions[i].ReceiverID,
-			&transactions[i].SenderID,
-			&transactions[i].Amount,
-			&transactions[i].Operation,
-			&transactions[i].CreatedAt,
-		)
+		err := scanTransaction(rows, &transactions[i])
 		if err != nil {
-			return nil, fmt.Errorf("scanning error: %w", err)
+			return nil, err
 		}
 	}
 
-	err = rows.Err()
+	err := rows.Err()
 	if err != nil {
 		return nil, fmt.Errorf("error during read transactions: %w", err)
 	}
@@ -204,6 +552,23 @@ func (r *Repository) GetTransactions(ctx context.Context,
 	return result, nil
 }
 
+func scanTransaction(rows pgx.Rows, transaction *entity.Transaction) error {
+	err := rows.Scan(
+		&transaction.ID,
+		&transaction.ReceiverID,
+		&transaction.SenderID,
+		&transaction.Amount,
+		&transaction.Operation,
+		&transaction.ParentID,
+		&transaction.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("scanning error: %w", err)
+	}
+
+	return nil
+}
+
 func (r *Repository) checkTx(ctx context.Context) executor {
 	var ex executor = r.DB
 
@@ -216,15 +581,41 @@ func (r *Repository) checkTx(ctx context.Context) executor {
 	return ex
 }
 
+// isConflict reports whether err is caused by a concurrent transaction
+// and the whole transaction can be safely retried.
+func isConflict(err error) bool {
+	var pgErr *pgconn.PgError
+
+	return errors.As(err, &pgErr) &&
+		(pgErr.Code == serializationFailureCode || pgErr.Code == deadlockDetectedCode)
+}
+
 const (
 	UpdateBalanceQuery  = `update bank_accounts set balance = (balance + $2) where userID = $1 returning balance`
-	NewTransactionQuery = `insert into transactions(id, receiverID, senderID, amount, operation)
-	values ($1, $2, $3, $4, $5)
+	NewTransactionQuery = `insert into transactions(id, receiverID, senderID, amount, operation, parentID)
+	values ($1, $2, $3, $4, $5, $6)
 	returning createdAt`
+	NewAccountQuery        = `insert into bank_accounts(userID, balance, tier, type) values ($1, $2, $3, $4)`
+	GetAccountQuery        = `select userID, balance, tier, type from bank_accounts where userID = $1`
+	UpdateAccountTierQuery = `update bank_accounts set tier = $2 where userID = $1
+	returning userID, balance, tier, type`
+	GetTransactionQuery = `select
+	id, receiverID, senderID, amount, operation, parentID, createdAt
+	from transactions
+	where id = $1`
 	GetTransactionsQuery = `select
-	id, receiverID, senderID, amount, operation, createdAt
+	id, receiverID, senderID, amount, operation, parentID, createdAt
 	from transactions
 	where receiverID = $1 OR senderID = $1
 	order by createdAt
 	limit 10`
+	GetTransactionsAfterQuery = `select
+	id, receiverID, senderID, amount, operation, parentID, createdAt
+	from transactions
+	where (receiverID = $1 OR senderID = $1)
+	and createdAt > (select createdAt from transactions where id = $2) - make_interval(secs => $3)
+	and id != $2
+	and ($4::uuid is null or (createdAt, id) > (select createdAt, id from transactions where id = $4))
+	order by createdAt, id
+	limit 100`
 )
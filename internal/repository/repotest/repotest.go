@@ -0,0 +1,579 @@
+// Package repotest is the conformance test suite of repository implementations, so the postgres,
+// sqlite and in-memory stores can't diverge. The suite only adds data, it may run
+// against a database shared with other tests.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aspirin100/finapi/internal/audit"
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/repository"
+	"github.com/aspirin100/finapi/internal/risk"
+	"github.com/aspirin100/finapi/internal/service"
+)
+
+// Store is the repository under test.
+type Store interface {
+	service.UserManager
+	risk.History
+	WalkAuditLog(ctx context.Context, fn func(entry entity.AuditEntry) error) error
+}
+
+var errRollback = errors.New("rollback")
+
+// Run runs the suite against the store.
+func Run(t *testing.T, store Store) {
+	t.Helper()
+
+	tests := []struct {
+		Name string
+		Test func(t *testing.T, store Store)
+	}{
+		{Name: "accounts", Test: testAccounts},
+		{Name: "account tier", Test: testAccountTier},
+		{Name: "balance", Test: testBalance},
+		{Name: "transactions", Test: testTransactions},
+		{Name: "transactions after pages", Test: testTransactionsAfterPages},
+		{Name: "rollback", Test: testRollback},
+		{Name: "concurrent transactions", Test: testConcurrentTransactions},
+		{Name: "reviews", Test: testReviews},
+		{Name: "blocklist", Test: testBlocklist},
+		{Name: "audit log", Test: testAuditLog},
+		{Name: "transfer stats", Test: testTransferStats},
+		{Name: "interest", Test: testInterest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			test.Test(t, store)
+		})
+	}
+}
+
+// newAccount creates the account with the balance.
+func newAccount(t *testing.T, store Store, accountType string, balance int64) entity.Account {
+	t.Helper()
+
+	ctx := context.Background()
+
+	account, err := store.CreateAccount(ctx, uuid.New(), accountType)
+	require.NoError(t, err)
+
+	if balance != 0 {
+		_, err = store.UpdateBalance(ctx, account.ID, decimal.NewFromInt(balance))
+		require.NoError(t, err)
+
+		account.Balance = decimal.NewFromInt(balance)
+	}
+
+	return *account
+}
+
+func requireBalance(t *testing.T, store Store, accountID uuid.UUID, expected int64) {
+	t.Helper()
+
+	account, err := store.GetAccount(context.Background(), accountID)
+	require.NoError(t, err)
+	require.True(t, account.Balance.Equal(decimal.NewFromInt(expected)),
+		"expected balance %d, got %s", expected, account.Balance)
+}
+
+func testAccounts(t *testing.T, store Store) {
+	ctx := context.Background()
+
+	checking, err := store.CreateAccount(ctx, uuid.New(), entity.AccountChecking)
+	require.NoError(t, err)
+	require.True(t, checking.Balance.IsZero())
+	require.Equal(t, entity.TierStandard, checking.Tier)
+
+	savings, err := store.CreateAccount(ctx, uuid.New(), entity.AccountSavings)
+	require.NoError(t, err)
+
+	got, err := store.GetAccount(ctx, savings.ID)
+	require.NoError(t, err)
+	require.Equal(t, savings.ID, got.ID)
+	require.Equal(t, entity.AccountSavings, got.Type)
+	require.Equal(t, entity.TierStandard, got.Tier)
+	require.True(t, got.Balance.IsZero())
+
+	_, err = store.GetAccount(ctx, uuid.New())
+	require.ErrorIs(t, err, repository.ErrUserNotFound)
+
+	_, err = store.CreateAccount(ctx, uuid.New(), "gold")
+	require.Error(t, err, "unknown account type")
+
+	_, err = store.CreateAccount(ctx, checking.ID, entity.AccountChecking)
+	require.ErrorIs(t, err, repository.ErrAccountExists)
+
+	accounts, err := store.GetAccountsByType(ctx, entity.AccountSavings)
+	require.NoError(t, err)
+
+	ids := make([]uuid.UUID, 0, len(accounts))
+	for _, account := range accounts {
+		require.Equal(t, entity.AccountSavings, account.Type)
+
+		ids = append(ids, account.ID)
+	}
+
+	require.Contains(t, ids, savings.ID)
+	require.NotContains(t, ids, checking.ID)
+}
+
+func testAccountTier(t *testing.T, store Store) {
+	ctx := context.Background()
+
+	account := newAccount(t, store, entity.AccountChecking, 100)
+
+	updated, err := store.UpdateAccountTier(ctx, account.ID, "premium")
+	require.NoError(t, err)
+	require.Equal(t, "premium", updated.Tier)
+	require.Equal(t, entity.AccountChecking, updated.Type)
+	require.True(t, updated.Balance.Equal(decimal.NewFromInt(100)))
+
+	got, err := store.GetAccount(ctx, account.ID)
+	require.NoError(t, err)
+	require.Equal(t, "premium", got.Tier)
+
+	_, err = store.UpdateAccountTier(ctx, uuid.New(), "premium")
+	require.ErrorIs(t, err, repository.ErrUserNotFound)
+}
+
+func testBalance(t *testing.T, store Store) {
+	ctx := context.Background()
+
+	account := newAccount(t, store, entity.AccountChecking, 0)
+
+	balance, err := store.UpdateBalance(ctx, account.ID, decimal.RequireFromString("10.25"))
+	require.NoError(t, err)
+	require.Equal(t, "10.25", balance.String())
+
+	balance, err = store.UpdateBalance(ctx, account.ID, decimal.RequireFromString("-10.25"))
+	require.NoError(t, err)
+	require.True(t, balance.IsZero(), "balance may drop to zero")
+
+	_, err = store.UpdateBalance(ctx, account.ID, decimal.RequireFromString("-0.01"))
+	require.ErrorIs(t, err, repository.ErrNegativeBalance)
+
+	requireBalance(t, store, account.ID, 0)
+
+	_, err = store.UpdateBalance(ctx, uuid.New(), decimal.NewFromInt(1))
+	require.ErrorIs(t, err, repository.ErrUserNotFound)
+}
+
+func testTransactions(t *testing.T, store Store) {
+	ctx := context.Background()
+
+	sender := newAccount(t, store, entity.AccountChecking, 0)
+	receiver := newAccount(t, store, entity.AccountChecking, 0)
+
+	_, err := store.SaveTransaction(ctx, uuid.New(), sender.ID, decimal.NewFromInt(1), "transfer")
+	require.ErrorIs(t, err, repository.ErrUserNotFound, "unknown receiver")
+
+	_, err = store.SaveTransaction(ctx, receiver.ID, uuid.New(), decimal.NewFromInt(1), "transfer")
+	require.ErrorIs(t, err, repository.ErrUserNotFound, "unknown sender")
+
+	deposit, err := store.SaveTransaction(ctx, sender.ID, sender.ID, decimal.NewFromInt(5), "deposit")
+	require.NoError(t, err)
+	require.False(t, deposit.CreatedAt.IsZero())
+
+	transfer, err := store.SaveTransaction(ctx, receiver.ID, sender.ID, decimal.RequireFromString("1.5"), "transfer")
+	require.NoError(t, err)
+
+	fee, err := store.SaveLinkedTransaction(ctx, transfer.ID, receiver.ID, sender.ID, decimal.NewFromInt(1), "fee")
+	require.NoError(t, err)
+	require.Equal(t, transfer.ID, *fee.ParentID)
+
+	got, err := store.GetTransaction(ctx, transfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, transfer.ID, got.ID)
+	require.Equal(t, sender.ID, got.SenderID)
+	require.Equal(t, receiver.ID, got.ReceiverID)
+	require.Equal(t, "1.5", got.Amount.String())
+	require.Equal(t, "transfer", got.Operation)
+	require.Nil(t, got.ParentID)
+	require.True(t, transfer.CreatedAt.Equal(got.CreatedAt))
+
+	got, err = store.GetTransaction(ctx, fee.ID)
+	require.NoError(t, err)
+	require.Equal(t, transfer.ID, *got.ParentID)
+
+	_, err = store.GetTransaction(ctx, uuid.New())
+	require.ErrorIs(t, err, repository.ErrNotFound)
+
+	transactions, err := store.GetTransactions(ctx, sender.ID)
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{deposit.ID, transfer.ID, fee.ID}, transactionIDs(transactions))
+
+	transactions, err = store.GetTransactions(ctx, receiver.ID)
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{transfer.ID, fee.ID}, transactionIDs(transactions))
+
+	transactions, err = store.GetTransactionsAfter(ctx, sender.ID, deposit.ID, uuid.Nil)
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{transfer.ID, fee.ID}, transactionIDs(transactions))
+
+	transactions, err = store.GetTransactionsAfter(ctx, sender.ID, uuid.New(), uuid.Nil)
+	require.NoError(t, err)
+	require.Empty(t, transactions, "unknown transaction")
+
+	transactions, err = store.GetTransactions(ctx, uuid.New())
+	require.NoError(t, err)
+	require.Empty(t, transactions)
+}
+
+func testTransactionsAfterPages(t *testing.T, store Store) {
+	ctx := context.Background()
+
+	account := newAccount(t, store, entity.AccountChecking, 0)
+
+	first, err := store.SaveTransaction(ctx, account.ID, account.ID, decimal.NewFromInt(1), "deposit")
+	require.NoError(t, err)
+
+	missed := make([]uuid.UUID, 0, repository.TransactionsAfterLimit+50)
+
+	for range cap(missed) {
+		transaction, err := store.SaveTransaction(ctx, account.ID, account.ID, decimal.NewFromInt(1), "deposit")
+		require.NoError(t, err)
+
+		missed = append(missed, transaction.ID)
+	}
+
+	page, err := store.GetTransactionsAfter(ctx, account.ID, first.ID, uuid.Nil)
+	require.NoError(t, err)
+	require.Len(t, page, repository.TransactionsAfterLimit)
+
+	got := transactionIDs(page)
+
+	page, err = store.GetTransactionsAfter(ctx, account.ID, first.ID, got[len(got)-1])
+	require.NoError(t, err)
+
+	got = append(got, transactionIDs(page)...)
+
+	page, err = store.GetTransactionsAfter(ctx, account.ID, first.ID, got[len(got)-1])
+	require.NoError(t, err)
+	require.Empty(t, page, "no page after the last one")
+
+	require.ElementsMatch(t, missed, got)
+}
+
+func transactionIDs(transactions []entity.Transaction) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(transactions))
+	for _, transaction := range transactions {
+		ids = append(ids, transaction.ID)
+	}
+
+	return ids
+}
+
+func testRollback(t *testing.T, store Store) {
+	ctx := context.Background()
+
+	account := newAccount(t, store, entity.AccountChecking, 10)
+
+	txCtx, commitOrRollback, err := store.BeginTx(ctx)
+	require.NoError(t, err)
+
+	_, err = store.UpdateBalance(txCtx, account.ID, decimal.NewFromInt(5))
+	require.NoError(t, err)
+
+	transaction, err := store.SaveTransaction(txCtx, account.ID, account.ID, decimal.NewFromInt(5), "deposit")
+	require.NoError(t, err)
+
+	created, err := store.CreateAccount(txCtx, uuid.New(), entity.AccountChecking)
+	require.NoError(t, err)
+
+	got, err := store.GetAccount(txCtx, account.ID)
+	require.NoError(t, err)
+	require.Equal(t, "15", got.Balance.String(), "transaction sees its own changes")
+
+	require.ErrorIs(t, commitOrRollback(errRollback), errRollback)
+
+	requireBalance(t, store, account.ID, 10)
+
+	_, err = store.GetTransaction(ctx, transaction.ID)
+	require.ErrorIs(t, err, repository.ErrNotFound)
+
+	_, err = store.GetAccount(ctx, created.ID)
+	require.ErrorIs(t, err, repository.ErrUserNotFound)
+
+	txCtx, commitOrRollback, err = store.BeginTx(ctx)
+	require.NoError(t, err)
+
+	_, err = store.UpdateBalance(txCtx, account.ID, decimal.NewFromInt(-4))
+	require.NoError(t, err)
+
+	require.NoError(t, commitOrRollback(nil))
+
+	requireBalance(t, store, account.ID, 6)
+}
+
+func testConcurrentTransactions(t *testing.T, store Store) {
+	ctx := context.Background()
+
+	const transfers = 20
+
+	sender := newAccount(t, store, entity.AccountChecking, transfers/2)
+	receiver := newAccount(t, store, entity.AccountChecking, 0)
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed int
+	)
+
+	for range transfers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			txCtx, commitOrRollback, err := store.BeginTx(ctx)
+			if !assertNoError(t, err) {
+				return
+			}
+
+			_, err = store.UpdateBalance(txCtx, sender.ID, decimal.NewFromInt(-1))
+			if err == nil {
+				_, err = store.UpdateBalance(txCtx, receiver.ID, decimal.NewFromInt(1))
+			}
+
+			err = commitOrRollback(err)
+			if err != nil {
+				mu.Lock()
+				defer mu.Unlock()
+
+				if !errors.Is(err, repository.ErrNegativeBalance) {
+					t.Errorf("unexpected transfer error: %v", err)
+				}
+
+				failed++
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	require.Equal(t, transfers/2, failed, "transfers over the balance fail")
+	requireBalance(t, store, sender.ID, 0)
+	requireBalance(t, store, receiver.ID, transfers/2)
+}
+
+func assertNoError(t *testing.T, err error) bool {
+	t.Helper()
+
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+
+		return false
+	}
+
+	return true
+}
+
+func testReviews(t *testing.T, store Store) {
+	ctx := context.Background()
+
+	sender := newAccount(t, store, entity.AccountChecking, 0)
+	receiver := newAccount(t, store, entity.AccountChecking, 0)
+
+	_, err := store.CreateReview(ctx, uuid.New(), sender.ID, decimal.NewFromInt(1), "rule", "reason")
+	require.ErrorIs(t, err, repository.ErrUserNotFound)
+
+	review, err := store.CreateReview(ctx, receiver.ID, sender.ID, decimal.NewFromInt(100), "max_amount", "too much")
+	require.NoError(t, err)
+	require.Equal(t, entity.ReviewPending, review.Status)
+	require.Equal(t, "max_amount", review.Rule)
+	require.Nil(t, review.DecidedAt)
+
+	got, err := store.GetReview(ctx, review.ID)
+	require.NoError(t, err)
+	require.Equal(t, review.ID, got.ID)
+	require.Equal(t, "100", got.Amount.String())
+
+	_, err = store.GetReview(ctx, uuid.New())
+	require.ErrorIs(t, err, repository.ErrNotFound)
+
+	pending, err := store.GetReviews(ctx, entity.ReviewPending)
+	require.NoError(t, err)
+	require.Contains(t, reviewIDs(pending), review.ID)
+
+	transaction, err := store.SaveTransaction(ctx, receiver.ID, sender.ID, decimal.NewFromInt(100), "transfer")
+	require.NoError(t, err)
+
+	decided, err := store.DecideReview(ctx, review.ID, entity.ReviewApproved, &transaction.ID)
+	require.NoError(t, err)
+	require.Equal(t, entity.ReviewApproved, decided.Status)
+	require.Equal(t, transaction.ID, *decided.TransactionID)
+	require.NotNil(t, decided.DecidedAt)
+
+	_, err = store.DecideReview(ctx, review.ID, entity.ReviewRejected, nil)
+	require.ErrorIs(t, err, repository.ErrNotFound, "review is decided once")
+
+	pending, err = store.GetReviews(ctx, entity.ReviewPending)
+	require.NoError(t, err)
+	require.NotContains(t, reviewIDs(pending), review.ID)
+}
+
+func reviewIDs(reviews []entity.Review) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(reviews))
+	for _, review := range reviews {
+		ids = append(ids, review.ID)
+	}
+
+	return ids
+}
+
+func testBlocklist(t *testing.T, store Store) {
+	ctx := context.Background()
+
+	partyID := uuid.New()
+
+	party, err := store.BlockParty(ctx, partyID, "sanctions")
+	require.NoError(t, err)
+	require.Equal(t, "sanctions", party.Reason)
+
+	party, err = store.BlockParty(ctx, partyID, "fraud")
+	require.NoError(t, err)
+	require.Equal(t, "fraud", party.Reason, "reason of blocked party is updated")
+
+	parties, err := store.GetBlockedParties(ctx)
+	require.NoError(t, err)
+	require.Contains(t, parties, *party)
+
+	err = store.SaveScreeningDecisions(ctx, []entity.ScreeningDecision{
+		{ID: uuid.New(), Operation: "transfer", PartyID: partyID, Blocked: true, Reason: "fraud"},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, store.UnblockParty(ctx, partyID))
+	require.ErrorIs(t, store.UnblockParty(ctx, partyID), repository.ErrNotFound)
+
+	parties, err = store.GetBlockedParties(ctx)
+	require.NoError(t, err)
+	require.NotContains(t, parties, *party)
+}
+
+func testAuditLog(t *testing.T, store Store) {
+	ctx := context.Background()
+
+	entry, err := audit.NewEntry(ctx, audit.ActionDeposit, "account/"+uuid.NewString(), nil, map[string]int{"balance": 1})
+	require.NoError(t, err)
+	require.NoError(t, store.AppendAudit(ctx, entry))
+
+	txCtx, commitOrRollback, err := store.BeginTx(ctx)
+	require.NoError(t, err)
+
+	rolledBack, err := audit.NewEntry(ctx, audit.ActionDeposit, "account/"+uuid.NewString(), nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, store.AppendAudit(txCtx, rolledBack))
+	require.ErrorIs(t, commitOrRollback(errRollback), errRollback)
+
+	var (
+		verifier audit.Verifier
+		ids      []uuid.UUID
+	)
+
+	err = store.WalkAuditLog(ctx, func(entry entity.AuditEntry) error {
+		ids = append(ids, entry.ID)
+
+		return verifier.Check(entry)
+	})
+	require.NoError(t, err, "entry of rolled back transaction doesn't break the chain")
+	require.Contains(t, ids, entry.ID)
+	require.NotContains(t, ids, rolledBack.ID)
+}
+
+func testTransferStats(t *testing.T, store Store) {
+	ctx := context.Background()
+
+	sender := newAccount(t, store, entity.AccountChecking, 0)
+	receiver := newAccount(t, store, entity.AccountChecking, 0)
+	other := newAccount(t, store, entity.AccountChecking, 0)
+
+	since := time.Now().Add(-time.Minute)
+
+	for _, transfer := range []struct {
+		receiverID uuid.UUID
+		amount     int64
+		operation  string
+	}{
+		{receiverID: receiver.ID, amount: 10, operation: "transfer"},
+		{receiverID: other.ID, amount: 5, operation: "transfer"},
+		{receiverID: receiver.ID, amount: 1, operation: "fee"},
+	} {
+		_, err := store.SaveTransaction(ctx, transfer.receiverID, sender.ID,
+			decimal.NewFromInt(transfer.amount), transfer.operation)
+		require.NoError(t, err)
+	}
+
+	stats, err := store.TransferStats(ctx, sender.ID, uuid.Nil, since)
+	require.NoError(t, err)
+	require.Equal(t, 2, stats.Count)
+	require.Equal(t, "15", stats.Sum.String())
+
+	stats, err = store.TransferStats(ctx, sender.ID, receiver.ID, since)
+	require.NoError(t, err)
+	require.Equal(t, 1, stats.Count)
+	require.Equal(t, "10", stats.Sum.String())
+
+	stats, err = store.TransferStats(ctx, sender.ID, uuid.Nil, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.Zero(t, stats.Count)
+	require.True(t, stats.Sum.IsZero())
+}
+
+func testInterest(t *testing.T, store Store) {
+	ctx := context.Background()
+
+	account := newAccount(t, store, entity.AccountSavings, 0)
+	payer := newAccount(t, store, entity.AccountChecking, 0)
+
+	amount := decimal.NewFromInt(100)
+
+	_, err := store.UpdateBalance(ctx, account.ID, amount)
+	require.NoError(t, err)
+
+	_, err = store.SaveTransaction(ctx, account.ID, account.ID, amount, "deposit")
+	require.NoError(t, err)
+
+	_, err = store.UpdateBalance(ctx, account.ID, decimal.NewFromInt(-30))
+	require.NoError(t, err)
+
+	_, err = store.UpdateBalance(ctx, payer.ID, decimal.NewFromInt(30))
+	require.NoError(t, err)
+
+	transfer, err := store.SaveTransaction(ctx, payer.ID, account.ID, decimal.NewFromInt(30), "transfer")
+	require.NoError(t, err)
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	balances, err := store.GetDailyBalances(ctx, account.ID, today.AddDate(0, 0, -1), today.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Len(t, balances, 2)
+	require.True(t, balances[0].Day.Equal(today.AddDate(0, 0, -1)))
+	require.True(t, balances[0].Balance.IsZero(), "deposit is made after yesterday")
+	require.True(t, balances[1].Day.Equal(today))
+	require.Equal(t, "70", balances[1].Balance.String())
+
+	_, err = store.GetDailyBalances(ctx, uuid.New(), today, today.AddDate(0, 0, 1))
+	require.ErrorIs(t, err, repository.ErrUserNotFound)
+
+	payout := entity.InterestPayout{
+		AccountID:     account.ID,
+		Period:        today.AddDate(0, -1, 0),
+		Amount:        decimal.RequireFromString("0.5"),
+		TransactionID: transfer.ID,
+	}
+
+	require.NoError(t, store.SaveInterestPayout(ctx, payout))
+	require.ErrorIs(t, store.SaveInterestPayout(ctx, payout), repository.ErrAlreadyPaid)
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/shopspring/decimal"
+
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/risk"
+)
+
+// TransferStats summarizes transfers of the sender made since the given time,
+// only to the receiver unless receiverID is uuid.Nil.
+func (r *Repository) TransferStats(ctx context.Context,
+	senderID, receiverID uuid.UUID,
+	since time.Time) (risk.Stats, error) {
+	ex := r.checkTx(ctx)
+
+	rows, err := ex.Query(ctx, TransferStatsQuery, senderID, receiverID, since)
+	if err != nil {
+		return risk.Stats{}, fmt.Errorf("failed to get transfer stats: %w", err)
+	}
+
+	stats, err := pgx.CollectExactlyOneRow(rows, func(row pgx.CollectableRow) (risk.Stats, error) {
+		var stats risk.Stats
+
+		err := row.Scan(&stats.Count, &stats.Sum)
+
+		return stats, err
+	})
+	if err != nil {
+		return risk.Stats{}, fmt.Errorf("failed to read transfer stats: %w", err)
+	}
+
+	return stats, nil
+}
+
+func (r *Repository) CreateReview(ctx context.Context,
+	receiverID, senderID uuid.UUID,
+	amount decimal.Decimal,
+	rule, reason string) (*entity.Review, error) {
+	ex := r.checkTx(ctx)
+
+	rows, err := ex.Query(ctx, NewReviewQuery, uuid.New(), senderID, receiverID, amount, rule, reason)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create review: %w", err)
+	}
+
+	review, err := collectReview(rows)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolationCode {
+			return nil, ErrUserNotFound
+		}
+
+		return nil, err
+	}
+
+	return review, nil
+}
+
+// GetReview returns the review, called in a transaction it locks the review until the transaction ends.
+func (r *Repository) GetReview(ctx context.Context, reviewID uuid.UUID) (*entity.Review, error) {
+	query := GetReviewQuery
+	if _, inTx := ctx.Value(txContextKey).(pgx.Tx); inTx {
+		query += " for update"
+	}
+
+	rows, err := r.checkTx(ctx).Query(ctx, query, reviewID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get review: %w", err)
+	}
+
+	return collectReview(rows)
+}
+
+// GetReviews returns the oldest 100 reviews with the status.
+func (r *Repository) GetReviews(ctx context.Context, status string) ([]entity.Review, error) {
+	rows, err := r.checkTx(ctx).Query(ctx, GetReviewsQuery, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reviews: %w", err)
+	}
+
+	reviews, err := pgx.CollectRows(rows, scanReview)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read reviews: %w", err)
+	}
+
+	return reviews, nil
+}
+
+// DecideReview sets the status of a pending review, transactionID is the executed transfer of approved one.
+func (r *Repository) DecideReview(ctx context.Context,
+	reviewID uuid.UUID,
+	status string,
+	transactionID *uuid.UUID) (*entity.Review, error) {
+	rows, err := r.checkTx(ctx).Query(ctx, DecideReviewQuery, reviewID, status, transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decide review: %w", err)
+	}
+
+	return collectReview(rows)
+}
+
+func collectReview(rows pgx.Rows) (*entity.Review, error) {
+	review, err := pgx.CollectExactlyOneRow(rows, scanReview)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+
+		return nil, fmt.Errorf("failed to read review: %w", err)
+	}
+
+	return &review, nil
+}
+
+func scanReview(row pgx.CollectableRow) (entity.Review, error) {
+	var review entity.Review
+
+	err := row.Scan(
+		&review.ID,
+		&review.SenderID,
+		&review.ReceiverID,
+		&review.Amount,
+		&review.Rule,
+		&review.Reason,
+		&review.Status,
+		&review.TransactionID,
+		&review.CreatedAt,
+		&review.DecidedAt,
+	)
+
+	return review, err //nolint:wrapcheck
+}
+
+const (
+	TransferStatsQuery = `select count(*), coalesce(sum(amount), 0)
+	from transactions
+	where senderID = $1
+	and operation = 'transfer'
+	and ($2 = '00000000-0000-0000-0000-000000000000'::uuid or receiverID = $2)
+	and createdAt >= $3`
+	reviewColumns  = `id, senderID, receiverID, amount, rule, reason, status, transactionID, createdAt, decidedAt`
+	NewReviewQuery = `insert into transfer_reviews(id, senderID, receiverID, amount, rule, reason)
+	values ($1, $2, $3, $4, $5, $6)
+	returning ` + reviewColumns
+	GetReviewQuery  = `select ` + reviewColumns + ` from transfer_reviews where id = $1`
+	GetReviewsQuery = `select ` + reviewColumns + ` from transfer_reviews
+	where status = $1
+	order by createdAt
+	limit 100`
+	DecideReviewQuery = `update transfer_reviews
+	set status = $2, transactionID = $3, decidedAt = now()
+	where id = $1 and status = 'pending'
+	returning ` + reviewColumns
+)
@@ -0,0 +1,129 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aspirin100/finapi/internal/audit"
+	"github.com/aspirin100/finapi/internal/entity"
+)
+
+// AppendAudit links the entry to the last one in the log and saves it. Inside a transaction the entry
+// is committed or rolled back together with the audited change. Appends are serialized as all the writes.
+func (r *Repository) AppendAudit(ctx context.Context, entry entity.AuditEntry) error {
+	tx, ok := ctx.Value(txContextKey).(*sql.Tx)
+	if ok {
+		return appendAudit(ctx, tx, entry)
+	}
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+
+	defer tx.Rollback() //nolint:errcheck
+
+	err = appendAudit(ctx, tx, entry)
+	if err != nil {
+		return fmt.Errorf("failed to append audit entry: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to append audit entry: %w", err)
+	}
+
+	return nil
+}
+
+func appendAudit(ctx context.Context, tx *sql.Tx, entry entity.AuditEntry) error {
+	var prevHash string
+
+	err := tx.QueryRowContext(ctx, GetAuditHeadQuery).Scan(&prevHash)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to read last audit entry: %w", err)
+	}
+
+	err = audit.Chain(&entry, prevHash)
+	if err != nil {
+		return fmt.Errorf("failed to chain audit entry: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, NewAuditEntryQuery,
+		entry.ID,
+		entry.Actor,
+		entry.Action,
+		entry.Resource,
+		[]byte(entry.Before),
+		[]byte(entry.After),
+		entry.RequestID,
+		timestamp(entry.CreatedAt),
+		entry.PrevHash,
+		entry.Hash)
+	if err != nil {
+		return fmt.Errorf("failed to save audit entry: %w", err)
+	}
+
+	return nil
+}
+
+// WalkAuditLog calls fn for every entry of the log in the chain order until fn returns an error.
+func (r *Repository) WalkAuditLog(ctx context.Context, fn func(entry entity.AuditEntry) error) error {
+	rows, err := r.DB.QueryContext(ctx, GetAuditLogQuery)
+	if err != nil {
+		return fmt.Errorf("failed to get audit log: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			entry         entity.AuditEntry
+			before, after []byte
+			createdAt     timestamp
+		)
+
+		err = rows.Scan(
+			&entry.Seq,
+			&entry.ID,
+			&entry.Actor,
+			&entry.Action,
+			&entry.Resource,
+			&before,
+			&after,
+			&entry.RequestID,
+			&createdAt,
+			&entry.PrevHash,
+			&entry.Hash)
+		if err != nil {
+			return fmt.Errorf("failed to read audit entry: %w", err)
+		}
+
+		entry.Before, entry.After = before, after
+		entry.CreatedAt = time.Time(createdAt)
+
+		err = fn(entry)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	return nil
+}
+
+const (
+	GetAuditHeadQuery  = `select hash from audit_log order by seq desc limit 1`
+	NewAuditEntryQuery = `insert into audit_log(id, actor, action, resource, before, after, requestID, createdAt, prevHash, hash)
+	values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	GetAuditLogQuery = `select
+	seq, id, actor, action, resource, before, after, requestID, createdAt, prevHash, hash
+	from audit_log
+	order by seq`
+)
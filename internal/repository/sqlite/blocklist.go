@@ -0,0 +1,114 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/repository"
+)
+
+func (r *Repository) GetBlockedParties(ctx context.Context) ([]entity.BlockedParty, error) {
+	rows, err := r.checkTx(ctx).QueryContext(ctx, GetBlockedPartiesQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blocked parties: %w", err)
+	}
+	defer rows.Close()
+
+	parties := []entity.BlockedParty{}
+
+	for rows.Next() {
+		party, err := scanBlockedParty(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		parties = append(parties, party)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blocked parties: %w", err)
+	}
+
+	return parties, nil
+}
+
+// BlockParty adds the party to the blocklist or updates the reason if it is already blocked.
+func (r *Repository) BlockParty(ctx context.Context, partyID uuid.UUID, reason string) (*entity.BlockedParty, error) {
+	party, err := scanBlockedParty(r.checkTx(ctx).QueryRowContext(ctx, BlockPartyQuery,
+		partyID, reason, timestamp(r.now())))
+	if err != nil {
+		return nil, err
+	}
+
+	return &party, nil
+}
+
+func (r *Repository) UnblockParty(ctx context.Context, partyID uuid.UUID) error {
+	result, err := r.checkTx(ctx).ExecContext(ctx, UnblockPartyQuery, partyID)
+	if err != nil {
+		return fmt.Errorf("failed to unblock party: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to unblock party: %w", err)
+	}
+
+	if deleted == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *Repository) SaveScreeningDecisions(ctx context.Context, decisions []entity.ScreeningDecision) error {
+	ex := r.checkTx(ctx)
+	createdAt := timestamp(r.now())
+
+	for _, decision := range decisions {
+		_, err := ex.ExecContext(ctx, NewScreeningDecisionQuery,
+			decision.ID,
+			decision.Operation,
+			decision.PartyID,
+			decision.Blocked,
+			decision.Reason,
+			decision.RequestID,
+			createdAt)
+		if err != nil {
+			return fmt.Errorf("failed to save screening decisions: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func scanBlockedParty(row scanner) (entity.BlockedParty, error) {
+	var (
+		party     entity.BlockedParty
+		createdAt timestamp
+	)
+
+	err := row.Scan(&party.ID, &party.Reason, &createdAt)
+	if err != nil {
+		return entity.BlockedParty{}, fmt.Errorf("failed to read blocked party: %w", err)
+	}
+
+	party.CreatedAt = time.Time(createdAt)
+
+	return party, nil
+}
+
+const (
+	GetBlockedPartiesQuery = `select id, reason, createdAt from blocked_parties order by createdAt`
+	BlockPartyQuery        = `insert into blocked_parties(id, reason, createdAt) values (?, ?, ?)
+	on conflict (id) do update set reason = excluded.reason
+	returning id, reason, createdAt`
+	UnblockPartyQuery         = `delete from blocked_parties where id = ?`
+	NewScreeningDecisionQuery = `insert into screening_decisions(id, operation, partyID, blocked, reason, requestID, createdAt)
+	values (?, ?, ?, ?, ?, ?, ?)`
+)
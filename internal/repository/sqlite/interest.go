@@ -0,0 +1,161 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/repository"
+)
+
+// GetAccountsByType returns all accounts of the type.
+func (r *Repository) GetAccountsByType(ctx context.Context, accountType string) ([]entity.Account, error) {
+	rows, err := r.checkTx(ctx).QueryContext(ctx, GetAccountsByTypeQuery, accountType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounts: %w", err)
+	}
+	defer rows.Close()
+
+	accounts := []entity.Account{}
+
+	for rows.Next() {
+		var account entity.Account
+
+		err = rows.Scan(&account.ID, &account.Balance, &account.Tier, &account.Type)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read account: %w", err)
+		}
+
+		accounts = append(accounts, account)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read accounts: %w", err)
+	}
+
+	return accounts, nil
+}
+
+// GetDailyBalances returns end of day balances of the account for every day in [from, to),
+// both are expected to be UTC midnights. A balance is the current one less all the changes made after the day,
+// so it doesn't depend on any job running daily.
+func (r *Repository) GetDailyBalances(ctx context.Context,
+	accountID uuid.UUID,
+	from, to time.Time) ([]entity.DailyBalance, error) {
+	balances := []entity.DailyBalance{}
+
+	if !from.Before(to) {
+		return balances, nil
+	}
+
+	account, err := r.GetAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	from = from.UTC()
+
+	rows, err := r.checkTx(ctx).QueryContext(ctx, GetBalanceChangesQuery, accountID, timestamp(from.AddDate(0, 0, 1)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily balances: %w", err)
+	}
+	defer rows.Close()
+
+	// changes are newest first, so the balance is rolled back day by day from the last one
+	type change struct {
+		createdAt time.Time
+		amount    decimal.Decimal
+	}
+
+	var changes []change
+
+	for rows.Next() {
+		var (
+			c         change
+			createdAt timestamp
+		)
+
+		err = rows.Scan(&createdAt, &c.amount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read balance change: %w", err)
+		}
+
+		c.createdAt = time.Time(createdAt)
+		changes = append(changes, c)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read daily balances: %w", err)
+	}
+
+	var days []time.Time
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		days = append(days, day)
+	}
+
+	balances = make([]entity.DailyBalance, len(days))
+	balance := account.Balance
+
+	for i := len(days) - 1; i >= 0; i-- {
+		end := days[i].AddDate(0, 0, 1)
+
+		for len(changes) > 0 && !changes[0].createdAt.Before(end) {
+			balance = balance.Sub(changes[0].amount)
+			changes = changes[1:]
+		}
+
+		balances[i] = entity.DailyBalance{Day: days[i], Balance: balance}
+	}
+
+	return balances, nil
+}
+
+// SaveInterestPayout records the payout, ErrAlreadyPaid is returned if interest of the period
+// is already paid to the account, so the db transaction paying it again must be rolled back.
+func (r *Repository) SaveInterestPayout(ctx context.Context, payout entity.InterestPayout) error {
+	result, err := r.checkTx(ctx).ExecContext(ctx, NewInterestPayoutQuery,
+		payout.AccountID,
+		payout.Period.UTC().Format(dateLayout),
+		payout.Amount,
+		payout.TransactionID,
+		timestamp(r.now()))
+	if err != nil {
+		if isConflict(err) {
+			return repository.ErrTxConflict
+		}
+
+		return fmt.Errorf("failed to save interest payout: %w", err)
+	}
+
+	saved, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to save interest payout: %w", err)
+	}
+
+	if saved == 0 {
+		return repository.ErrAlreadyPaid
+	}
+
+	return nil
+}
+
+const (
+	GetAccountsByTypeQuery = `select userID, balance, tier, type from bank_accounts where type = ? order by userID`
+	// deposits have the same sender and receiver and are the only operation adding money from outside,
+	// amounts are positive, so outgoing ones are negated by the minus prefix
+	GetBalanceChangesQuery = `select createdAt,
+	case when operation = 'deposit' or receiverID = ?1 then amount else '-' || amount end
+	from transactions
+	where (receiverID = ?1 or senderID = ?1)
+	and createdAt >= ?2
+	order by createdAt desc`
+	NewInterestPayoutQuery = `insert into interest_payouts(accountID, period, amount, transactionID, createdAt)
+	values (?, ?, ?, ?, ?)
+	on conflict (accountID, period) do nothing`
+)
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+-- decimals are TEXT: DECIMAL columns have NUMERIC affinity and sqlite would store them as REAL.
+-- Decimals are always written in the canonical form, so a negative one starts with a minus.
+CREATE TABLE IF NOT EXISTS bank_accounts (
+    userID TEXT PRIMARY KEY,
+    balance TEXT NOT NULL CHECK (balance NOT LIKE '-%')
+);
+
+-- timestamps are TEXT in the fixed width UTC format, so they are ordered as strings
+CREATE TABLE IF NOT EXISTS transactions (
+    id TEXT PRIMARY KEY,
+    receiverID TEXT NOT NULL REFERENCES bank_accounts(userID),
+    senderID TEXT NOT NULL REFERENCES bank_accounts(userID),
+    operation VARCHAR(8) NOT NULL,
+    amount TEXT NOT NULL,
+    createdAt TEXT DEFAULT (strftime('%Y-%m-%dT%H:%M:%f000Z', 'now')) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS senderid_index ON transactions (senderID);
+
+CREATE INDEX IF NOT EXISTS receiverid_index ON transactions (receiverID);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS transactions;
+DROP TABLE IF EXISTS bank_accounts;
+-- +goose StatementEnd
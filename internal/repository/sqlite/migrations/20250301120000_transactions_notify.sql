@@ -0,0 +1,6 @@
+-- +goose Up
+-- sqlite has no notifications, committed transactions are published by the service
+SELECT 1;
+
+-- +goose Down
+SELECT 1;
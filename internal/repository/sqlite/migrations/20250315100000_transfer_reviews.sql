@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS transfer_reviews (
+    id TEXT PRIMARY KEY,
+    senderID TEXT NOT NULL REFERENCES bank_accounts(userID),
+    receiverID TEXT NOT NULL REFERENCES bank_accounts(userID),
+    amount TEXT NOT NULL,
+    rule TEXT NOT NULL,
+    reason TEXT NOT NULL,
+    status VARCHAR(8) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'approved', 'rejected')),
+    transactionID TEXT REFERENCES transactions(id),
+    createdAt TEXT DEFAULT (strftime('%Y-%m-%dT%H:%M:%f000Z', 'now')) NOT NULL,
+    decidedAt TEXT
+);
+
+CREATE INDEX IF NOT EXISTS transfer_reviews_status_index ON transfer_reviews (status, createdAt);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS transfer_reviews;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS blocked_parties (
+    id TEXT PRIMARY KEY,
+    reason TEXT NOT NULL DEFAULT '',
+    createdAt TEXT DEFAULT (strftime('%Y-%m-%dT%H:%M:%f000Z', 'now')) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS screening_decisions (
+    id TEXT PRIMARY KEY,
+    operation VARCHAR(16) NOT NULL,
+    partyID TEXT NOT NULL,
+    blocked BOOLEAN NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    requestID TEXT NOT NULL DEFAULT '',
+    createdAt TEXT DEFAULT (strftime('%Y-%m-%dT%H:%M:%f000Z', 'now')) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS screening_decisions_party_index ON screening_decisions (partyID, createdAt);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS screening_decisions;
+DROP TABLE IF EXISTS blocked_parties;
+-- +goose StatementEnd
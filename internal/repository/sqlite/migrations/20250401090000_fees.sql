@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE bank_accounts ADD COLUMN tier TEXT NOT NULL DEFAULT 'standard';
+
+ALTER TABLE transactions ADD COLUMN parentID TEXT REFERENCES transactions(id);
+
+CREATE INDEX IF NOT EXISTS transactions_parentid_index ON transactions (parentID) WHERE parentID IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS transactions_parentid_index;
+
+-- sqlite can't drop a column with a foreign key, the table is rebuilt without it
+CREATE TABLE transactions_without_parent (
+    id TEXT PRIMARY KEY,
+    receiverID TEXT NOT NULL REFERENCES bank_accounts(userID),
+    senderID TEXT NOT NULL REFERENCES bank_accounts(userID),
+    operation VARCHAR(8) NOT NULL,
+    amount TEXT NOT NULL,
+    createdAt TEXT DEFAULT (strftime('%Y-%m-%dT%H:%M:%f000Z', 'now')) NOT NULL
+);
+
+INSERT INTO transactions_without_parent (id, receiverID, senderID, operation, amount, createdAt)
+SELECT id, receiverID, senderID, operation, amount, createdAt FROM transactions;
+
+DROP TABLE transactions;
+
+ALTER TABLE transactions_without_parent RENAME TO transactions;
+
+CREATE INDEX IF NOT EXISTS senderid_index ON transactions (senderID);
+
+CREATE INDEX IF NOT EXISTS receiverid_index ON transactions (receiverID);
+
+ALTER TABLE bank_accounts DROP COLUMN tier;
+-- +goose StatementEnd
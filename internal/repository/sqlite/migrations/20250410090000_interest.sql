@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE bank_accounts ADD COLUMN type TEXT NOT NULL DEFAULT 'checking'
+    CHECK (type IN ('checking', 'savings'));
+
+CREATE INDEX IF NOT EXISTS bank_accounts_type_index ON bank_accounts (type);
+
+CREATE INDEX IF NOT EXISTS transactions_createdat_index ON transactions (createdAt);
+
+-- one payout per account and period makes payouts idempotent, periods are dates as YYYY-MM-DD
+CREATE TABLE IF NOT EXISTS interest_payouts (
+    accountID TEXT NOT NULL REFERENCES bank_accounts(userID),
+    period TEXT NOT NULL,
+    amount TEXT NOT NULL CHECK (amount NOT LIKE '-%' AND amount != '0'),
+    transactionID TEXT NOT NULL REFERENCES transactions(id),
+    createdAt TEXT DEFAULT (strftime('%Y-%m-%dT%H:%M:%f000Z', 'now')) NOT NULL,
+    PRIMARY KEY (accountID, period)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS interest_payouts;
+DROP INDEX IF EXISTS transactions_createdat_index;
+DROP INDEX IF EXISTS bank_accounts_type_index;
+ALTER TABLE bank_accounts DROP COLUMN type;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- sqlite has no partitions, only the indexes for history by time are mirrored
+DROP INDEX IF EXISTS senderid_index;
+DROP INDEX IF EXISTS receiverid_index;
+
+CREATE INDEX IF NOT EXISTS transactions_sender_createdat_index ON transactions (senderID, createdAt);
+
+CREATE INDEX IF NOT EXISTS transactions_receiver_createdat_index ON transactions (receiverID, createdAt);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS transactions_receiver_createdat_index;
+DROP INDEX IF EXISTS transactions_sender_createdat_index;
+
+CREATE INDEX IF NOT EXISTS senderid_index ON transactions (senderID);
+
+CREATE INDEX IF NOT EXISTS receiverid_index ON transactions (receiverID);
+-- +goose StatementEnd
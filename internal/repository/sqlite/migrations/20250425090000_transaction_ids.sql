@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- sqlite transactions aren't partitioned, their ids stay unique and referenced by foreign keys,
+-- the version only mirrors the postgres migrations
+SELECT 1;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 1;
+-- +goose StatementEnd
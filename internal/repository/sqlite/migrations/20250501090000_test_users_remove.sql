@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- test users are loaded by cmd/seed from configs/fixtures/dev.yml, so they don't reach production.
+-- Users with history are kept, their transactions reference them.
+DELETE FROM bank_accounts
+WHERE userID IN ('3fec06e9-29cc-4ff4-9ae7-fb0e7c757b61', '4178f61f-2ff9-4ab5-afa5-f30dc16e6ad9')
+    AND NOT EXISTS (SELECT 1 FROM transactions WHERE senderID = userID OR receiverID = userID)
+    AND NOT EXISTS (SELECT 1 FROM transfer_reviews WHERE senderID = userID OR receiverID = userID)
+    AND NOT EXISTS (SELECT 1 FROM interest_payouts WHERE accountID = userID);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+INSERT INTO bank_accounts (userID, balance)
+VALUES ('3fec06e9-29cc-4ff4-9ae7-fb0e7c757b61', '0'),
+    ('4178f61f-2ff9-4ab5-afa5-f30dc16e6ad9', '100')
+ON CONFLICT (userID) DO NOTHING;
+-- +goose StatementEnd
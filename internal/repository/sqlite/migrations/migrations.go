@@ -0,0 +1,8 @@
+// Package migrations is the sqlite schema. Every migration mirrors the postgres one
+// of the same version, so both stores report the schema version expected by the binary.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var Migrations embed.FS
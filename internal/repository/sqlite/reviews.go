@@ -0,0 +1,152 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/repository"
+	"github.com/aspirin100/finapi/internal/risk"
+)
+
+// TransferStats summarizes transfers of the sender made since the given time,
+// only to the receiver unless receiverID is uuid.Nil.
+func (r *Repository) TransferStats(ctx context.Context,
+	senderID, receiverID uuid.UUID,
+	since time.Time) (risk.Stats, error) {
+	var stats risk.Stats
+
+	err := r.checkTx(ctx).QueryRowContext(ctx, TransferStatsQuery, senderID, receiverID, timestamp(since)).
+		Scan(&stats.Count, &stats.Sum)
+	if err != nil {
+		return risk.Stats{}, fmt.Errorf("failed to read transfer stats: %w", err)
+	}
+
+	return stats, nil
+}
+
+func (r *Repository) CreateReview(ctx context.Context,
+	receiverID, senderID uuid.UUID,
+	amount decimal.Decimal,
+	rule, reason string) (*entity.Review, error) {
+	row := r.checkTx(ctx).QueryRowContext(ctx, NewReviewQuery,
+		uuid.New(), senderID, receiverID, amount, rule, reason, timestamp(r.now()))
+
+	review, err := scanReview(row)
+	if err != nil {
+		if errorCode(err) == sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY {
+			return nil, repository.ErrUserNotFound
+		}
+
+		return nil, err
+	}
+
+	return review, nil
+}
+
+// GetReview returns the review, the review is locked by the transaction as the whole database.
+func (r *Repository) GetReview(ctx context.Context, reviewID uuid.UUID) (*entity.Review, error) {
+	return scanReview(r.checkTx(ctx).QueryRowContext(ctx, GetReviewQuery, reviewID))
+}
+
+// GetReviews returns the oldest 100 reviews with the status.
+func (r *Repository) GetReviews(ctx context.Context, status string) ([]entity.Review, error) {
+	rows, err := r.checkTx(ctx).QueryContext(ctx, GetReviewsQuery, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reviews: %w", err)
+	}
+	defer rows.Close()
+
+	reviews := []entity.Review{}
+
+	for rows.Next() {
+		review, err := scanReview(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		reviews = append(reviews, *review)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read reviews: %w", err)
+	}
+
+	return reviews, nil
+}
+
+// DecideReview sets the status of a pending review, transactionID is the executed transfer of approved one.
+func (r *Repository) DecideReview(ctx context.Context,
+	reviewID uuid.UUID,
+	status string,
+	transactionID *uuid.UUID) (*entity.Review, error) {
+	return scanReview(r.checkTx(ctx).QueryRowContext(ctx, DecideReviewQuery,
+		reviewID, status, transactionID, timestamp(r.now())))
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanReview(row scanner) (*entity.Review, error) {
+	var (
+		review    entity.Review
+		createdAt timestamp
+		decidedAt nullTimestamp
+	)
+
+	err := row.Scan(
+		&review.ID,
+		&review.SenderID,
+		&review.ReceiverID,
+		&review.Amount,
+		&review.Rule,
+		&review.Reason,
+		&review.Status,
+		&review.TransactionID,
+		&createdAt,
+		&decidedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+
+		return nil, fmt.Errorf("failed to read review: %w", err)
+	}
+
+	review.CreatedAt = time.Time(createdAt)
+	review.DecidedAt = decidedAt.Time
+
+	return &review, nil
+}
+
+const (
+	TransferStatsQuery = `select count(*), coalesce(decimal_sum(amount), '0')
+	from transactions
+	where senderID = ?1
+	and operation = 'transfer'
+	and (?2 = '00000000-0000-0000-0000-000000000000' or receiverID = ?2)
+	and createdAt >= ?3`
+	reviewColumns  = `id, senderID, receiverID, amount, rule, reason, status, transactionID, createdAt, decidedAt`
+	NewReviewQuery = `insert into transfer_reviews(id, senderID, receiverID, amount, rule, reason, createdAt)
+	values (?, ?, ?, ?, ?, ?, ?)
+	returning ` + reviewColumns
+	GetReviewQuery  = `select ` + reviewColumns + ` from transfer_reviews where id = ?`
+	GetReviewsQuery = `select ` + reviewColumns + ` from transfer_reviews
+	where status = ?
+	order by createdAt
+	limit 100`
+	DecideReviewQuery = `update transfer_reviews
+	set status = ?2, transactionID = ?3, decidedAt = ?4
+	where id = ?1 and status = 'pending'
+	returning ` + reviewColumns
+)
@@ -0,0 +1,461 @@
+// Package sqlite is the repository on sqlite for single node and embedded deployments without postgres.
+// It follows the postgres repository: the same errors, constraints and transaction semantics.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pressly/goose/v3"
+	"github.com/shopspring/decimal"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/logger"
+	"github.com/aspirin100/finapi/internal/metrics"
+	"github.com/aspirin100/finapi/internal/repository"
+	"github.com/aspirin100/finapi/internal/repository/sqlite/migrations"
+	"github.com/aspirin100/finapi/internal/tracing"
+)
+
+// busyTimeout is how long a query waits for a lock held by another process.
+const busyTimeout = 5 * time.Second
+
+// Repository keeps the data in a sqlite database. Sqlite has a single writer, so the repository
+// has a single connection: db transactions are serialized instead of failing with SQLITE_BUSY
+// and operations outside of transactions wait for the running one.
+// So an operation outside of the transaction must not be called by the goroutine running one.
+type Repository struct {
+	DB *sql.DB
+
+	migrator *goose.Provider
+
+	mu      sync.Mutex
+	lastNow time.Time
+}
+
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Open opens the database file at path, ":memory:" keeps the database in memory,
+// and migrates it to the latest schema.
+func Open(ctx context.Context, path string) (*Repository, error) {
+	pragmas := url.Values{"_pragma": {
+		"foreign_keys(1)",
+		fmt.Sprintf("busy_timeout(%d)", busyTimeout.Milliseconds()),
+		"journal_mode(WAL)",
+	}}
+
+	db, err := sql.Open("sqlite", "file:"+path+"?"+pragmas.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database: %w", err)
+	}
+
+	db.SetMaxOpenConns(1)
+	// an in-memory database lives as long as its connection
+	db.SetConnMaxIdleTime(0)
+	db.SetConnMaxLifetime(0)
+
+	migrator, err := goose.NewProvider(goose.DialectSQLite3, db, migrations.Migrations)
+	if err != nil {
+		db.Close()
+
+		return nil, fmt.Errorf("failed to create migrations provider: %w", err)
+	}
+
+	_, err = migrator.Up(ctx)
+	if err != nil {
+		db.Close()
+
+		return nil, fmt.Errorf("failed to migrate sqlite database: %w", err)
+	}
+
+	return &Repository{
+		DB:       db,
+		migrator: migrator,
+	}, nil
+}
+
+// Ping checks that the database is reachable.
+func (r *Repository) Ping(ctx context.Context) error {
+	err := r.DB.PingContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to ping sqlite: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes the database.
+func (r *Repository) Close() {
+	r.DB.Close()
+}
+
+// WaitTx blocks until the db transaction in progress is committed or rolled back.
+func (r *Repository) WaitTx(ctx context.Context) error {
+	conn, err := r.DB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("db transaction is still in progress: %w", err)
+	}
+
+	return conn.Close() //nolint:wrapcheck
+}
+
+// SchemaVersion returns the latest applied migration version.
+func (r *Repository) SchemaVersion(ctx context.Context) (int64, error) {
+	version, err := r.migrator.GetDBVersion(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get schema version: %w", err)
+	}
+
+	return version, nil
+}
+
+type ctxKey struct{}
+
+var txContextKey = ctxKey{}
+
+func (r *Repository) BeginTx(ctx context.Context) (context.Context, repository.CommitOrRollback, error) {
+	ctx, span := tracing.Start(ctx, "db.transaction")
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		err = fmt.Errorf("failed to start transaction: %w", err)
+		tracing.End(span, err)
+
+		return nil, nil, err
+	}
+
+	log := logger.FromContext(ctx)
+	log.Debug("db transaction started")
+
+	return context.WithValue(ctx, txContextKey, tx), func(err error) (errTx error) {
+		defer func() {
+			tracing.End(span, errTx)
+		}()
+
+		if err != nil {
+			metrics.TxRollbacks.Inc()
+
+			errRollback := tx.Rollback()
+			if errRollback != nil {
+				return errors.Join(err, errRollback)
+			}
+
+			log.Debug("db transaction rolled back", slog.Any("reason", err))
+
+			return err
+		}
+
+		errCommit := tx.Commit()
+		if errCommit != nil {
+			metrics.TxCommitFailures.Inc()
+
+			log.Warn("failed to commit db transaction", slog.Any("error", errCommit))
+
+			if isConflict(errCommit) {
+				return repository.ErrTxConflict
+			}
+
+			return fmt.Errorf("failed to commit transaction: %w", errCommit)
+		}
+
+		log.Debug("db transaction committed")
+
+		return nil
+	}, nil
+}
+
+func (r *Repository) UpdateBalance(ctx context.Context,
+	userID uuid.UUID,
+	amount decimal.Decimal) (*decimal.Decimal, error) {
+	var currentBalance decimal.Decimal
+
+	err := r.checkTx(ctx).QueryRowContext(ctx, UpdateBalanceQuery, userID, amount).Scan(&currentBalance)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, repository.ErrUserNotFound
+		case errorCode(err) == sqlite3.SQLITE_CONSTRAINT_CHECK:
+			return nil, repository.ErrNegativeBalance
+		case isConflict(err):
+			return nil, repository.ErrTxConflict
+		default:
+			return nil, fmt.Errorf("update balance query fail: %w", err)
+		}
+	}
+
+	return &currentBalance, nil
+}
+
+func (r *Repository) SaveTransaction(ctx context.Context,
+	receiverID, senderID uuid.UUID,
+	amount decimal.Decimal,
+	operation string) (*entity.Transaction, error) {
+	return r.saveTransaction(ctx, receiverID, senderID, amount, operation, nil)
+}
+
+// SaveLinkedTransaction saves the transaction linked to the parent one, e.g. the fee of a transfer.
+func (r *Repository) SaveLinkedTransaction(ctx context.Context,
+	parentID, receiverID, senderID uuid.UUID,
+	amount decimal.Decimal,
+	operation string) (*entity.Transaction, error) {
+	return r.saveTransaction(ctx, receiverID, senderID, amount, operation, &parentID)
+}
+
+func (r *Repository) saveTransaction(ctx context.Context,
+	receiverID, senderID uuid.UUID,
+	amount decimal.Decimal,
+	operation string,
+	parentID *uuid.UUID) (*entity.Transaction, error) {
+	transaction := entity.Transaction{
+		ID:         uuid.New(),
+		ReceiverID: receiverID,
+		SenderID:   senderID,
+		Operation:  operation,
+		Amount:     amount,
+		ParentID:   parentID,
+		CreatedAt:  r.now(),
+	}
+
+	_, err := r.checkTx(ctx).ExecContext(ctx,
+		NewTransactionQuery,
+		transaction.ID,
+		receiverID,
+		senderID,
+		amount,
+		operation,
+		parentID,
+		timestamp(transaction.CreatedAt))
+	if err != nil {
+		if errorCode(err) == sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY {
+			return nil, repository.ErrUserNotFound
+		}
+
+		return nil, fmt.Errorf("failed to save transaction: %w", err)
+	}
+
+	return &transaction, nil
+}
+
+// CreateAccount opens the account with the id, ErrAccountExists is returned if the id is taken.
+func (r *Repository) CreateAccount(ctx context.Context, id uuid.UUID, accountType string) (*entity.Account, error) {
+	account := entity.Account{
+		ID:      id,
+		Balance: decimal.Zero,
+		Tier:    entity.TierStandard,
+		Type:    accountType,
+	}
+
+	_, err := r.checkTx(ctx).ExecContext(ctx, NewAccountQuery, account.ID, account.Balance, account.Tier, account.Type)
+	if err != nil {
+		if errorCode(err) == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY {
+			return nil, repository.ErrAccountExists
+		}
+
+		return nil, fmt.Errorf("create account query fail: %w", err)
+	}
+
+	return &account, nil
+}
+
+// UpdateAccountTier moves the account to the tier, ErrUserNotFound is returned if it doesn't exist.
+func (r *Repository) UpdateAccountTier(ctx context.Context, userID uuid.UUID, tier string) (*entity.Account, error) {
+	var account entity.Account
+
+	err := r.checkTx(ctx).QueryRowContext(ctx, UpdateAccountTierQuery, tier, userID).
+		Scan(&account.ID, &account.Balance, &account.Tier, &account.Type)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrUserNotFound
+		}
+
+		return nil, fmt.Errorf("failed to update account tier: %w", err)
+	}
+
+	return &account, nil
+}
+
+func (r *Repository) GetAccount(ctx context.Context, userID uuid.UUID) (*entity.Account, error) {
+	var account entity.Account
+
+	err := r.checkTx(ctx).QueryRowContext(ctx, GetAccountQuery, userID).
+		Scan(&account.ID, &account.Balance, &account.Tier, &account.Type)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrUserNotFound
+		}
+
+		return nil, fmt.Errorf("failed to read account: %w", err)
+	}
+
+	return &account, nil
+}
+
+func (r *Repository) GetTransaction(ctx context.Context,
+	transactionID uuid.UUID) (*entity.Transaction, error) {
+	rows, err := r.checkTx(ctx).QueryContext(ctx, GetTransactionQuery, transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+
+	transactions, err := scanTransactions(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(transactions) == 0 {
+		return nil, repository.ErrNotFound
+	}
+
+	return &transactions[0], nil
+}
+
+func (r *Repository) GetTransactions(ctx context.Context,
+	userID uuid.UUID) ([]entity.Transaction, error) {
+	rows, err := r.checkTx(ctx).QueryContext(ctx, GetTransactionsQuery, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users's transactions: %w", err)
+	}
+
+	return scanTransactions(rows)
+}
+
+// GetTransactionsAfter returns a page of up to TransactionsAfterLimit transactions of the user committed
+// after the transaction with afterID, oldest first. The page starts after the transaction with pageAfterID,
+// or at the beginning if it is uuid.Nil. It returns no transactions if afterID is unknown.
+func (r *Repository) GetTransactionsAfter(ctx context.Context,
+	userID, afterID, pageAfterID uuid.UUID) ([]entity.Transaction, error) {
+	var cursor any
+	if pageAfterID != uuid.Nil {
+		cursor = pageAfterID
+	}
+
+	rows, err := r.checkTx(ctx).QueryContext(ctx, GetTransactionsAfterQuery, userID, afterID, cursor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users's transactions: %w", err)
+	}
+
+	return scanTransactions(rows)
+}
+
+func scanTransactions(rows *sql.Rows) ([]entity.Transaction, error) {
+	defer rows.Close()
+
+	transactions := []entity.Transaction{}
+
+	for rows.Next() {
+		var (
+			transaction entity.Transaction
+			createdAt   timestamp
+		)
+
+		err := rows.Scan(
+			&transaction.ID,
+			&transaction.ReceiverID,
+			&transaction.SenderID,
+			&transaction.Amount,
+			&transaction.Operation,
+			&transaction.ParentID,
+			&createdAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scanning error: %w", err)
+		}
+
+		transaction.CreatedAt = time.Time(createdAt)
+		transactions = append(transactions, transaction)
+	}
+
+	err := rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("error during read transactions: %w", err)
+	}
+
+	return transactions, nil
+}
+
+func (r *Repository) checkTx(ctx context.Context) executor {
+	var ex executor = r.DB
+
+	// checks if current operation is in transaction
+	tx, ok := ctx.Value(txContextKey).(*sql.Tx)
+	if ok {
+		ex = tx
+	}
+
+	return ex
+}
+
+// now returns the current time in the precision of the stored timestamps. It never goes back,
+// so rows saved one after another are ordered by their timestamps.
+func (r *Repository) now() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	if !now.After(r.lastNow) {
+		now = r.lastNow.Add(time.Microsecond)
+	}
+
+	r.lastNow = now
+
+	return now
+}
+
+// errorCode returns the extended result code of the sqlite error, the counterpart of SQLSTATE.
+func errorCode(err error) int {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code()
+	}
+
+	return 0
+}
+
+// isConflict reports whether err is caused by a lock of another process
+// and the whole transaction can be safely retried.
+func isConflict(err error) bool {
+	code := errorCode(err)
+
+	return code&0xff == sqlite3.SQLITE_BUSY || code&0xff == sqlite3.SQLITE_LOCKED
+}
+
+const (
+	UpdateBalanceQuery  = `update bank_accounts set balance = decimal_add(balance, ?2) where userID = ?1 returning balance`
+	NewTransactionQuery = `insert into transactions(id, receiverID, senderID, amount, operation, parentID, createdAt)
+	values (?, ?, ?, ?, ?, ?, ?)`
+	NewAccountQuery        = `insert into bank_accounts(userID, balance, tier, type) values (?, ?, ?, ?)`
+	GetAccountQuery        = `select userID, balance, tier, type from bank_accounts where userID = ?`
+	UpdateAccountTierQuery = `update bank_accounts set tier = ? where userID = ?
+	returning userID, balance, tier, type`
+	GetTransactionQuery = `select
+	id, receiverID, senderID, amount, operation, parentID, createdAt
+	from transactions
+	where id = ?`
+	GetTransactionsQuery = `select
+	id, receiverID, senderID, amount, operation, parentID, createdAt
+	from transactions
+	where receiverID = ?1 OR senderID = ?1
+	order by createdAt
+	limit 10`
+	GetTransactionsAfterQuery = `select
+	id, receiverID, senderID, amount, operation, parentID, createdAt
+	from transactions
+	where (receiverID = ?1 OR senderID = ?1)
+	and (createdAt, id) > (select createdAt, id from transactions where id = ?2)
+	and (?3 is null or (createdAt, id) > (select createdAt, id from transactions where id = ?3))
+	order by createdAt, id
+	limit 100`
+)
@@ -0,0 +1,92 @@
+package sqlite_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/repository/migrations"
+	"github.com/aspirin100/finapi/internal/repository/repotest"
+	"github.com/aspirin100/finapi/internal/repository/sqlite"
+)
+
+func TestConformance(t *testing.T) {
+	repo, err := sqlite.Open(context.Background(), filepath.Join(t.TempDir(), "finapi.db"))
+	require.NoError(t, err)
+
+	defer repo.Close()
+
+	repotest.Run(t, repo)
+}
+
+func TestSchemaVersion(t *testing.T) {
+	ctx := context.Background()
+
+	repo, err := sqlite.Open(ctx, ":memory:")
+	require.NoError(t, err)
+
+	defer repo.Close()
+
+	expected, err := migrations.Version()
+	require.NoError(t, err)
+
+	version, err := repo.SchemaVersion(ctx)
+	require.NoError(t, err)
+	require.Equal(t, expected, version, "sqlite migrations mirror the postgres ones")
+}
+
+func TestDecimals(t *testing.T) {
+	ctx := context.Background()
+
+	repo, err := sqlite.Open(ctx, ":memory:")
+	require.NoError(t, err)
+
+	defer repo.Close()
+
+	account, err := repo.CreateAccount(ctx, uuid.New(), entity.AccountChecking)
+	require.NoError(t, err)
+
+	cases := []struct {
+		Name            string
+		Amount          string
+		ExpectedBalance string
+	}{
+		{
+			Name:            "not representable as float",
+			Amount:          "0.1",
+			ExpectedBalance: "0.1",
+		},
+		{
+			Name:            "sum of floats",
+			Amount:          "0.2",
+			ExpectedBalance: "0.3",
+		},
+		{
+			Name:            "beyond float precision",
+			Amount:          "12345678901234567890.000000000000000001",
+			ExpectedBalance: "12345678901234567890.300000000000000001",
+		},
+		{
+			Name:            "down to zero",
+			Amount:          "-12345678901234567890.300000000000000001",
+			ExpectedBalance: "0",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			balance, err := repo.UpdateBalance(ctx, account.ID, decimal.RequireFromString(tc.Amount))
+			require.NoError(t, err)
+			require.Equal(t, tc.ExpectedBalance, balance.String())
+
+			got, err := repo.GetAccount(ctx, account.ID)
+			require.NoError(t, err)
+			require.Equal(t, tc.ExpectedBalance, got.Balance.String())
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package sqlite
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"modernc.org/sqlite"
+)
+
+// timestampLayout is fixed width UTC with microseconds like postgres timestamps,
+// so timestamps stored as TEXT are ordered as strings.
+const timestampLayout = "2006-01-02T15:04:05.000000Z"
+
+// dateLayout is the layout of DATE columns.
+const dateLayout = time.DateOnly
+
+var errUnexpectedType = errors.New("unexpected type")
+
+// timestamp stores time.Time as TEXT in timestampLayout.
+type timestamp time.Time
+
+func (t timestamp) Value() (driver.Value, error) {
+	return time.Time(t).UTC().Format(timestampLayout), nil
+}
+
+func (t *timestamp) Scan(src any) error {
+	var text string
+
+	switch src := src.(type) {
+	case string:
+		text = src
+	case []byte:
+		text = string(src)
+	default:
+		return fmt.Errorf("%w of timestamp: %T", errUnexpectedType, src)
+	}
+
+	parsed, err := time.Parse(timestampLayout, text)
+	if err != nil {
+		return fmt.Errorf("failed to parse timestamp: %w", err)
+	}
+
+	*t = timestamp(parsed)
+
+	return nil
+}
+
+// nullTimestamp is a timestamp that may be NULL.
+type nullTimestamp struct {
+	Time  *time.Time
+	Valid bool
+}
+
+func (t *nullTimestamp) Scan(src any) error {
+	if src == nil {
+		t.Time, t.Valid = nil, false
+
+		return nil
+	}
+
+	var ts timestamp
+
+	err := ts.Scan(src)
+	if err != nil {
+		return err
+	}
+
+	value := time.Time(ts)
+	t.Time, t.Valid = &value, true
+
+	return nil
+}
+
+// Decimals are stored as TEXT in the canonical form of decimal.Decimal.String and all the arithmetic on them
+// is done by these functions, so amounts never pass through floats.
+func init() {
+	sqlite.MustRegisterDeterministicScalarFunction("decimal_add", 2, //nolint:mnd
+		func(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+			a, err := decimalArg(args[0])
+			if err != nil {
+				return nil, err
+			}
+
+			b, err := decimalArg(args[1])
+			if err != nil {
+				return nil, err
+			}
+
+			return a.Add(b).String(), nil
+		})
+
+	sqlite.MustRegisterFunction("decimal_sum", &sqlite.FunctionImpl{
+		NArgs:         1,
+		Deterministic: true,
+		MakeAggregate: func(_ sqlite.FunctionContext) (sqlite.AggregateFunction, error) {
+			return &decimalSum{sum: decimal.Zero}, nil
+		},
+	})
+}
+
+func decimalArg(arg driver.Value) (decimal.Decimal, error) {
+	switch arg := arg.(type) {
+	case string:
+		return decimal.NewFromString(arg) //nolint:wrapcheck
+	case []byte:
+		return decimal.NewFromString(string(arg)) //nolint:wrapcheck
+	case int64:
+		return decimal.NewFromInt(arg), nil
+	default:
+		return decimal.Decimal{}, fmt.Errorf("%w of decimal: %T", errUnexpectedType, arg)
+	}
+}
+
+// decimalSum is the sum aggregate of decimals, NULLs are skipped like by sum.
+type decimalSum struct {
+	sum decimal.Decimal
+}
+
+func (s *decimalSum) Step(_ *sqlite.FunctionContext, args []driver.Value) error {
+	if args[0] == nil {
+		return nil
+	}
+
+	value, err := decimalArg(args[0])
+	if err != nil {
+		return err
+	}
+
+	s.sum = s.sum.Add(value)
+
+	return nil
+}
+
+func (s *decimalSum) WindowInverse(_ *sqlite.FunctionContext, args []driver.Value) error {
+	if args[0] == nil {
+		return nil
+	}
+
+	value, err := decimalArg(args[0])
+	if err != nil {
+		return err
+	}
+
+	s.sum = s.sum.Sub(value)
+
+	return nil
+}
+
+func (s *decimalSum) WindowValue(_ *sqlite.FunctionContext) (driver.Value, error) {
+	return s.sum.String(), nil
+}
+
+func (s *decimalSum) Final(_ *sqlite.FunctionContext) {}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/aspirin100/finapi/internal/breaker"
+	"github.com/aspirin100/finapi/internal/tracing"
+)
+
+// queryTracer creates a span for every query executed through pgx
+// and reports queries and connection acquiring to the circuit breaker.
+type queryTracer struct {
+	breaker *breaker.Breaker
+}
+
+func (queryTracer) TraceQueryStart(ctx context.Context,
+	_ *pgx.Conn,
+	data pgx.TraceQueryStartData) context.Context {
+	ctx, _ = tracing.Start(ctx, "pgx.query",
+		semconv.DBSystemPostgreSQL,
+		semconv.DBQueryText(data.SQL),
+		attribute.Int("db.query.args", len(data.Args)),
+	)
+
+	return ctx
+}
+
+func (t queryTracer) TraceQueryEnd(ctx context.Context,
+	_ *pgx.Conn,
+	data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+
+	tracing.End(span, data.Err)
+
+	reportRoundTrip(t.breaker, data.Err)
+}
+
+func (queryTracer) TraceAcquireStart(ctx context.Context,
+	_ *pgxpool.Pool,
+	_ pgxpool.TraceAcquireStartData) context.Context {
+	return ctx
+}
+
+// TraceAcquireEnd reports only failures, an idle connection is acquired without a round trip.
+func (t queryTracer) TraceAcquireEnd(_ context.Context,
+	_ *pgxpool.Pool,
+	data pgxpool.TraceAcquireEndData) {
+	if data.Err != nil {
+		reportRoundTrip(t.breaker, data.Err)
+	}
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// txTracker counts db transactions in progress, so shutdown can wait for them.
+type txTracker struct {
+	mu     sync.Mutex
+	active int
+	idle   chan struct{}
+}
+
+func (t *txTracker) begin() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.active++
+}
+
+func (t *txTracker) end() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.active--
+
+	if t.active == 0 && t.idle != nil {
+		close(t.idle)
+		t.idle = nil
+	}
+}
+
+func (t *txTracker) wait(ctx context.Context) error {
+	t.mu.Lock()
+
+	if t.active == 0 {
+		t.mu.Unlock()
+
+		return nil
+	}
+
+	if t.idle == nil {
+		t.idle = make(chan struct{})
+	}
+
+	idle := t.idle
+	active := t.active
+
+	t.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%d db transactions are still in progress: %w", active, ctx.Err())
+	}
+}
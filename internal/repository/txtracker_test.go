@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxTrackerWait(t *testing.T) {
+	var tracker txTracker
+
+	require.NoError(t, tracker.wait(context.Background()))
+
+	tracker.begin()
+	tracker.begin()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	require.ErrorIs(t, tracker.wait(ctx), context.DeadlineExceeded)
+
+	done := make(chan error)
+
+	go func() {
+		done <- tracker.wait(context.Background())
+	}()
+
+	tracker.end()
+
+	select {
+	case <-done:
+		t.Fatal("wait returned while transaction is still in progress")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	tracker.end()
+
+	require.NoError(t, <-done)
+}
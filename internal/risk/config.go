@@ -0,0 +1,130 @@
+package risk
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gopkg.in/yaml.v3"
+)
+
+// Types of rules in the rule set file.
+const (
+	RuleVelocity    = "velocity"
+	RuleNewReceiver = "new_receiver"
+	RuleRoundTrip   = "round_trip"
+)
+
+var (
+	ErrUnknownRule = errors.New("unknown risk rule type")
+	ErrInvalidRule = errors.New("invalid risk rule")
+)
+
+// ruleConfig is a rule of the rule set file, fields are used depending on the rule type.
+type ruleConfig struct {
+	Name      string          `yaml:"name"`
+	Type      string          `yaml:"type"`
+	Action    string          `yaml:"action"`
+	Window    time.Duration   `yaml:"window"`
+	MaxCount  int             `yaml:"maxCount"`
+	MaxAmount decimal.Decimal `yaml:"maxAmount"`
+	Threshold decimal.Decimal `yaml:"threshold"`
+	MinRatio  decimal.Decimal `yaml:"minRatio"`
+}
+
+type ruleSetConfig struct {
+	Rules []ruleConfig `yaml:"rules"`
+}
+
+// LoadRules reads the rule set from a YAML or JSON file.
+func LoadRules(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read risk rules: %w", err)
+	}
+
+	return ParseRules(data)
+}
+
+// ParseRules parses the rule set in YAML or JSON, e.g.
+//
+//	rules:
+//	  - name: transfers burst
+//	    type: velocity
+//	    action: review
+//	    window: 1h
+//	    maxCount: 10
+//	    maxAmount: "10000"
+func ParseRules(data []byte) ([]Rule, error) {
+	var cfg ruleSetConfig
+
+	err := yaml.Unmarshal(data, &cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse risk rules: %w", err)
+	}
+
+	rules := make([]Rule, 0, len(cfg.Rules))
+
+	for i, ruleCfg := range cfg.Rules {
+		rule, err := ruleCfg.build()
+		if err != nil {
+			return nil, fmt.Errorf("rule #%d: %w", i+1, err)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+func (c ruleConfig) build() (Rule, error) {
+	action, err := parseDecision(c.Action)
+	if err != nil {
+		return nil, err
+	}
+
+	name := c.Name
+	if name == "" {
+		name = c.Type
+	}
+
+	switch c.Type {
+	case RuleVelocity:
+		if c.Window <= 0 || (c.MaxCount <= 0 && !c.MaxAmount.IsPositive()) {
+			return nil, fmt.Errorf("%w: velocity requires window and maxCount or maxAmount", ErrInvalidRule)
+		}
+
+		return &VelocityRule{
+			RuleName:  name,
+			Action:    action,
+			Window:    c.Window,
+			MaxCount:  c.MaxCount,
+			MaxAmount: c.MaxAmount,
+		}, nil
+	case RuleNewReceiver:
+		if c.Threshold.IsNegative() {
+			return nil, fmt.Errorf("%w: new_receiver threshold must not be negative", ErrInvalidRule)
+		}
+
+		return &NewReceiverRule{
+			RuleName:  name,
+			Action:    action,
+			Threshold: c.Threshold,
+		}, nil
+	case RuleRoundTrip:
+		if c.Window <= 0 || c.MinRatio.IsNegative() {
+			return nil, fmt.Errorf("%w: round_trip requires window and not negative minRatio", ErrInvalidRule)
+		}
+
+		return &RoundTripRule{
+			RuleName: name,
+			Action:   action,
+			Window:   c.Window,
+			MinRatio: c.MinRatio,
+		}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownRule, c.Type)
+	}
+}
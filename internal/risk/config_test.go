@@ -0,0 +1,16 @@
+package risk_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/aspirin100/finapi/internal/risk"
+)
+
+func TestLoadExampleRules(t *testing.T) {
+	rules, err := risk.LoadRules("../../configs/risk_rules.example.yml")
+	require.NoError(t, err)
+	require.Len(t, rules, 3)
+	require.Equal(t, "transfers burst", rules[0].Name())
+}
@@ -0,0 +1,128 @@
+// Package risk evaluates transfers against fraud and risk rules before money moves.
+package risk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/aspirin100/finapi/internal/metrics"
+)
+
+// Decision is the outcome of transfer evaluation, ordered from the mildest to the strictest.
+type Decision string
+
+const (
+	DecisionAllow  Decision = "allow"
+	DecisionReview Decision = "review"
+	DecisionDeny   Decision = "deny"
+)
+
+var ErrUnknownDecision = errors.New("unknown risk decision")
+
+func (d Decision) severity() int {
+	switch d {
+	case DecisionDeny:
+		return 2 //nolint:mnd
+	case DecisionReview:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func parseDecision(raw string) (Decision, error) {
+	switch decision := Decision(raw); decision {
+	case DecisionAllow, DecisionReview, DecisionDeny:
+		return decision, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnknownDecision, raw)
+	}
+}
+
+// Transfer is the evaluated transfer.
+type Transfer struct {
+	SenderID   uuid.UUID
+	ReceiverID uuid.UUID
+	Amount     decimal.Decimal
+	At         time.Time
+}
+
+// Stats summarizes committed transfers.
+type Stats struct {
+	Count int
+	Sum   decimal.Decimal
+}
+
+// History provides transfers committed before the evaluated one.
+type History interface {
+	// TransferStats summarizes transfers of the sender made since the given time,
+	// only to the receiver unless receiverID is uuid.Nil.
+	TransferStats(ctx context.Context, senderID, receiverID uuid.UUID, since time.Time) (Stats, error)
+}
+
+// Verdict is the decision about the transfer and the rule which made it.
+// Rule and Reason are empty if the transfer is allowed.
+type Verdict struct {
+	Decision Decision
+	Rule     string
+	Reason   string
+}
+
+// Rule checks the transfer and returns the verdict of the rule.
+type Rule interface {
+	Name() string
+	Evaluate(ctx context.Context, history History, transfer Transfer) (Verdict, error)
+}
+
+type Engine struct {
+	rules   []Rule
+	history History
+	now     func() time.Time
+}
+
+func NewEngine(rules []Rule, history History) *Engine {
+	return &Engine{
+		rules:   rules,
+		history: history,
+		now:     time.Now,
+	}
+}
+
+// Evaluate returns the strictest verdict of all rules. Rules are evaluated in order
+// until one of them denies the transfer.
+func (e *Engine) Evaluate(ctx context.Context, senderID, receiverID uuid.UUID, amount decimal.Decimal) (Verdict, error) {
+	transfer := Transfer{
+		SenderID:   senderID,
+		ReceiverID: receiverID,
+		Amount:     amount,
+		At:         e.now(),
+	}
+
+	strictest := Verdict{Decision: DecisionAllow}
+
+	for _, rule := range e.rules {
+		verdict, err := rule.Evaluate(ctx, e.history, transfer)
+		if err != nil {
+			return Verdict{}, fmt.Errorf("failed to evaluate rule %s: %w", rule.Name(), err)
+		}
+
+		if verdict.Decision != DecisionAllow {
+			metrics.RiskRuleHits.WithLabelValues(rule.Name(), string(verdict.Decision)).Inc()
+		}
+
+		if verdict.Decision.severity() > strictest.Decision.severity() {
+			strictest = verdict
+		}
+
+		if strictest.Decision == DecisionDeny {
+			break
+		}
+	}
+
+	return strictest, nil
+}
@@ -0,0 +1,196 @@
+package risk
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+type pastTransfer struct {
+	senderID   uuid.UUID
+	receiverID uuid.UUID
+	amount     decimal.Decimal
+	at         time.Time
+}
+
+type fakeHistory []pastTransfer
+
+func (h fakeHistory) TransferStats(_ context.Context,
+	senderID, receiverID uuid.UUID,
+	since time.Time) (Stats, error) {
+	stats := Stats{Sum: decimal.Zero}
+
+	for _, transfer := range h {
+		if transfer.senderID != senderID || transfer.at.Before(since) {
+			continue
+		}
+
+		if receiverID != uuid.Nil && transfer.receiverID != receiverID {
+			continue
+		}
+
+		stats.Count++
+		stats.Sum = stats.Sum.Add(transfer.amount)
+	}
+
+	return stats, nil
+}
+
+const testRules = `
+rules:
+  - name: burst
+    type: velocity
+    action: review
+    window: 1h
+    maxCount: 3
+    maxAmount: 1000
+  - type: new_receiver
+    action: review
+    threshold: "500"
+  - type: round_trip
+    action: deny
+    window: 24h
+    minRatio: 0.9
+`
+
+func TestEngine(t *testing.T) {
+	rules, err := ParseRules([]byte(testRules))
+	require.NoError(t, err)
+	require.Len(t, rules, 3)
+
+	now := time.Now()
+
+	alice, bob, carol := uuid.New(), uuid.New(), uuid.New()
+
+	history := fakeHistory{
+		{senderID: alice, receiverID: bob, amount: decimal.NewFromInt(100), at: now.Add(-time.Minute)},
+		{senderID: alice, receiverID: bob, amount: decimal.NewFromInt(100), at: now.Add(-2 * time.Hour)},
+		{senderID: bob, receiverID: carol, amount: decimal.NewFromInt(300), at: now.Add(-time.Hour)},
+		{senderID: carol, receiverID: alice, amount: decimal.NewFromInt(50), at: now.Add(-time.Minute)},
+		{senderID: carol, receiverID: alice, amount: decimal.NewFromInt(50), at: now.Add(-time.Minute)},
+	}
+
+	engine := NewEngine(rules, history)
+	engine.now = func() time.Time { return now }
+
+	cases := []struct {
+		Name             string
+		SenderID         uuid.UUID
+		ReceiverID       uuid.UUID
+		Amount           int64
+		ExpectedDecision Decision
+		ExpectedRule     string
+	}{
+		{
+			Name:             "known receiver",
+			SenderID:         alice,
+			ReceiverID:       bob,
+			Amount:           600,
+			ExpectedDecision: DecisionAllow,
+		},
+		{
+			Name:             "amount velocity exceeded",
+			SenderID:         alice,
+			ReceiverID:       bob,
+			Amount:           901,
+			ExpectedDecision: DecisionReview,
+			ExpectedRule:     "burst",
+		},
+		{
+			Name:             "new receiver below threshold",
+			SenderID:         bob,
+			ReceiverID:       alice,
+			Amount:           500,
+			ExpectedDecision: DecisionAllow,
+		},
+		{
+			Name:             "new receiver above threshold",
+			SenderID:         bob,
+			ReceiverID:       alice,
+			Amount:           501,
+			ExpectedDecision: DecisionReview,
+			ExpectedRule:     RuleNewReceiver,
+		},
+		{
+			Name:             "round trip",
+			SenderID:         alice,
+			ReceiverID:       carol,
+			Amount:           100,
+			ExpectedDecision: DecisionDeny,
+			ExpectedRule:     RuleRoundTrip,
+		},
+		{
+			Name:             "sent back less than ratio",
+			SenderID:         alice,
+			ReceiverID:       carol,
+			Amount:           112,
+			ExpectedDecision: DecisionAllow,
+		},
+	}
+
+	for _, tcase := range cases {
+		t.Run(tcase.Name, func(t *testing.T) {
+			verdict, err := engine.Evaluate(context.Background(),
+				tcase.SenderID, tcase.ReceiverID, decimal.NewFromInt(tcase.Amount))
+			require.NoError(t, err)
+			require.Equal(t, tcase.ExpectedDecision, verdict.Decision)
+			require.Equal(t, tcase.ExpectedRule, verdict.Rule)
+		})
+	}
+
+	history = append(history,
+		pastTransfer{senderID: alice, receiverID: bob, amount: decimal.NewFromInt(1), at: now},
+		pastTransfer{senderID: alice, receiverID: bob, amount: decimal.NewFromInt(1), at: now})
+	engine.history = history
+
+	verdict, err := engine.Evaluate(context.Background(), alice, bob, decimal.NewFromInt(1))
+	require.NoError(t, err)
+	require.Equal(t, Verdict{
+		Decision: DecisionReview,
+		Rule:     "burst",
+		Reason:   "more than 3 transfers within 1h0m0s",
+	}, verdict)
+}
+
+func TestParseRules(t *testing.T) {
+	cases := []struct {
+		Name        string
+		Data        string
+		ExpectedErr error
+	}{
+		{
+			Name: "json",
+			Data: `{"rules": [{"type": "velocity", "action": "deny", "window": "10m", "maxCount": 5}]}`,
+		},
+		{
+			Name: "no rules",
+			Data: `rules: []`,
+		},
+		{
+			Name:        "unknown type",
+			Data:        `{"rules": [{"type": "geo", "action": "deny"}]}`,
+			ExpectedErr: ErrUnknownRule,
+		},
+		{
+			Name:        "unknown action",
+			Data:        `{"rules": [{"type": "new_receiver", "action": "block"}]}`,
+			ExpectedErr: ErrUnknownDecision,
+		},
+		{
+			Name:        "velocity without limits",
+			Data:        `{"rules": [{"type": "velocity", "action": "deny", "window": "10m"}]}`,
+			ExpectedErr: ErrInvalidRule,
+		},
+	}
+
+	for _, tcase := range cases {
+		t.Run(tcase.Name, func(t *testing.T) {
+			_, err := ParseRules([]byte(tcase.Data))
+			require.ErrorIs(t, err, tcase.ExpectedErr)
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// VelocityRule triggers when the sender exceeds MaxCount transfers or MaxAmount
+// moved within Window, the evaluated transfer included. Zero limits are not checked.
+type VelocityRule struct {
+	RuleName  string
+	Action    Decision
+	Window    time.Duration
+	MaxCount  int
+	MaxAmount decimal.Decimal
+}
+
+func (r *VelocityRule) Name() string {
+	return r.RuleName
+}
+
+func (r *VelocityRule) Evaluate(ctx context.Context, history History, transfer Transfer) (Verdict, error) {
+	stats, err := history.TransferStats(ctx, transfer.SenderID, uuid.Nil, transfer.At.Add(-r.Window))
+	if err != nil {
+		return Verdict{}, err //nolint:wrapcheck
+	}
+
+	if r.MaxCount > 0 && stats.Count+1 > r.MaxCount {
+		return r.verdict(fmt.Sprintf("more than %d transfers within %s", r.MaxCount, r.Window)), nil
+	}
+
+	if r.MaxAmount.IsPositive() && stats.Sum.Add(transfer.Amount).GreaterThan(r.MaxAmount) {
+		return r.verdict(fmt.Sprintf("more than %s transferred within %s", r.MaxAmount, r.Window)), nil
+	}
+
+	return Verdict{Decision: DecisionAllow}, nil
+}
+
+func (r *VelocityRule) verdict(reason string) Verdict {
+	return Verdict{Decision: r.Action, Rule: r.RuleName, Reason: reason}
+}
+
+// NewReceiverRule triggers on the first transfer from the sender to the receiver
+// with amount above Threshold.
+type NewReceiverRule struct {
+	RuleName  string
+	Action    Decision
+	Threshold decimal.Decimal
+}
+
+func (r *NewReceiverRule) Name() string {
+	return r.RuleName
+}
+
+func (r *NewReceiverRule) Evaluate(ctx context.Context, history History, transfer Transfer) (Verdict, error) {
+	if !transfer.Amount.GreaterThan(r.Threshold) {
+		return Verdict{Decision: DecisionAllow}, nil
+	}
+
+	stats, err := history.TransferStats(ctx, transfer.SenderID, transfer.ReceiverID, time.Time{})
+	if err != nil {
+		return Verdict{}, err //nolint:wrapcheck
+	}
+
+	if stats.Count > 0 {
+		return Verdict{Decision: DecisionAllow}, nil
+	}
+
+	return Verdict{
+		Decision: r.Action,
+		Rule:     r.RuleName,
+		Reason:   fmt.Sprintf("first transfer to the receiver above %s", r.Threshold),
+	}, nil
+}
+
+// RoundTripRule triggers when the receiver has sent money back to the sender within Window,
+// at least MinRatio of the evaluated amount in total.
+type RoundTripRule struct {
+	RuleName string
+	Action   Decision
+	Window   time.Duration
+	MinRatio decimal.Decimal
+}
+
+func (r *RoundTripRule) Name() string {
+	return r.RuleName
+}
+
+func (r *RoundTripRule) Evaluate(ctx context.Context, history History, transfer Transfer) (Verdict, error) {
+	stats, err := history.TransferStats(ctx, transfer.ReceiverID, transfer.SenderID, transfer.At.Add(-r.Window))
+	if err != nil {
+		return Verdict{}, err //nolint:wrapcheck
+	}
+
+	if stats.Count == 0 || stats.Sum.LessThan(transfer.Amount.Mul(r.MinRatio)) {
+		return Verdict{Decision: DecisionAllow}, nil
+	}
+
+	return Verdict{
+		Decision: r.Action,
+		Rule:     r.RuleName,
+		Reason:   fmt.Sprintf("receiver transferred %s back within %s", stats.Sum, r.Window),
+	}, nil
+}
@@ -0,0 +1,126 @@
+package screening
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/aspirin100/finapi/internal/entity"
+)
+
+// Formats of blocklist files.
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+var (
+	ErrUnknownFormat = errors.New("unknown blocklist format")
+	ErrInvalidEntry  = errors.New("invalid blocklist entry")
+)
+
+// ReadFile reads the blocklist file, format is detected by the extension if empty.
+func ReadFile(path, format string) ([]entity.BlockedParty, error) {
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open blocklist: %w", err)
+	}
+	defer file.Close()
+
+	switch format {
+	case FormatCSV:
+		return ParseCSV(file)
+	case FormatJSON:
+		return ParseJSON(file)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+}
+
+// ParseCSV parses the blocklist with "id,reason" header, reason column is optional.
+func ParseCSV(r io.Reader) ([]entity.BlockedParty, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blocklist header: %w", err)
+	}
+
+	idColumn, reasonColumn := -1, -1
+
+	for i, column := range header {
+		switch strings.ToLower(strings.TrimSpace(column)) {
+		case "id":
+			idColumn = i
+		case "reason":
+			reasonColumn = i
+		}
+	}
+
+	if idColumn < 0 {
+		return nil, fmt.Errorf("%w: no id column", ErrInvalidEntry)
+	}
+
+	var parties []entity.BlockedParty
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to read blocklist: %w", err)
+		}
+
+		if idColumn >= len(record) {
+			return nil, fmt.Errorf("%w: line %d: no id", ErrInvalidEntry, line)
+		}
+
+		party := entity.BlockedParty{}
+
+		party.ID, err = uuid.Parse(record[idColumn])
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %w", ErrInvalidEntry, line, err)
+		}
+
+		if reasonColumn >= 0 && reasonColumn < len(record) {
+			party.Reason = record[reasonColumn]
+		}
+
+		parties = append(parties, party)
+	}
+
+	return parties, nil
+}
+
+// ParseJSON parses the blocklist of [{"id": "...", "reason": "..."}] form.
+func ParseJSON(r io.Reader) ([]entity.BlockedParty, error) {
+	var parties []entity.BlockedParty
+
+	err := json.NewDecoder(r).Decode(&parties)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse blocklist: %w", err)
+	}
+
+	for i, party := range parties {
+		if party.ID == uuid.Nil {
+			return nil, fmt.Errorf("%w: entry %d: no id", ErrInvalidEntry, i+1)
+		}
+	}
+
+	return parties, nil
+}
@@ -0,0 +1,76 @@
+// Package screening checks parties of money operations against the blocklist.
+package screening
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/logger"
+)
+
+// Source provides the blocklist.
+type Source interface {
+	GetBlockedParties(ctx context.Context) ([]entity.BlockedParty, error)
+}
+
+// Screener keeps a local copy of the blocklist, so screening doesn't query the db.
+type Screener struct {
+	source  Source
+	blocked atomic.Pointer[map[uuid.UUID]entity.BlockedParty]
+}
+
+func New(source Source) *Screener {
+	screener := &Screener{source: source}
+	screener.blocked.Store(&map[uuid.UUID]entity.BlockedParty{})
+
+	return screener
+}
+
+// Reload replaces the local copy with the current blocklist.
+func (s *Screener) Reload(ctx context.Context) error {
+	parties, err := s.source.GetBlockedParties(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load blocklist: %w", err)
+	}
+
+	blocked := make(map[uuid.UUID]entity.BlockedParty, len(parties))
+	for _, party := range parties {
+		blocked[party.ID] = party
+	}
+
+	s.blocked.Store(&blocked)
+
+	return nil
+}
+
+// RunReload reloads the blocklist every interval until ctx is done. The previous copy
+// is kept if reload fails.
+func (s *Screener) RunReload(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := s.Reload(ctx)
+			if err != nil && ctx.Err() == nil {
+				logger.FromContext(ctx).Error("blocklist reload failed", slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// Screen returns the blocklist entry of the party, if it is blocked.
+func (s *Screener) Screen(partyID uuid.UUID) (entity.BlockedParty, bool) {
+	party, blocked := (*s.blocked.Load())[partyID]
+
+	return party, blocked
+}
@@ -0,0 +1,109 @@
+package screening_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/screening"
+)
+
+type fakeSource struct {
+	parties []entity.BlockedParty
+	err     error
+}
+
+func (s *fakeSource) GetBlockedParties(_ context.Context) ([]entity.BlockedParty, error) {
+	return s.parties, s.err
+}
+
+func TestScreener(t *testing.T) {
+	blockedID := uuid.New()
+
+	source := &fakeSource{parties: []entity.BlockedParty{{ID: blockedID, Reason: "sanctions"}}}
+	screener := screening.New(source)
+
+	_, blocked := screener.Screen(blockedID)
+	require.False(t, blocked, "nothing is blocked before load")
+
+	require.NoError(t, screener.Reload(context.Background()))
+
+	party, blocked := screener.Screen(blockedID)
+	require.True(t, blocked)
+	require.Equal(t, "sanctions", party.Reason)
+
+	_, blocked = screener.Screen(uuid.New())
+	require.False(t, blocked)
+
+	source.err = errors.New("db is down")
+	require.Error(t, screener.Reload(context.Background()))
+
+	_, blocked = screener.Screen(blockedID)
+	require.True(t, blocked, "previous blocklist is kept")
+
+	source.err = nil
+	source.parties = nil
+	require.NoError(t, screener.Reload(context.Background()))
+
+	_, blocked = screener.Screen(blockedID)
+	require.False(t, blocked, "unblocked")
+}
+
+func TestParseCSV(t *testing.T) {
+	id1, id2 := uuid.New(), uuid.New()
+
+	cases := []struct {
+		Name            string
+		Data            string
+		ExpectedParties []entity.BlockedParty
+		ExpectedErr     error
+	}{
+		{
+			Name: "id and reason",
+			Data: "reason,id\nsanctions," + id1.String() + "\n\"fraud, confirmed\"," + id2.String() + "\n",
+			ExpectedParties: []entity.BlockedParty{
+				{ID: id1, Reason: "sanctions"},
+				{ID: id2, Reason: "fraud, confirmed"},
+			},
+		},
+		{
+			Name:            "id only",
+			Data:            "id\n" + id1.String() + "\n",
+			ExpectedParties: []entity.BlockedParty{{ID: id1}},
+		},
+		{
+			Name:        "no id column",
+			Data:        "account,reason\n" + id1.String() + ",fraud\n",
+			ExpectedErr: screening.ErrInvalidEntry,
+		},
+		{
+			Name:        "malformed id",
+			Data:        "id,reason\n42,fraud\n",
+			ExpectedErr: screening.ErrInvalidEntry,
+		},
+	}
+
+	for _, tcase := range cases {
+		t.Run(tcase.Name, func(t *testing.T) {
+			parties, err := screening.ParseCSV(strings.NewReader(tcase.Data))
+			require.ErrorIs(t, err, tcase.ExpectedErr)
+			require.Equal(t, tcase.ExpectedParties, parties)
+		})
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	id := uuid.New()
+
+	parties, err := screening.ParseJSON(strings.NewReader(`[{"id": "` + id.String() + `", "reason": "sanctions"}]`))
+	require.NoError(t, err)
+	require.Equal(t, []entity.BlockedParty{{ID: id, Reason: "sanctions"}}, parties)
+
+	_, err = screening.ParseJSON(strings.NewReader(`[{"reason": "sanctions"}]`))
+	require.ErrorIs(t, err, screening.ErrInvalidEntry)
+}
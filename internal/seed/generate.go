@@ -0,0 +1,118 @@
+package seed
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/aspirin100/finapi/internal/entity"
+)
+
+// Shape of the generated traffic. Amounts are log-normal, so most of them are small
+// with a long tail of large ones, and a few accounts make most of the transactions.
+const (
+	savingsShare   = 0.2
+	depositShare   = 0.1
+	balanceMedian  = 500.0
+	balanceSigma   = 1.2
+	depositMedian  = 1500.0
+	depositSigma   = 0.6
+	transferMedian = 25.0
+	transferSigma  = 1.1
+	activitySkew   = 1.2
+)
+
+var cent = decimal.New(1, -2)
+
+// Generate returns a fixture of n accounts with opening balances and m transactions between them
+// for load testing. The fixture is the same for the same rnd state, transfers never exceed
+// the sender's balance, so all of them succeed when loaded into empty accounts.
+func Generate(rnd *rand.Rand, n, m int) *Fixture {
+	fixture := &Fixture{
+		Environments: []string{EnvDevelopment, EnvTest},
+		Accounts:     make([]Account, n),
+		Transactions: make([]Transaction, 0, m),
+	}
+
+	if n == 0 {
+		return fixture
+	}
+
+	balances := make([]decimal.Decimal, n)
+
+	for i := range fixture.Accounts {
+		accountType := entity.AccountChecking
+		if rnd.Float64() < savingsShare {
+			accountType = entity.AccountSavings
+		}
+
+		balances[i] = logNormal(rnd, balanceMedian, balanceSigma)
+
+		fixture.Accounts[i] = Account{
+			ID:      uuid.Must(uuid.NewRandomFromReader(rnd)),
+			Type:    accountType,
+			Balance: balances[i],
+		}
+	}
+
+	pick := func() int { return 0 }
+
+	if n > 1 {
+		// the most active accounts are spread over the fixture, not the first ones
+		order := rnd.Perm(n)
+		activity := rand.NewZipf(rnd, activitySkew, 1, uint64(n-1))
+
+		pick = func() int {
+			return order[activity.Uint64()]
+		}
+	}
+
+	for range m {
+		receiver := pick()
+
+		sender := pick()
+		if n > 1 {
+			for sender == receiver {
+				sender = pick()
+			}
+		}
+
+		amount := decimal.Min(logNormal(rnd, transferMedian, transferSigma), balances[sender])
+
+		if sender == receiver || rnd.Float64() < depositShare || amount.LessThan(cent) {
+			amount = logNormal(rnd, depositMedian, depositSigma)
+			balances[receiver] = balances[receiver].Add(amount)
+
+			fixture.Transactions = append(fixture.Transactions, Transaction{
+				Operation: OperationDeposit,
+				To:        fixture.Accounts[receiver].ID,
+				Amount:    amount,
+			})
+
+			continue
+		}
+
+		balances[sender] = balances[sender].Sub(amount)
+		balances[receiver] = balances[receiver].Add(amount)
+
+		from := fixture.Accounts[sender].ID
+
+		fixture.Transactions = append(fixture.Transactions, Transaction{
+			Operation: OperationTransfer,
+			From:      &from,
+			To:        fixture.Accounts[receiver].ID,
+			Amount:    amount,
+		})
+	}
+
+	return fixture
+}
+
+// logNormal returns a random amount in cents, at least a cent, half of the amounts are below median.
+func logNormal(rnd *rand.Rand, median, sigma float64) decimal.Decimal {
+	amount := decimal.NewFromFloat(median * math.Exp(sigma*rnd.NormFloat64())).RoundBank(2) //nolint:mnd
+
+	return decimal.Max(amount, cent)
+}
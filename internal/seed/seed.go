@@ -0,0 +1,267 @@
+// Package seed loads fixture accounts and transactions through the service, so seeded data
+// passes the same checks and lands in the same audit log as the API traffic.
+package seed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gopkg.in/yaml.v3"
+
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/service"
+)
+
+// Environments fixtures are loaded into unless they list their own.
+const (
+	EnvDevelopment = "development"
+	EnvTest        = "test"
+)
+
+// Operations of fixture transactions.
+const (
+	OperationDeposit  = "deposit"
+	OperationTransfer = "transfer"
+)
+
+var (
+	ErrInvalidFixture = errors.New("invalid fixture")
+	ErrEnvironment    = errors.New("fixture isn't allowed in the environment")
+)
+
+// Fixture is a set of accounts and transactions between them, e.g.
+//
+//	environments: [development, test]
+//	accounts:
+//	  - id: 3fec06e9-29cc-4ff4-9ae7-fb0e7c757b61
+//	  - id: 4178f61f-2ff9-4ab5-afa5-f30dc16e6ad9
+//	    balance: "100"
+//	transactions:
+//	  - operation: transfer
+//	    from: 4178f61f-2ff9-4ab5-afa5-f30dc16e6ad9
+//	    to: 3fec06e9-29cc-4ff4-9ae7-fb0e7c757b61
+//	    amount: "10"
+type Fixture struct {
+	// Environments the fixture may be loaded into, development and test if empty.
+	Environments []string      `json:"environments,omitempty" yaml:"environments,omitempty"`
+	Accounts     []Account     `json:"accounts" yaml:"accounts"`
+	Transactions []Transaction `json:"transactions,omitempty" yaml:"transactions,omitempty"`
+}
+
+// Account is opened with a deposit of its balance.
+type Account struct {
+	ID      uuid.UUID       `json:"id" yaml:"id"`
+	Type    string          `json:"type,omitempty" yaml:"type,omitempty"`
+	Balance decimal.Decimal `json:"balance" yaml:"balance"`
+}
+
+// Transaction is a deposit to To or a transfer From one account To another.
+type Transaction struct {
+	Operation string          `json:"operation" yaml:"operation"`
+	From      *uuid.UUID      `json:"from,omitempty" yaml:"from,omitempty"`
+	To        uuid.UUID       `json:"to" yaml:"to"`
+	Amount    decimal.Decimal `json:"amount" yaml:"amount"`
+}
+
+// Service executes the operations of fixtures.
+type Service interface {
+	CreateAccountWithID(ctx context.Context, id uuid.UUID, accountType string) (*entity.Account, error)
+	Deposit(ctx context.Context, userID uuid.UUID, amount decimal.Decimal) (*entity.Deposit, error)
+	Transfer(ctx context.Context, receiverID, senderID uuid.UUID, amount decimal.Decimal) (*entity.Transaction, error)
+}
+
+// Stats counts what loading of a fixture did.
+type Stats struct {
+	Accounts            int
+	SkippedAccounts     int
+	Transactions        int
+	SkippedTransactions int
+}
+
+// ReadFile reads the YAML or JSON fixture.
+func ReadFile(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture: %w", err)
+	}
+
+	return Parse(data)
+}
+
+// Parse parses the YAML or JSON fixture and checks that transactions refer to its accounts.
+func Parse(data []byte) (*Fixture, error) {
+	var fixture Fixture
+
+	err := yaml.Unmarshal(data, &fixture)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse fixture: %w", err)
+	}
+
+	err = fixture.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &fixture, nil
+}
+
+// WriteFile writes the fixture as JSON if path has the .json extension and as YAML otherwise.
+func WriteFile(path string, fixture *Fixture) error {
+	var (
+		data []byte
+		err  error
+	)
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		data, err = json.MarshalIndent(fixture, "", "  ")
+	} else {
+		data, err = yaml.Marshal(fixture)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to encode fixture: %w", err)
+	}
+
+	err = os.WriteFile(path, data, 0o644) //nolint:gosec,mnd
+	if err != nil {
+		return fmt.Errorf("failed to write fixture: %w", err)
+	}
+
+	return nil
+}
+
+func (f *Fixture) validate() error {
+	accounts := make(map[uuid.UUID]bool, len(f.Accounts))
+
+	for i, account := range f.Accounts {
+		switch {
+		case account.ID == uuid.Nil:
+			return fmt.Errorf("%w: account %d has no id", ErrInvalidFixture, i)
+		case accounts[account.ID]:
+			return fmt.Errorf("%w: account %s is duplicated", ErrInvalidFixture, account.ID)
+		case account.Balance.IsNegative():
+			return fmt.Errorf("%w: account %s has negative balance", ErrInvalidFixture, account.ID)
+		}
+
+		accounts[account.ID] = true
+	}
+
+	for i, transaction := range f.Transactions {
+		parties := []uuid.UUID{transaction.To}
+
+		switch transaction.Operation {
+		case OperationDeposit:
+			if transaction.From != nil {
+				return fmt.Errorf("%w: deposit %d has a sender", ErrInvalidFixture, i)
+			}
+		case OperationTransfer:
+			if transaction.From == nil {
+				return fmt.Errorf("%w: transfer %d has no sender", ErrInvalidFixture, i)
+			}
+
+			parties = append(parties, *transaction.From)
+		default:
+			return fmt.Errorf("%w: transaction %d has unknown operation %q", ErrInvalidFixture, i, transaction.Operation)
+		}
+
+		if !transaction.Amount.IsPositive() {
+			return fmt.Errorf("%w: transaction %d amount isn't positive", ErrInvalidFixture, i)
+		}
+
+		for _, party := range parties {
+			if !accounts[party] {
+				return fmt.Errorf("%w: transaction %d refers to account %s missing in the fixture",
+					ErrInvalidFixture, i, party)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Allowed reports whether the fixture may be loaded into the environment.
+func (f *Fixture) Allowed(env string) bool {
+	environments := f.Environments
+	if len(environments) == 0 {
+		environments = []string{EnvDevelopment, EnvTest}
+	}
+
+	return slices.Contains(environments, env)
+}
+
+// LoadFile reads the fixture at path and loads it.
+func LoadFile(ctx context.Context, srvc Service, env, path string) (Stats, error) {
+	fixture, err := ReadFile(path)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	return Load(ctx, srvc, env, fixture)
+}
+
+// Load opens the accounts of the fixture with deposits of their balances, then executes its transactions
+// in order. Accounts opened already are skipped together with their transactions, so loading
+// the fixture again changes nothing.
+func Load(ctx context.Context, srvc Service, env string, fixture *Fixture) (Stats, error) {
+	var stats Stats
+
+	if !fixture.Allowed(env) {
+		return stats, fmt.Errorf("%w: %q", ErrEnvironment, env)
+	}
+
+	created := make(map[uuid.UUID]bool, len(fixture.Accounts))
+
+	for _, account := range fixture.Accounts {
+		_, err := srvc.CreateAccountWithID(ctx, account.ID, account.Type)
+		if errors.Is(err, service.ErrAccountExists) {
+			stats.SkippedAccounts++
+
+			continue
+		}
+
+		if err != nil {
+			return stats, fmt.Errorf("failed to create account %s: %w", account.ID, err)
+		}
+
+		created[account.ID] = true
+		stats.Accounts++
+
+		if account.Balance.IsPositive() {
+			_, err = srvc.Deposit(ctx, account.ID, account.Balance)
+			if err != nil {
+				return stats, fmt.Errorf("failed to deposit opening balance of account %s: %w", account.ID, err)
+			}
+		}
+	}
+
+	for i, transaction := range fixture.Transactions {
+		var err error
+
+		switch {
+		case !created[transaction.To] || (transaction.From != nil && !created[*transaction.From]):
+			stats.SkippedTransactions++
+
+			continue
+		case transaction.Operation == OperationDeposit:
+			_, err = srvc.Deposit(ctx, transaction.To, transaction.Amount)
+		default:
+			_, err = srvc.Transfer(ctx, transaction.To, *transaction.From, transaction.Amount)
+		}
+
+		if err != nil {
+			return stats, fmt.Errorf("failed to execute transaction %d: %w", i, err)
+		}
+
+		stats.Transactions++
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,141 @@
+package seed_test
+
+import (
+	"context"
+	"math/rand"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aspirin100/finapi/internal/repository/memory"
+	"github.com/aspirin100/finapi/internal/seed"
+	"github.com/aspirin100/finapi/internal/service"
+)
+
+const fixture = `
+accounts:
+  - id: 3fec06e9-29cc-4ff4-9ae7-fb0e7c757b61
+  - id: 4178f61f-2ff9-4ab5-afa5-f30dc16e6ad9
+    type: savings
+    balance: "100"
+transactions:
+  - operation: transfer
+    from: 4178f61f-2ff9-4ab5-afa5-f30dc16e6ad9
+    to: 3fec06e9-29cc-4ff4-9ae7-fb0e7c757b61
+    amount: "10.5"
+  - operation: deposit
+    to: 3fec06e9-29cc-4ff4-9ae7-fb0e7c757b61
+    amount: "1"
+`
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		Name        string
+		Fixture     string
+		ExpectedErr error
+	}{
+		{
+			Name:    "yaml",
+			Fixture: fixture,
+		},
+		{
+			Name:    "json",
+			Fixture: `{"accounts": [{"id": "3fec06e9-29cc-4ff4-9ae7-fb0e7c757b61", "balance": "1"}]}`,
+		},
+		{
+			Name:        "account without id",
+			Fixture:     `accounts: [{balance: "1"}]`,
+			ExpectedErr: seed.ErrInvalidFixture,
+		},
+		{
+			Name: "unknown account",
+			Fixture: `
+accounts: [{id: 3fec06e9-29cc-4ff4-9ae7-fb0e7c757b61}]
+transactions: [{operation: deposit, to: 4178f61f-2ff9-4ab5-afa5-f30dc16e6ad9, amount: "1"}]`,
+			ExpectedErr: seed.ErrInvalidFixture,
+		},
+		{
+			Name: "transfer without sender",
+			Fixture: `
+accounts: [{id: 3fec06e9-29cc-4ff4-9ae7-fb0e7c757b61}]
+transactions: [{operation: transfer, to: 3fec06e9-29cc-4ff4-9ae7-fb0e7c757b61, amount: "1"}]`,
+			ExpectedErr: seed.ErrInvalidFixture,
+		},
+		{
+			Name: "negative amount",
+			Fixture: `
+accounts: [{id: 3fec06e9-29cc-4ff4-9ae7-fb0e7c757b61}]
+transactions: [{operation: deposit, to: 3fec06e9-29cc-4ff4-9ae7-fb0e7c757b61, amount: "-1"}]`,
+			ExpectedErr: seed.ErrInvalidFixture,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			_, err := seed.Parse([]byte(tc.Fixture))
+			require.ErrorIs(t, err, tc.ExpectedErr)
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	ctx := context.Background()
+	srvc := service.New(time.Second, memory.New())
+
+	parsed, err := seed.Parse([]byte(fixture))
+	require.NoError(t, err)
+
+	_, err = seed.Load(ctx, srvc, "production", parsed)
+	require.ErrorIs(t, err, seed.ErrEnvironment)
+
+	stats, err := seed.Load(ctx, srvc, seed.EnvDevelopment, parsed)
+	require.NoError(t, err)
+	require.Equal(t, seed.Stats{Accounts: 2, Transactions: 2}, stats)
+
+	requireBalance(t, srvc, "3fec06e9-29cc-4ff4-9ae7-fb0e7c757b61", "11.5")
+	requireBalance(t, srvc, "4178f61f-2ff9-4ab5-afa5-f30dc16e6ad9", "89.5")
+
+	stats, err = seed.Load(ctx, srvc, seed.EnvTest, parsed)
+	require.NoError(t, err)
+	require.Equal(t, seed.Stats{SkippedAccounts: 2, SkippedTransactions: 2}, stats, "loading again changes nothing")
+
+	requireBalance(t, srvc, "3fec06e9-29cc-4ff4-9ae7-fb0e7c757b61", "11.5")
+}
+
+func TestGenerate(t *testing.T) {
+	generated := seed.Generate(rand.New(rand.NewSource(1)), 50, 1000) //nolint:gosec
+	require.Len(t, generated.Accounts, 50)
+	require.Len(t, generated.Transactions, 1000)
+
+	require.Equal(t, generated, seed.Generate(rand.New(rand.NewSource(1)), 50, 1000), //nolint:gosec
+		"same seed generates the same fixture")
+
+	for _, ext := range []string{".yml", ".json"} {
+		path := filepath.Join(t.TempDir(), "fixture"+ext)
+
+		require.NoError(t, seed.WriteFile(path, generated))
+
+		read, err := seed.ReadFile(path)
+		require.NoError(t, err)
+		require.Len(t, read.Transactions, len(generated.Transactions))
+	}
+
+	srvc := service.New(time.Second, memory.New())
+
+	stats, err := seed.Load(context.Background(), srvc, seed.EnvTest, generated)
+	require.NoError(t, err, "transfers don't exceed balances")
+	require.Equal(t, seed.Stats{Accounts: 50, Transactions: 1000}, stats)
+}
+
+func requireBalance(t *testing.T, srvc *service.Service, userID, expected string) {
+	t.Helper()
+
+	account, err := srvc.GetAccount(context.Background(), uuid.MustParse(userID))
+	require.NoError(t, err)
+	require.True(t, decimal.RequireFromString(expected).Equal(account.Balance),
+		"expected balance %s, got %s", expected, account.Balance)
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/aspirin100/finapi/internal/audit"
+	"github.com/aspirin100/finapi/internal/entity"
+)
+
+// Resources of audit log entries are "<kind>/<id>".
+const (
+	resourceAccount     = "account/"
+	resourceTransaction = "transaction/"
+	resourceReview      = "review/"
+	resourceParty       = "blocklist/"
+)
+
+type balanceState struct {
+	Balance decimal.Decimal `json:"balance"`
+}
+
+type depositState struct {
+	Balance     decimal.Decimal     `json:"balance"`
+	Transaction *entity.Transaction `json:"transaction"`
+}
+
+type transferState struct {
+	SenderBalance   decimal.Decimal     `json:"senderBalance"`
+	ReceiverBalance decimal.Decimal     `json:"receiverBalance"`
+	Transaction     *entity.Transaction `json:"transaction,omitempty"`
+}
+
+type tierState struct {
+	Tier string `json:"tier"`
+}
+
+type partyState struct {
+	ID uuid.UUID `json:"id"`
+}
+
+// record appends the state change to the audit log. It must be called in the db transaction
+// making the change, so the change isn't committed without its entry. Inside runTx the entry is
+// appended right before the commit, as appending locks the log until the transaction ends.
+func (s *Service) record(ctx context.Context, action, resource string, before, after any) error {
+	entry, err := audit.NewEntry(ctx, action, resource, before, after)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	if pending, ok := ctx.Value(pendingAuditKey{}).(*pendingAudit); ok {
+		pending.entries = append(pending.entries, entry)
+
+		return nil
+	}
+
+	return s.appendAudit(ctx, entry)
+}
+
+func (s *Service) appendAudit(ctx context.Context, entry entity.AuditEntry) error {
+	err := s.userManager.AppendAudit(ctx, entry)
+	if err != nil {
+		return fmt.Errorf("failed to record %s: %w", entry.Action, err)
+	}
+
+	return nil
+}
+
+type pendingAuditKey struct{}
+
+// pendingAudit keeps entries recorded in a db transaction until it is about to commit.
+type pendingAudit struct {
+	entries []entity.AuditEntry
+}
+
+func withPendingAudit(ctx context.Context) (context.Context, *pendingAudit) {
+	pending := &pendingAudit{}
+
+	return context.WithValue(ctx, pendingAuditKey{}, pending), pending
+}
+
+// flush appends the pending entries in the order they were recorded.
+func (p *pendingAudit) flush(ctx context.Context, s *Service) error {
+	for _, entry := range p.entries {
+		err := s.appendAudit(ctx, entry)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
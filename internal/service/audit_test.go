@@ -0,0 +1,214 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aspirin100/finapi/internal/audit"
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/logger"
+	"github.com/aspirin100/finapi/internal/repository"
+	"github.com/aspirin100/finapi/internal/service"
+)
+
+var errAuditUnavailable = errors.New("audit log unavailable")
+
+// auditManager chains audit entries in memory, balances are 100 before every operation.
+type auditManager struct {
+	stubUserManager
+
+	mu      sync.Mutex
+	entries []entity.AuditEntry
+	fail    bool
+}
+
+func (m *auditManager) UpdateBalance(_ context.Context,
+	_ uuid.UUID,
+	amount decimal.Decimal) (*decimal.Decimal, error) {
+	balance := decimal.NewFromInt(100).Add(amount)
+
+	return &balance, nil
+}
+
+func (m *auditManager) AppendAudit(_ context.Context, entry entity.AuditEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.fail {
+		return errAuditUnavailable
+	}
+
+	var prevHash string
+	if len(m.entries) > 0 {
+		prevHash = m.entries[len(m.entries)-1].Hash
+	}
+
+	err := audit.Chain(&entry, prevHash)
+	if err != nil {
+		return err
+	}
+
+	m.entries = append(m.entries, entry)
+
+	return nil
+}
+
+func TestAuditLog(t *testing.T) {
+	ctx := audit.WithActor(logger.WithRequestID(context.Background(), "audited-request"), "operator")
+
+	manager := &auditManager{}
+	srvc := service.New(DefaultTimeout, manager)
+
+	userID, receiverID := uuid.New(), uuid.New()
+
+	_, err := srvc.CreateAccount(ctx, entity.AccountSavings)
+	require.NoError(t, err)
+
+	_, err = srvc.Deposit(ctx, userID, decimal.NewFromInt(10))
+	require.NoError(t, err)
+
+	transaction, err := srvc.Transfer(ctx, receiverID, userID, decimal.NewFromInt(30))
+	require.NoError(t, err)
+
+	_, err = srvc.Transfer(context.Background(), receiverID, userID, decimal.NewFromInt(1))
+	require.NoError(t, err)
+
+	require.Len(t, manager.entries, 4)
+
+	var verifier audit.Verifier
+
+	for _, entry := range manager.entries {
+		require.NoError(t, verifier.Check(entry))
+	}
+
+	cases := []struct {
+		Name             string
+		Entry            entity.AuditEntry
+		ExpectedAction   string
+		ExpectedResource string
+		ExpectedActor    string
+		ExpectedBefore   string
+	}{
+		{
+			Name:           "account",
+			Entry:          manager.entries[0],
+			ExpectedAction: audit.ActionAccountCreate,
+			ExpectedActor:  "operator",
+		},
+		{
+			Name:             "deposit",
+			Entry:            manager.entries[1],
+			ExpectedAction:   audit.ActionDeposit,
+			ExpectedResource: "account/" + userID.String(),
+			ExpectedActor:    "operator",
+			ExpectedBefore:   `{"balance":"100"}`,
+		},
+		{
+			Name:             "transfer",
+			Entry:            manager.entries[2],
+			ExpectedAction:   audit.ActionTransfer,
+			ExpectedResource: "transaction/" + transaction.ID.String(),
+			ExpectedActor:    "operator",
+			ExpectedBefore:   `{"senderBalance":"100","receiverBalance":"100"}`,
+		},
+		{
+			Name:           "anonymous transfer",
+			Entry:          manager.entries[3],
+			ExpectedAction: audit.ActionTransfer,
+			ExpectedActor:  audit.ActorAnonymous,
+			ExpectedBefore: `{"senderBalance":"100","receiverBalance":"100"}`,
+		},
+	}
+
+	for _, tcase := range cases {
+		t.Run(tcase.Name, func(t *testing.T) {
+			require.Equal(t, tcase.ExpectedAction, tcase.Entry.Action)
+			require.Equal(t, tcase.ExpectedActor, tcase.Entry.Actor)
+			require.NotEmpty(t, tcase.Entry.After)
+
+			if tcase.ExpectedResource != "" {
+				require.Equal(t, tcase.ExpectedResource, tcase.Entry.Resource)
+			}
+
+			if tcase.ExpectedBefore == "" {
+				require.Nil(t, tcase.Entry.Before)
+			} else {
+				require.JSONEq(t, tcase.ExpectedBefore, string(tcase.Entry.Before))
+			}
+		})
+	}
+
+	require.Equal(t, "audited-request", manager.entries[2].RequestID)
+
+	var after struct {
+		SenderBalance decimal.Decimal    `json:"senderBalance"`
+		Transaction   entity.Transaction `json:"transaction"`
+	}
+
+	require.NoError(t, json.Unmarshal(manager.entries[2].After, &after))
+	require.Equal(t, "70", after.SenderBalance.String())
+	require.Equal(t, transaction.ID, after.Transaction.ID)
+
+	manager.fail = true
+
+	_, err = srvc.Deposit(ctx, userID, decimal.NewFromInt(10))
+	require.ErrorIs(t, err, errAuditUnavailable, "change isn't made without its audit entry")
+}
+
+// orderedManager logs writes, audit appends and commits in the order they are made.
+type orderedManager struct {
+	auditManager
+
+	calls []string
+}
+
+func (m *orderedManager) BeginTx(ctx context.Context) (context.Context, repository.CommitOrRollback, error) {
+	return ctx, func(err error) error {
+		if err == nil {
+			m.calls = append(m.calls, "commit")
+		}
+
+		return err
+	}, nil
+}
+
+func (m *orderedManager) UpdateBalance(ctx context.Context,
+	userID uuid.UUID,
+	amount decimal.Decimal) (*decimal.Decimal, error) {
+	m.calls = append(m.calls, "write")
+
+	return m.auditManager.UpdateBalance(ctx, userID, amount)
+}
+
+func (m *orderedManager) SaveTransaction(ctx context.Context,
+	receiverID, senderID uuid.UUID,
+	amount decimal.Decimal,
+	operation string) (*entity.Transaction, error) {
+	m.calls = append(m.calls, "write")
+
+	return m.auditManager.SaveTransaction(ctx, receiverID, senderID, amount, operation)
+}
+
+func (m *orderedManager) AppendAudit(ctx context.Context, entry entity.AuditEntry) error {
+	m.calls = append(m.calls, "audit")
+
+	return m.auditManager.AppendAudit(ctx, entry)
+}
+
+func TestAuditAppendedBeforeCommit(t *testing.T) {
+	manager := &orderedManager{}
+	srvc := service.New(DefaultTimeout, manager)
+
+	_, err := srvc.Transfer(context.Background(), uuid.New(), uuid.New(), decimal.NewFromInt(30))
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"write", "write", "write", "audit", "commit"}, manager.calls,
+		"the audit log is locked only for the append and the commit")
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/metrics"
+	"github.com/aspirin100/finapi/internal/tracing"
+)
+
+// chargeFee moves the fee of the charged transaction from the payer to the revenue account
+// as a transaction linked to it, it must be called in the db transaction of the charged one.
+// Nil is returned if there is no fee.
+func (s *Service) chargeFee(ctx context.Context,
+	operation string,
+	payerID uuid.UUID,
+	charged *entity.Transaction) (*entity.Transaction, error) {
+	if s.fees == nil {
+		return nil, nil //nolint:nilnil
+	}
+
+	revenueID := s.fees.RevenueAccount()
+	if payerID == revenueID {
+		return nil, nil //nolint:nilnil
+	}
+
+	fee, err := s.fee(ctx, operation, payerID, charged.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	if !fee.IsPositive() {
+		return nil, nil //nolint:nilnil
+	}
+
+	_, err = s.userManager.UpdateBalance(ctx, payerID, decimal.Zero.Sub(fee))
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	_, err = s.userManager.UpdateBalance(ctx, revenueID, fee)
+	if err != nil {
+		return nil, fmt.Errorf("failed to credit fee to revenue account: %w", err)
+	}
+
+	return s.userManager.SaveLinkedTransaction(ctx, charged.ID, revenueID, payerID, fee, operationFee)
+}
+
+// fee computes the fee of the operation by the tier of the account.
+func (s *Service) fee(ctx context.Context,
+	operation string,
+	accountID uuid.UUID,
+	amount decimal.Decimal) (decimal.Decimal, error) {
+	account, err := s.userManager.GetAccount(ctx, accountID)
+	if err != nil {
+		return decimal.Zero, err //nolint:wrapcheck
+	}
+
+	return s.fees.Fee(operation, account.Tier, amount), nil
+}
+
+func (s *Service) observeFee(operation string, transaction *entity.Transaction) {
+	if transaction.Fee != nil {
+		metrics.FeesCharged.WithLabelValues(operation).Add(transaction.Fee.Amount.InexactFloat64())
+	}
+}
+
+// QuoteFee previews the fee the account would be charged for the operation without moving money.
+func (s *Service) QuoteFee(ctx context.Context,
+	operation string,
+	accountID uuid.UUID,
+	amount decimal.Decimal) (*entity.FeeQuote, error) {
+	ctx, span := tracing.Start(ctx, "Service.QuoteFee",
+		attribute.String("operation", operation),
+		attribute.String("user.id", accountID.String()),
+		attribute.String("amount", amount.String()))
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	account, err := s.userManager.GetAccount(ctx, accountID)
+	if err != nil {
+		err = responseOnRepoError(err)
+		tracing.RecordError(span, err)
+
+		return nil, err
+	}
+
+	quote := entity.FeeQuote{
+		Operation: operation,
+		AccountID: accountID,
+		Tier:      account.Tier,
+		Amount:    amount,
+		Fee:       decimal.Zero,
+	}
+
+	if s.fees != nil && accountID != s.fees.RevenueAccount() {
+		quote.Fee = s.fees.Fee(operation, account.Tier, amount)
+	}
+
+	switch operation {
+	case operationDeposit:
+		quote.BalanceChange = amount.Sub(quote.Fee)
+	default:
+		quote.BalanceChange = decimal.Zero.Sub(amount).Sub(quote.Fee)
+	}
+
+	return &quote, nil
+}
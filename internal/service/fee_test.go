@@ -0,0 +1,174 @@
+package service_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/fee"
+	"github.com/aspirin100/finapi/internal/repository"
+	"github.com/aspirin100/finapi/internal/repository/memory"
+	"github.com/aspirin100/finapi/internal/service"
+)
+
+// ledgerManager keeps balances and tiers of known accounts.
+type ledgerManager struct {
+	stubUserManager
+
+	mu       sync.Mutex
+	balances map[uuid.UUID]decimal.Decimal
+	tiers    map[uuid.UUID]string
+}
+
+func (m *ledgerManager) UpdateBalance(_ context.Context,
+	userID uuid.UUID,
+	amount decimal.Decimal) (*decimal.Decimal, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	balance, ok := m.balances[userID]
+	if !ok {
+		return nil, repository.ErrUserNotFound
+	}
+
+	balance = balance.Add(amount)
+	if balance.IsNegative() {
+		return nil, repository.ErrNegativeBalance
+	}
+
+	m.balances[userID] = balance
+
+	return &balance, nil
+}
+
+func (m *ledgerManager) GetAccount(_ context.Context, userID uuid.UUID) (*entity.Account, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	balance, ok := m.balances[userID]
+	if !ok {
+		return nil, repository.ErrUserNotFound
+	}
+
+	return &entity.Account{ID: userID, Balance: balance, Tier: m.tiers[userID]}, nil
+}
+
+func (m *ledgerManager) balance(userID uuid.UUID) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.balances[userID].String()
+}
+
+func TestFees(t *testing.T) {
+	ctx := context.Background()
+
+	revenueID, senderID, premiumID, receiverID := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	manager := &ledgerManager{
+		balances: map[uuid.UUID]decimal.Decimal{
+			revenueID:  decimal.Zero,
+			senderID:   decimal.NewFromInt(200),
+			premiumID:  decimal.NewFromInt(200),
+			receiverID: decimal.Zero,
+		},
+		tiers: map[uuid.UUID]string{
+			senderID:  entity.TierStandard,
+			premiumID: "premium",
+		},
+	}
+
+	engine, err := fee.NewEngine(revenueID, fee.DefaultPrecision, []fee.Schedule{
+		{Operation: fee.OperationTransfer, Flat: decimal.NewFromInt(1), Percent: decimal.NewFromInt(1)},
+		{Operation: fee.OperationTransfer, Tier: "premium"},
+		{Operation: fee.OperationDeposit, Flat: decimal.RequireFromString("0.5")},
+	})
+	require.NoError(t, err)
+
+	srvc := service.New(DefaultTimeout, manager, service.WithFees(engine))
+
+	transaction, err := srvc.Transfer(ctx, receiverID, senderID, decimal.NewFromInt(100))
+	require.NoError(t, err)
+	require.NotNil(t, transaction.Fee)
+	require.Equal(t, "2", transaction.Fee.Amount.String())
+	require.Equal(t, "fee", transaction.Fee.Operation)
+	require.Equal(t, revenueID, transaction.Fee.ReceiverID)
+	require.Equal(t, senderID, transaction.Fee.SenderID)
+	require.Equal(t, transaction.ID, *transaction.Fee.ParentID)
+
+	require.Equal(t, "98", manager.balance(senderID))
+	require.Equal(t, "100", manager.balance(receiverID))
+	require.Equal(t, "2", manager.balance(revenueID))
+
+	transaction, err = srvc.Transfer(ctx, receiverID, premiumID, decimal.NewFromInt(100))
+	require.NoError(t, err)
+	require.Nil(t, transaction.Fee, "premium transfers are free")
+
+	deposit, err := srvc.Deposit(ctx, receiverID, decimal.NewFromInt(10))
+	require.NoError(t, err)
+	require.Equal(t, "0.5", deposit.Transaction.Fee.Amount.String())
+	require.Equal(t, "209.5", deposit.Balance.String())
+
+	// the stub doesn't roll back, so the failed transfer is the last one
+	_, err = srvc.Transfer(ctx, receiverID, senderID, decimal.NewFromInt(97))
+	require.ErrorIs(t, err, service.ErrNegativeBalance, "balance doesn't cover the fee")
+
+	quote, err := srvc.QuoteFee(ctx, fee.OperationTransfer, senderID, decimal.NewFromInt(50))
+	require.NoError(t, err)
+	require.Equal(t, "1.5", quote.Fee.String())
+	require.Equal(t, "-51.5", quote.BalanceChange.String())
+	require.Equal(t, entity.TierStandard, quote.Tier)
+
+	_, err = srvc.QuoteFee(ctx, fee.OperationTransfer, uuid.New(), decimal.NewFromInt(50))
+	require.ErrorIs(t, err, service.ErrUserNotFound)
+}
+
+func TestAccountTierFees(t *testing.T) {
+	ctx := context.Background()
+
+	store := memory.New()
+
+	revenue, err := store.CreateAccount(ctx, uuid.New(), entity.AccountChecking)
+	require.NoError(t, err)
+
+	engine, err := fee.NewEngine(revenue.ID, fee.DefaultPrecision, []fee.Schedule{
+		{Operation: fee.OperationTransfer, Flat: decimal.NewFromInt(1)},
+		{Operation: fee.OperationTransfer, Tier: "business", Percent: decimal.NewFromInt(2)},
+	})
+	require.NoError(t, err)
+
+	srvc := service.New(DefaultTimeout, store, service.WithFees(engine))
+
+	sender, err := srvc.CreateAccount(ctx, entity.AccountChecking)
+	require.NoError(t, err)
+
+	receiver, err := srvc.CreateAccount(ctx, entity.AccountChecking)
+	require.NoError(t, err)
+
+	_, err = srvc.Deposit(ctx, sender.ID, decimal.NewFromInt(500))
+	require.NoError(t, err)
+
+	account, err := srvc.UpdateAccountTier(ctx, sender.ID, "business")
+	require.NoError(t, err)
+	require.Equal(t, "business", account.Tier)
+
+	transaction, err := srvc.Transfer(ctx, receiver.ID, sender.ID, decimal.NewFromInt(200))
+	require.NoError(t, err)
+	require.NotNil(t, transaction.Fee)
+	require.Equal(t, "4", transaction.Fee.Amount.String(), "business schedule is charged")
+
+	sender, err = store.GetAccount(ctx, sender.ID)
+	require.NoError(t, err)
+	require.Equal(t, "296", sender.Balance.String())
+
+	_, err = srvc.UpdateAccountTier(ctx, sender.ID, "Gold Plus")
+	require.ErrorIs(t, err, service.ErrAccountTier)
+
+	_, err = srvc.UpdateAccountTier(ctx, uuid.New(), "business")
+	require.ErrorIs(t, err, service.ErrUserNotFound)
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/aspirin100/finapi/internal/audit"
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/interest"
+	"github.com/aspirin100/finapi/internal/logger"
+	"github.com/aspirin100/finapi/internal/metrics"
+	"github.com/aspirin100/finapi/internal/tracing"
+)
+
+// PayInterest pays interest accrued in the month of the period to all accounts earning it,
+// from the payer account as "interest" transactions. An account already paid for the period
+// is skipped, so the payout may be rerun any number of times. Failed accounts, including ones blocked
+// by screening, don't stop the payout of the rest, their errors are joined. The number of paid accounts is returned.
+func (s *Service) PayInterest(ctx context.Context, period time.Time) (int, error) {
+	from, to := interest.Month(period)
+
+	ctx, span := tracing.Start(ctx, "Service.PayInterest",
+		attribute.String("period", from.Format(time.DateOnly)))
+	defer span.End()
+
+	if s.interest == nil {
+		return 0, nil
+	}
+
+	if to.After(time.Now()) {
+		err := fmt.Errorf("%w: %s", ErrPeriodNotOver, from.Format(time.DateOnly))
+		tracing.RecordError(span, err)
+
+		return 0, err
+	}
+
+	var (
+		paid int
+		errs []error
+	)
+
+	for _, accountType := range s.interest.AccountTypes() {
+		accounts, err := s.accountsByType(ctx, accountType)
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+
+		for _, account := range accounts {
+			transaction, err := s.payInterest(ctx, account, from, to)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to pay interest to %s: %w", account.ID, err))
+
+				continue
+			}
+
+			if transaction != nil {
+				paid++
+			}
+		}
+	}
+
+	err := errors.Join(errs...)
+	if err != nil {
+		tracing.RecordError(span, err)
+	}
+
+	return paid, err
+}
+
+// RunInterestPayouts pays interest of the last completed month every interval until ctx is done.
+// A period is paid once it succeeds for all accounts, failed ones are retried on the next tick.
+func (s *Service) RunInterestPayouts(ctx context.Context, interval time.Duration) {
+	ctx = audit.WithActor(ctx, audit.ActorSystem)
+
+	var paidPeriod time.Time
+
+	payout := func() {
+		period, _ := interest.LastPeriod(time.Now())
+		if period.Equal(paidPeriod) {
+			return
+		}
+
+		paid, err := s.PayInterest(ctx, period)
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.FromContext(ctx).Error("interest payout failed",
+					slog.String("period", period.Format(time.DateOnly)), slog.Any("error", err))
+			}
+
+			return
+		}
+
+		logger.FromContext(ctx).Info("interest paid",
+			slog.String("period", period.Format(time.DateOnly)), slog.Int("accounts", paid))
+
+		paidPeriod = period
+	}
+
+	payout()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			payout()
+		}
+	}
+}
+
+func (s *Service) accountsByType(ctx context.Context, accountType string) ([]entity.Account, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	accounts, err := s.userManager.GetAccountsByType(ctx, accountType)
+	if err != nil {
+		return nil, responseOnRepoError(err)
+	}
+
+	return accounts, nil
+}
+
+// payInterest pays interest of [from, to) to the account, nil transaction is returned
+// if there is nothing to pay or it is already paid.
+func (s *Service) payInterest(ctx context.Context,
+	account entity.Account,
+	from, to time.Time) (*entity.Transaction, error) {
+	payerID := s.interest.PayerAccount()
+	if account.ID == payerID {
+		return nil, nil //nolint:nilnil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	balances, err := s.userManager.GetDailyBalances(ctx, account.ID, from, to)
+	if err != nil {
+		return nil, responseOnRepoError(err)
+	}
+
+	amount := s.interest.Accrue(account.Type, balances)
+	if !amount.IsPositive() {
+		return nil, nil //nolint:nilnil
+	}
+
+	// blocked accounts are skipped and retried on the next payout, they may be unblocked by then
+	err = s.screen(ctx, operationInterest, account.ID, payerID)
+	if err != nil {
+		if errors.Is(err, ErrPartyBlocked) {
+			metrics.ObserveOperation(operationInterest, metrics.OutcomeBlocked, amount)
+		}
+
+		return nil, err
+	}
+
+	var transaction *entity.Transaction
+
+	err = s.inTx(ctx, func(ctx context.Context) error {
+		payerBalance, err := s.userManager.UpdateBalance(ctx, payerID, decimal.Zero.Sub(amount))
+		if err != nil {
+			return fmt.Errorf("failed to debit interest payer account: %w", err)
+		}
+
+		accountBalance, err := s.userManager.UpdateBalance(ctx, account.ID, amount)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+
+		transaction, err = s.userManager.SaveTransaction(ctx, account.ID, payerID, amount, operationInterest)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+
+		err = s.userManager.SaveInterestPayout(ctx, entity.InterestPayout{
+			AccountID:     account.ID,
+			Period:        from,
+			Amount:        amount,
+			TransactionID: transaction.ID,
+		})
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+
+		return s.record(ctx, audit.ActionInterestPayout, resourceTransaction+transaction.ID.String(),
+			transferState{
+				SenderBalance:   payerBalance.Add(amount),
+				ReceiverBalance: accountBalance.Sub(amount),
+			},
+			transferState{
+				SenderBalance:   *payerBalance,
+				ReceiverBalance: *accountBalance,
+				Transaction:     transaction,
+			})
+	})
+	if errors.Is(err, ErrInterestPaid) {
+		return nil, nil //nolint:nilnil
+	}
+
+	metrics.ObserveOperation(operationInterest, operationOutcome(err), amount)
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(transaction)
+
+	return transaction, nil
+}
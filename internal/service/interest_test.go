@@ -0,0 +1,192 @@
+package service_test
+
+import (
+	"context"
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/interest"
+	"github.com/aspirin100/finapi/internal/repository"
+	"github.com/aspirin100/finapi/internal/screening"
+	"github.com/aspirin100/finapi/internal/service"
+)
+
+type payoutKey struct {
+	accountID uuid.UUID
+	period    time.Time
+}
+
+// interestManager keeps account types and payouts and rolls balances back with failed db transactions.
+type interestManager struct {
+	*ledgerManager
+
+	types   map[uuid.UUID]string
+	payouts map[payoutKey]entity.InterestPayout
+}
+
+func (m *interestManager) BeginTx(ctx context.Context) (context.Context, repository.CommitOrRollback, error) {
+	m.mu.Lock()
+	snapshot := maps.Clone(m.balances)
+	m.mu.Unlock()
+
+	return ctx, func(err error) error {
+		if err != nil {
+			m.mu.Lock()
+			m.balances = snapshot
+			m.mu.Unlock()
+		}
+
+		return err
+	}, nil
+}
+
+func (m *interestManager) GetAccountsByType(_ context.Context, accountType string) ([]entity.Account, error) {
+	var accounts []entity.Account
+
+	for accountID, t := range m.types {
+		if t == accountType {
+			accounts = append(accounts, entity.Account{ID: accountID, Type: accountType})
+		}
+	}
+
+	return accounts, nil
+}
+
+// GetDailyBalances returns the current balance for every day of the period.
+func (m *interestManager) GetDailyBalances(_ context.Context,
+	accountID uuid.UUID,
+	from, to time.Time) ([]entity.DailyBalance, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var balances []entity.DailyBalance
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		balances = append(balances, entity.DailyBalance{Day: day, Balance: m.balances[accountID]})
+	}
+
+	return balances, nil
+}
+
+func (m *interestManager) SaveInterestPayout(_ context.Context, payout entity.InterestPayout) error {
+	key := payoutKey{accountID: payout.AccountID, period: payout.Period}
+
+	if _, ok := m.payouts[key]; ok {
+		return repository.ErrAlreadyPaid
+	}
+
+	m.payouts[key] = payout
+
+	return nil
+}
+
+// staticBlocklist is the blocklist of the parties.
+type staticBlocklist []entity.BlockedParty
+
+func (b staticBlocklist) GetBlockedParties(_ context.Context) ([]entity.BlockedParty, error) {
+	return b, nil
+}
+
+func TestPayInterest(t *testing.T) {
+	ctx := context.Background()
+
+	payerID, savingsID, checkingID := uuid.New(), uuid.New(), uuid.New()
+
+	manager := &interestManager{
+		ledgerManager: &ledgerManager{
+			balances: map[uuid.UUID]decimal.Decimal{
+				payerID:    decimal.NewFromInt(1000),
+				savingsID:  decimal.NewFromInt(36500),
+				checkingID: decimal.NewFromInt(36500),
+			},
+		},
+		types: map[uuid.UUID]string{
+			savingsID:  entity.AccountSavings,
+			checkingID: entity.AccountChecking,
+		},
+		payouts: make(map[payoutKey]entity.InterestPayout),
+	}
+
+	engine, err := interest.NewEngine(payerID, interest.DefaultPrecision, []interest.Product{
+		{AccountType: entity.AccountSavings, Rate: decimal.NewFromInt(1), DayCount: interest.DayCountActual365},
+	})
+	require.NoError(t, err)
+
+	srvc := service.New(DefaultTimeout, manager, service.WithInterest(engine))
+
+	_, err = srvc.PayInterest(ctx, time.Now())
+	require.ErrorIs(t, err, service.ErrPeriodNotOver)
+
+	period := time.Date(2025, time.January, 10, 0, 0, 0, 0, time.UTC)
+
+	paid, err := srvc.PayInterest(ctx, period)
+	require.NoError(t, err)
+	require.Equal(t, 1, paid)
+	require.Equal(t, "36531", manager.balance(savingsID))
+	require.Equal(t, "969", manager.balance(payerID))
+	require.Equal(t, "36500", manager.balance(checkingID), "checking accounts earn no interest")
+
+	payout := manager.payouts[payoutKey{accountID: savingsID, period: time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)}]
+	require.Equal(t, "31", payout.Amount.String())
+
+	paid, err = srvc.PayInterest(ctx, period)
+	require.NoError(t, err)
+	require.Zero(t, paid, "rerun doesn't pay the period again")
+	require.Equal(t, "36531", manager.balance(savingsID))
+	require.Equal(t, "969", manager.balance(payerID))
+
+	// the next month accrues on the balance with the paid interest, the payer can't cover it
+	manager.balances[payerID] = decimal.NewFromInt(10)
+
+	paid, err = srvc.PayInterest(ctx, period.AddDate(0, 1, 0))
+	require.ErrorIs(t, err, service.ErrNegativeBalance)
+	require.Zero(t, paid)
+	require.Equal(t, "36531", manager.balance(savingsID), "failed payout is rolled back")
+}
+
+func TestPayInterestBlocked(t *testing.T) {
+	ctx := context.Background()
+
+	payerID, blockedID, savingsID := uuid.New(), uuid.New(), uuid.New()
+
+	manager := &interestManager{
+		ledgerManager: &ledgerManager{
+			balances: map[uuid.UUID]decimal.Decimal{
+				payerID:   decimal.NewFromInt(1000),
+				blockedID: decimal.NewFromInt(36500),
+				savingsID: decimal.NewFromInt(36500),
+			},
+		},
+		types: map[uuid.UUID]string{
+			blockedID: entity.AccountSavings,
+			savingsID: entity.AccountSavings,
+		},
+		payouts: make(map[payoutKey]entity.InterestPayout),
+	}
+
+	engine, err := interest.NewEngine(payerID, interest.DefaultPrecision, []interest.Product{
+		{AccountType: entity.AccountSavings, Rate: decimal.NewFromInt(1), DayCount: interest.DayCountActual365},
+	})
+	require.NoError(t, err)
+
+	screener := screening.New(staticBlocklist{{ID: blockedID, Reason: "sanctions list"}})
+	require.NoError(t, screener.Reload(ctx))
+
+	srvc := service.New(DefaultTimeout, manager, service.WithInterest(engine), service.WithScreener(screener))
+
+	paid, err := srvc.PayInterest(ctx, time.Date(2025, time.January, 10, 0, 0, 0, 0, time.UTC))
+	require.ErrorIs(t, err, service.ErrPartyBlocked, "the period is retried while the account is blocked")
+	require.Equal(t, 1, paid)
+	require.Equal(t, "36531", manager.balance(savingsID))
+	require.Equal(t, "36500", manager.balance(blockedID), "blocked accounts get no interest")
+	require.Equal(t, "969", manager.balance(payerID))
+	require.NotContains(t, manager.payouts, payoutKey{
+		accountID: blockedID,
+		period:    time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+	})
+}
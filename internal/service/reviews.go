@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/aspirin100/finapi/internal/audit"
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/metrics"
+	"github.com/aspirin100/finapi/internal/tracing"
+)
+
+func (s *Service) GetReview(ctx context.Context, reviewID uuid.UUID) (*entity.Review, error) {
+	ctx, span := tracing.Start(ctx, "Service.GetReview",
+		attribute.String("review.id", reviewID.String()))
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	review, err := s.userManager.GetReview(ctx, reviewID)
+	if err != nil {
+		err = responseOnRepoError(err)
+		tracing.RecordError(span, err)
+
+		return nil, err
+	}
+
+	return review, nil
+}
+
+// GetReviews returns the oldest reviews with the status.
+func (s *Service) GetReviews(ctx context.Context, status string) ([]entity.Review, error) {
+	ctx, span := tracing.Start(ctx, "Service.GetReviews",
+		attribute.String("review.status", status))
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	reviews, err := s.userManager.GetReviews(ctx, status)
+	if err != nil {
+		err = responseOnRepoError(err)
+		tracing.RecordError(span, err)
+
+		return nil, err
+	}
+
+	return reviews, nil
+}
+
+// ApproveReview executes the held transfer, risk rules are not evaluated again.
+func (s *Service) ApproveReview(ctx context.Context, reviewID uuid.UUID) (*entity.Review, error) {
+	ctx, span := tracing.Start(ctx, "Service.ApproveReview",
+		attribute.String("review.id", reviewID.String()))
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var (
+		pending     *entity.Review
+		decided     *entity.Review
+		transaction *entity.Transaction
+	)
+
+	// parties are screened again as the blocklist may have changed since the transfer was held
+	err := s.screenReview(ctx, reviewID)
+	if err != nil {
+		tracing.RecordError(span, err)
+
+		return nil, err
+	}
+
+	err = s.inTx(ctx, func(ctx context.Context) error {
+		var err error
+
+		pending, err = s.pendingReview(ctx, reviewID)
+		if err != nil {
+			return err
+		}
+
+		transaction, err = s.moveMoney(ctx, pending.ReceiverID, pending.SenderID, pending.Amount)
+		if err != nil {
+			return err
+		}
+
+		decided, err = s.userManager.DecideReview(ctx, reviewID, entity.ReviewApproved, &transaction.ID)
+		if err != nil {
+			return err
+		}
+
+		return s.record(ctx, audit.ActionReviewApprove, resourceReview+reviewID.String(), pending, decided)
+	})
+
+	if pending != nil && !errors.Is(err, ErrReviewDecided) {
+		metrics.ObserveOperation(operationTransfer, operationOutcome(err), pending.Amount)
+	}
+
+	if err != nil {
+		tracing.RecordError(span, err)
+
+		return nil, err
+	}
+
+	s.observeFee(operationTransfer, transaction)
+	s.publish(transaction)
+
+	return decided, nil
+}
+
+// RejectReview cancels the held transfer.
+func (s *Service) RejectReview(ctx context.Context, reviewID uuid.UUID) (*entity.Review, error) {
+	ctx, span := tracing.Start(ctx, "Service.RejectReview",
+		attribute.String("review.id", reviewID.String()))
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var decided *entity.Review
+
+	err := s.inTx(ctx, func(ctx context.Context) error {
+		pending, err := s.pendingReview(ctx, reviewID)
+		if err != nil {
+			return err
+		}
+
+		decided, err = s.userManager.DecideReview(ctx, reviewID, entity.ReviewRejected, nil)
+		if err != nil {
+			return err
+		}
+
+		return s.record(ctx, audit.ActionReviewReject, resourceReview+reviewID.String(), pending, decided)
+	})
+	if err != nil {
+		tracing.RecordError(span, err)
+
+		return nil, err
+	}
+
+	return decided, nil
+}
+
+// pendingReview locks the review and checks it is not decided yet, it must be called in a db transaction.
+func (s *Service) pendingReview(ctx context.Context, reviewID uuid.UUID) (*entity.Review, error) {
+	review, err := s.userManager.GetReview(ctx, reviewID)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	if review.Status != entity.ReviewPending {
+		return nil, ErrReviewDecided
+	}
+
+	return review, nil
+}
+
+func (s *Service) screenReview(ctx context.Context, reviewID uuid.UUID) error {
+	if s.screener == nil {
+		return nil
+	}
+
+	review, err := s.userManager.GetReview(ctx, reviewID)
+	if err != nil {
+		return responseOnRepoError(err)
+	}
+
+	err = s.screen(ctx, operationTransfer, review.SenderID, review.ReceiverID)
+	if errors.Is(err, ErrPartyBlocked) {
+		metrics.ObserveOperation(operationTransfer, metrics.OutcomeBlocked, review.Amount)
+	}
+
+	return err
+}
@@ -0,0 +1,159 @@
+package service_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/repository"
+	"github.com/aspirin100/finapi/internal/risk"
+	"github.com/aspirin100/finapi/internal/service"
+)
+
+// reviewsManager keeps reviews and counts saved transactions.
+type reviewsManager struct {
+	stubUserManager
+
+	mu           sync.Mutex
+	reviews      map[uuid.UUID]entity.Review
+	transactions int
+}
+
+func newReviewsManager() *reviewsManager {
+	return &reviewsManager{reviews: make(map[uuid.UUID]entity.Review)}
+}
+
+func (m *reviewsManager) SaveTransaction(ctx context.Context,
+	receiverID, senderID uuid.UUID,
+	amount decimal.Decimal,
+	operation string) (*entity.Transaction, error) {
+	m.mu.Lock()
+	m.transactions++
+	m.mu.Unlock()
+
+	return m.stubUserManager.SaveTransaction(ctx, receiverID, senderID, amount, operation)
+}
+
+func (m *reviewsManager) CreateReview(ctx context.Context,
+	receiverID, senderID uuid.UUID,
+	amount decimal.Decimal,
+	rule, reason string) (*entity.Review, error) {
+	review, err := m.stubUserManager.CreateReview(ctx, receiverID, senderID, amount, rule, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.reviews[review.ID] = *review
+
+	return review, nil
+}
+
+func (m *reviewsManager) GetReview(_ context.Context, reviewID uuid.UUID) (*entity.Review, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	review, ok := m.reviews[reviewID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+
+	return &review, nil
+}
+
+func (m *reviewsManager) DecideReview(_ context.Context,
+	reviewID uuid.UUID,
+	status string,
+	transactionID *uuid.UUID) (*entity.Review, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	review, ok := m.reviews[reviewID]
+	if !ok || review.Status != entity.ReviewPending {
+		return nil, repository.ErrNotFound
+	}
+
+	review.Status = status
+	review.TransactionID = transactionID
+	m.reviews[reviewID] = review
+
+	return &review, nil
+}
+
+// amountRisk denies transfers above 100 and holds ones above 10.
+type amountRisk struct{}
+
+func (amountRisk) Evaluate(_ context.Context,
+	_, _ uuid.UUID,
+	amount decimal.Decimal) (risk.Verdict, error) {
+	switch {
+	case amount.GreaterThan(decimal.NewFromInt(100)):
+		return risk.Verdict{Decision: risk.DecisionDeny, Rule: "limit", Reason: "too much"}, nil
+	case amount.GreaterThan(decimal.NewFromInt(10)):
+		return risk.Verdict{Decision: risk.DecisionReview, Rule: "limit", Reason: "a lot"}, nil
+	default:
+		return risk.Verdict{Decision: risk.DecisionAllow}, nil
+	}
+}
+
+func TestTransferRisk(t *testing.T) {
+	ctx := context.Background()
+
+	manager := newReviewsManager()
+	srvc := service.New(DefaultTimeout, manager, service.WithRiskEvaluator(amountRisk{}))
+
+	senderID, receiverID := uuid.New(), uuid.New()
+
+	_, err := srvc.Transfer(ctx, receiverID, senderID, decimal.NewFromInt(10))
+	require.NoError(t, err)
+	require.Equal(t, 1, manager.transactions)
+
+	_, err = srvc.Transfer(ctx, receiverID, senderID, decimal.NewFromInt(101))
+	require.ErrorIs(t, err, service.ErrTransferDenied)
+	require.Equal(t, 1, manager.transactions, "denied transfer is not executed")
+
+	_, err = srvc.Transfer(ctx, receiverID, senderID, decimal.NewFromInt(11))
+	require.ErrorIs(t, err, service.ErrTransferHeld)
+	require.Equal(t, 1, manager.transactions, "held transfer is not executed")
+
+	var held *service.HeldError
+
+	require.ErrorAs(t, err, &held)
+	require.Equal(t, entity.ReviewPending, held.Review.Status)
+	require.Equal(t, "a lot", held.Review.Reason)
+
+	reviews, err := srvc.GetReviews(ctx, entity.ReviewPending)
+	require.NoError(t, err)
+	require.Empty(t, reviews, "stub doesn't list reviews")
+
+	approved, err := srvc.ApproveReview(ctx, held.Review.ID)
+	require.NoError(t, err)
+	require.Equal(t, entity.ReviewApproved, approved.Status)
+	require.NotNil(t, approved.TransactionID)
+	require.Equal(t, 2, manager.transactions)
+
+	_, err = srvc.ApproveReview(ctx, held.Review.ID)
+	require.ErrorIs(t, err, service.ErrReviewDecided)
+
+	_, err = srvc.RejectReview(ctx, held.Review.ID)
+	require.ErrorIs(t, err, service.ErrReviewDecided)
+
+	_, err = srvc.Transfer(ctx, receiverID, senderID, decimal.NewFromInt(50))
+	require.ErrorAs(t, err, &held)
+
+	rejected, err := srvc.RejectReview(ctx, held.Review.ID)
+	require.NoError(t, err)
+	require.Equal(t, entity.ReviewRejected, rejected.Status)
+	require.Nil(t, rejected.TransactionID)
+	require.Equal(t, 2, manager.transactions, "rejected transfer is not executed")
+
+	_, err = srvc.GetReview(ctx, uuid.New())
+	require.ErrorIs(t, err, service.ErrNotFound)
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/aspirin100/finapi/internal/audit"
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/logger"
+	"github.com/aspirin100/finapi/internal/metrics"
+	"github.com/aspirin100/finapi/internal/tracing"
+)
+
+const (
+	screeningClear   = "clear"
+	screeningBlocked = "blocked"
+)
+
+// screen checks the parties against the blocklist and records every decision.
+// The operation must not be executed if recording fails, so ErrPartyBlocked or
+// the recording error is returned.
+func (s *Service) screen(ctx context.Context, operation string, partyIDs ...uuid.UUID) error {
+	if s.screener == nil {
+		return nil
+	}
+
+	requestID := logger.RequestID(ctx)
+	decisions := make([]entity.ScreeningDecision, 0, len(partyIDs))
+	blocked := false
+
+	for _, partyID := range partyIDs {
+		party, isBlocked := s.screener.Screen(partyID)
+
+		decisions = append(decisions, entity.ScreeningDecision{
+			ID:        uuid.New(),
+			Operation: operation,
+			PartyID:   partyID,
+			Blocked:   isBlocked,
+			Reason:    party.Reason,
+			RequestID: requestID,
+		})
+
+		result := screeningClear
+		if isBlocked {
+			result = screeningBlocked
+			blocked = true
+
+			logger.FromContext(ctx).Warn("party blocked by screening",
+				slog.String("operation", operation),
+				slog.String("party_id", partyID.String()),
+				slog.String("reason", party.Reason))
+		}
+
+		metrics.ScreeningDecisions.WithLabelValues(operation, result).Inc()
+	}
+
+	err := s.userManager.SaveScreeningDecisions(ctx, decisions)
+	if err != nil {
+		return fmt.Errorf("failed to record screening decisions: %w", err)
+	}
+
+	if blocked {
+		return ErrPartyBlocked
+	}
+
+	return nil
+}
+
+func (s *Service) GetBlockedParties(ctx context.Context) ([]entity.BlockedParty, error) {
+	ctx, span := tracing.Start(ctx, "Service.GetBlockedParties")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	parties, err := s.userManager.GetBlockedParties(ctx)
+	if err != nil {
+		err = responseOnRepoError(err)
+		tracing.RecordError(span, err)
+
+		return nil, err
+	}
+
+	return parties, nil
+}
+
+// BlockParty adds the party to the blocklist. The local copy of this instance is reloaded at once,
+// other instances pick the change up on their periodic reload.
+func (s *Service) BlockParty(ctx context.Context, partyID uuid.UUID, reason string) (*entity.BlockedParty, error) {
+	ctx, span := tracing.Start(ctx, "Service.BlockParty",
+		attribute.String("party.id", partyID.String()))
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var party *entity.BlockedParty
+
+	err := s.inTx(ctx, func(ctx context.Context) error {
+		var err error
+
+		party, err = s.userManager.BlockParty(ctx, partyID, reason)
+		if err != nil {
+			return err
+		}
+
+		return s.record(ctx, audit.ActionPartyBlock, resourceParty+partyID.String(), nil, party)
+	})
+	if err != nil {
+		tracing.RecordError(span, err)
+
+		return nil, err
+	}
+
+	s.reloadBlocklist(ctx)
+
+	return party, nil
+}
+
+// UnblockParty removes the party from the blocklist.
+func (s *Service) UnblockParty(ctx context.Context, partyID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "Service.UnblockParty",
+		attribute.String("party.id", partyID.String()))
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	err := s.inTx(ctx, func(ctx context.Context) error {
+		err := s.userManager.UnblockParty(ctx, partyID)
+		if err != nil {
+			return err
+		}
+
+		return s.record(ctx, audit.ActionPartyUnblock, resourceParty+partyID.String(), partyState{ID: partyID}, nil)
+	})
+	if err != nil {
+		tracing.RecordError(span, err)
+
+		return err
+	}
+
+	s.reloadBlocklist(ctx)
+
+	return nil
+}
+
+// reloadBlocklist only logs failures, the change is already stored and will be loaded by the periodic reload.
+func (s *Service) reloadBlocklist(ctx context.Context) {
+	if s.screener == nil {
+		return
+	}
+
+	err := s.screener.Reload(ctx)
+	if err != nil {
+		logger.FromContext(ctx).Warn("failed to reload blocklist", slog.Any("error", err))
+	}
+}
@@ -0,0 +1,141 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/repository"
+	"github.com/aspirin100/finapi/internal/screening"
+	"github.com/aspirin100/finapi/internal/service"
+)
+
+var errRecordingFailed = errors.New("recording failed")
+
+// blocklistManager keeps the blocklist and recorded screening decisions on top of reviewsManager.
+type blocklistManager struct {
+	*reviewsManager
+
+	blocked      map[uuid.UUID]entity.BlockedParty
+	decisions    []entity.ScreeningDecision
+	failDecision bool
+}
+
+func newBlocklistManager() *blocklistManager {
+	return &blocklistManager{
+		reviewsManager: newReviewsManager(),
+		blocked:        make(map[uuid.UUID]entity.BlockedParty),
+	}
+}
+
+func (m *blocklistManager) GetBlockedParties(_ context.Context) ([]entity.BlockedParty, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	parties := make([]entity.BlockedParty, 0, len(m.blocked))
+	for _, party := range m.blocked {
+		parties = append(parties, party)
+	}
+
+	return parties, nil
+}
+
+func (m *blocklistManager) BlockParty(_ context.Context,
+	partyID uuid.UUID,
+	reason string) (*entity.BlockedParty, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	party := entity.BlockedParty{ID: partyID, Reason: reason}
+	m.blocked[partyID] = party
+
+	return &party, nil
+}
+
+func (m *blocklistManager) UnblockParty(_ context.Context, partyID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.blocked[partyID]; !ok {
+		return repository.ErrNotFound
+	}
+
+	delete(m.blocked, partyID)
+
+	return nil
+}
+
+func (m *blocklistManager) SaveScreeningDecisions(_ context.Context, decisions []entity.ScreeningDecision) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.failDecision {
+		return errRecordingFailed
+	}
+
+	m.decisions = append(m.decisions, decisions...)
+
+	return nil
+}
+
+func TestScreening(t *testing.T) {
+	ctx := context.Background()
+
+	manager := newBlocklistManager()
+	srvc := service.New(DefaultTimeout, manager,
+		service.WithRiskEvaluator(amountRisk{}),
+		service.WithScreener(screening.New(manager)))
+
+	senderID, receiverID := uuid.New(), uuid.New()
+
+	_, err := srvc.Transfer(ctx, receiverID, senderID, decimal.NewFromInt(1))
+	require.NoError(t, err)
+	require.Len(t, manager.decisions, 2, "both parties are screened")
+	require.False(t, manager.decisions[0].Blocked)
+
+	_, err = srvc.Transfer(ctx, receiverID, senderID, decimal.NewFromInt(11))
+
+	var held *service.HeldError
+
+	require.ErrorAs(t, err, &held)
+
+	_, err = srvc.BlockParty(ctx, receiverID, "sanctions list")
+	require.NoError(t, err)
+
+	_, err = srvc.Deposit(ctx, receiverID, decimal.NewFromInt(1))
+	require.ErrorIs(t, err, service.ErrPartyBlocked)
+
+	_, err = srvc.Transfer(ctx, receiverID, senderID, decimal.NewFromInt(1))
+	require.ErrorIs(t, err, service.ErrPartyBlocked)
+	require.Equal(t, 1, manager.transactions, "blocked transfer is not executed")
+
+	_, err = srvc.ApproveReview(ctx, held.Review.ID)
+	require.ErrorIs(t, err, service.ErrPartyBlocked, "parties of held transfers are screened again")
+	require.Equal(t, 1, manager.transactions)
+
+	last := manager.decisions[len(manager.decisions)-1]
+	require.True(t, last.Blocked)
+	require.Equal(t, receiverID, last.PartyID)
+	require.Equal(t, "sanctions list", last.Reason)
+
+	parties, err := srvc.GetBlockedParties(ctx)
+	require.NoError(t, err)
+	require.Len(t, parties, 1)
+
+	require.NoError(t, srvc.UnblockParty(ctx, receiverID))
+	require.ErrorIs(t, srvc.UnblockParty(ctx, receiverID), service.ErrNotFound)
+
+	_, err = srvc.ApproveReview(ctx, held.Review.ID)
+	require.NoError(t, err)
+	require.Equal(t, 2, manager.transactions)
+
+	manager.failDecision = true
+
+	_, err = srvc.Deposit(ctx, senderID, decimal.NewFromInt(1))
+	require.ErrorIs(t, err, errRecordingFailed, "operation fails if the decision isn't recorded")
+}
@@ -4,86 +4,441 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/otel/attribute"
 
+	"github.com/aspirin100/finapi/internal/audit"
 	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/logger"
+	"github.com/aspirin100/finapi/internal/metrics"
 	"github.com/aspirin100/finapi/internal/repository"
+	"github.com/aspirin100/finapi/internal/risk"
+	"github.com/aspirin100/finapi/internal/tracing"
 )
 
 var (
 	ErrUserNotFound    = errors.New("user not found")
 	ErrNegativeBalance = errors.New("not enough money on balance")
+	ErrNotFound        = errors.New("resource not found")
+	ErrTransferDenied  = errors.New("transfer denied by risk rules")
+	ErrTransferHeld    = errors.New("transfer held for review")
+	ErrReviewDecided   = errors.New("review is already decided")
+	ErrPartyBlocked    = errors.New("operation blocked by sanctions screening")
+	ErrAccountType     = errors.New("unknown account type")
+	ErrAccountTier     = errors.New("invalid account tier")
+	ErrInterestPaid    = errors.New("interest is already paid for the period")
+	ErrPeriodNotOver   = errors.New("interest period is not over yet")
+	ErrAccountExists   = errors.New("account already exists")
 )
 
+// HeldError is returned by Transfer when risk rules hold the transfer for review.
+type HeldError struct {
+	Review *entity.Review
+}
+
+func (e *HeldError) Error() string {
+	return fmt.Sprintf("%s %s: %s", ErrTransferHeld, e.Review.ID, e.Review.Reason)
+}
+
+func (e *HeldError) Unwrap() error {
+	return ErrTransferHeld
+}
+
 const (
 	operationTransfer = "transfer"
 	operationDeposit  = "deposit"
+	operationFee      = "fee"
+	operationInterest = "interest"
 )
 
+const maxTxAttempts = 3
+
 type UserManager interface {
 	UpdateBalance(ctx context.Context, userID uuid.UUID, amount decimal.Decimal) (*decimal.Decimal, error)
+	GetTransaction(ctx context.Context, transactionID uuid.UUID) (*entity.Transaction, error)
 	GetTransactions(ctx context.Context, userID uuid.UUID) ([]entity.Transaction, error)
+	GetTransactionsAfter(ctx context.Context, userID, afterID, pageAfterID uuid.UUID) ([]entity.Transaction, error)
 	SaveTransaction(ctx context.Context,
 		receiverID,
 		senderID uuid.UUID,
 		amount decimal.Decimal,
 		operation string) (*entity.Transaction, error)
+	SaveLinkedTransaction(ctx context.Context,
+		parentID,
+		receiverID,
+		senderID uuid.UUID,
+		amount decimal.Decimal,
+		operation string) (*entity.Transaction, error)
 	BeginTx(ctx context.Context) (context.Context, repository.CommitOrRollback, error)
+	CreateAccount(ctx context.Context, id uuid.UUID, accountType string) (*entity.Account, error)
+	UpdateAccountTier(ctx context.Context, userID uuid.UUID, tier string) (*entity.Account, error)
+	GetAccount(ctx context.Context, userID uuid.UUID) (*entity.Account, error)
+	GetAccountsByType(ctx context.Context, accountType string) ([]entity.Account, error)
+	GetDailyBalances(ctx context.Context, accountID uuid.UUID, from, to time.Time) ([]entity.DailyBalance, error)
+	SaveInterestPayout(ctx context.Context, payout entity.InterestPayout) error
+	CreateReview(ctx context.Context,
+		receiverID,
+		senderID uuid.UUID,
+		amount decimal.Decimal,
+		rule, reason string) (*entity.Review, error)
+	GetReview(ctx context.Context, reviewID uuid.UUID) (*entity.Review, error)
+	GetReviews(ctx context.Context, status string) ([]entity.Review, error)
+	DecideReview(ctx context.Context, reviewID uuid.UUID, status string, transactionID *uuid.UUID) (*entity.Review, error)
+	GetBlockedParties(ctx context.Context) ([]entity.BlockedParty, error)
+	BlockParty(ctx context.Context, partyID uuid.UUID, reason string) (*entity.BlockedParty, error)
+	UnblockParty(ctx context.Context, partyID uuid.UUID) error
+	SaveScreeningDecisions(ctx context.Context, decisions []entity.ScreeningDecision) error
+	AppendAudit(ctx context.Context, entry entity.AuditEntry) error
+}
+
+// RiskEvaluator decides whether the transfer may be executed.
+type RiskEvaluator interface {
+	Evaluate(ctx context.Context, senderID, receiverID uuid.UUID, amount decimal.Decimal) (risk.Verdict, error)
+}
+
+// FeeCalculator computes fees of operations by the account tier.
+type FeeCalculator interface {
+	Fee(operation, tier string, amount decimal.Decimal) decimal.Decimal
+	RevenueAccount() uuid.UUID
+}
+
+// InterestCalculator accrues interest by the account type.
+type InterestCalculator interface {
+	Accrue(accountType string, balances []entity.DailyBalance) decimal.Decimal
+	AccountTypes() []string
+	PayerAccount() uuid.UUID
+}
+
+// Screener checks parties against the local copy of the blocklist.
+type Screener interface {
+	Screen(partyID uuid.UUID) (entity.BlockedParty, bool)
+	Reload(ctx context.Context) error
+}
+
+// Publisher is notified about every committed transaction.
+type Publisher interface {
+	Publish(transaction entity.Transaction)
 }
 
 type Service struct {
 	userManager UserManager
+	publisher   Publisher
+	risk        RiskEvaluator
+	screener    Screener
+	fees        FeeCalculator
+	interest    InterestCalculator
 	timeout     time.Duration
 }
 
+type Option func(s *Service)
+
+// WithPublisher sets publisher of committed transactions.
+func WithPublisher(publisher Publisher) Option {
+	return func(s *Service) {
+		s.publisher = publisher
+	}
+}
+
+// WithRiskEvaluator makes transfers checked by risk rules before money moves.
+func WithRiskEvaluator(evaluator RiskEvaluator) Option {
+	return func(s *Service) {
+		s.risk = evaluator
+	}
+}
+
+// WithScreener makes parties of deposits and transfers screened against the blocklist.
+func WithScreener(screener Screener) Option {
+	return func(s *Service) {
+		s.screener = screener
+	}
+}
+
+// WithFees makes deposits and transfers charged with fees credited to the revenue account.
+func WithFees(fees FeeCalculator) Option {
+	return func(s *Service) {
+		s.fees = fees
+	}
+}
+
+// WithInterest makes interest paid to accounts earning it by PayInterest.
+func WithInterest(interest InterestCalculator) Option {
+	return func(s *Service) {
+		s.interest = interest
+	}
+}
+
 func New(timeout time.Duration,
-	userManager UserManager) *Service {
-	return &Service{
+	userManager UserManager,
+	opts ...Option) *Service {
+	srvc := &Service{
 		userManager: userManager,
 		timeout:     timeout,
 	}
+
+	for _, opt := range opts {
+		opt(srvc)
+	}
+
+	return srvc
 }
 
-func (s *Service) Deposit(ctx context.Context, userID uuid.UUID, amount decimal.Decimal) (*decimal.Decimal, error) {
+// CreateAccount opens the account of the type, checking one is opened if the type is empty.
+func (s *Service) CreateAccount(ctx context.Context, accountType string) (*entity.Account, error) {
+	return s.CreateAccountWithID(ctx, uuid.New(), accountType)
+}
+
+// CreateAccountWithID opens the account with the given id, which fixtures refer to.
+// ErrAccountExists is returned if the id is taken.
+func (s *Service) CreateAccountWithID(ctx context.Context,
+	id uuid.UUID,
+	accountType string) (*entity.Account, error) {
+	if accountType == "" {
+		accountType = entity.AccountChecking
+	}
+
+	ctx, span := tracing.Start(ctx, "Service.CreateAccount",
+		attribute.String("account.id", id.String()),
+		attribute.String("account.type", accountType))
+	defer span.End()
+
+	if !entity.ValidAccountType(accountType) {
+		err := fmt.Errorf("%w: %q", ErrAccountType, accountType)
+		tracing.RecordError(span, err)
+
+		return nil, err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
-	ctx, commitOrRollback, err := s.userManager.BeginTx(ctx)
+	var account *entity.Account
+
+	err := s.inTx(ctx, func(ctx context.Context) error {
+		var err error
+
+		account, err = s.userManager.CreateAccount(ctx, id, accountType)
+		if errors.Is(err, repository.ErrAccountExists) {
+			return ErrAccountExists
+		}
+
+		if err != nil {
+			return err
+		}
+
+		return s.record(ctx, audit.ActionAccountCreate, resourceAccount+account.ID.String(), nil, account)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to begin bd transaction: %w", err)
+		tracing.RecordError(span, err)
+
+		return nil, err
 	}
 
-	defer func() {
-		errTx := commitOrRollback(err)
-		if errTx != nil {
-			fmt.Printf("commit/rollback error: %v", errTx)
+	return account, nil
+}
+
+// UpdateAccountTier moves the account to the tier, which chooses the fee schedule of its operations.
+func (s *Service) UpdateAccountTier(ctx context.Context, userID uuid.UUID, tier string) (*entity.Account, error) {
+	ctx, span := tracing.Start(ctx, "Service.UpdateAccountTier",
+		attribute.String("account.id", userID.String()),
+		attribute.String("account.tier", tier))
+	defer span.End()
+
+	if !entity.ValidTier(tier) {
+		err := fmt.Errorf("%w: %q", ErrAccountTier, tier)
+		tracing.RecordError(span, err)
+
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var account *entity.Account
+
+	err := s.inTx(ctx, func(ctx context.Context) error {
+		before, err := s.userManager.GetAccount(ctx, userID)
+		if err != nil {
+			return err
 		}
-	}()
 
-	currentBalance, err := s.userManager.UpdateBalance(ctx, userID, amount)
+		account, err = s.userManager.UpdateAccountTier(ctx, userID, tier)
+		if err != nil {
+			return err
+		}
+
+		return s.record(ctx, audit.ActionAccountTier, resourceAccount+userID.String(),
+			tierState{Tier: before.Tier}, tierState{Tier: account.Tier})
+	})
 	if err != nil {
-		return nil, responseOnRepoError(err)
+		tracing.RecordError(span, err)
+
+		return nil, err
 	}
 
-	_, err = s.userManager.SaveTransaction(ctx, userID, userID, amount, operationDeposit)
+	return account, nil
+}
+
+func (s *Service) GetAccount(ctx context.Context, userID uuid.UUID) (*entity.Account, error) {
+	ctx, span := tracing.Start(ctx, "Service.GetAccount",
+		attribute.String("user.id", userID.String()))
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	account, err := s.userManager.GetAccount(ctx, userID)
 	if err != nil {
-		return nil, responseOnRepoError(err)
+		err = responseOnRepoError(err)
+		tracing.RecordError(span, err)
+
+		return nil, err
 	}
 
-	return currentBalance, nil
+	return account, nil
+}
+
+func (s *Service) Deposit(ctx context.Context, userID uuid.UUID, amount decimal.Decimal) (*entity.Deposit, error) {
+	ctx, span := tracing.Start(ctx, "Service.Deposit",
+		attribute.String("user.id", userID.String()),
+		attribute.String("amount", amount.String()))
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var (
+		currentBalance *decimal.Decimal
+		transaction    *entity.Transaction
+	)
+
+	err := s.screen(ctx, operationDeposit, userID)
+	if err != nil {
+		metrics.ObserveOperation(operationDeposit, operationOutcome(err), amount)
+		tracing.RecordError(span, err)
+
+		return nil, err
+	}
+
+	err = s.inTx(ctx, func(ctx context.Context) error {
+		var err error
+
+		currentBalance, err = s.userManager.UpdateBalance(ctx, userID, amount)
+		if err != nil {
+			return err
+		}
+
+		transaction, err = s.userManager.SaveTransaction(ctx, userID, userID, amount, operationDeposit)
+		if err != nil {
+			return err
+		}
+
+		transaction.Fee, err = s.chargeFee(ctx, operationDeposit, userID, transaction)
+		if err != nil {
+			return err
+		}
+
+		before := currentBalance.Sub(amount)
+
+		if transaction.Fee != nil {
+			*currentBalance = currentBalance.Sub(transaction.Fee.Amount)
+		}
+
+		return s.record(ctx, audit.ActionDeposit, resourceAccount+userID.String(),
+			balanceState{Balance: before},
+			depositState{Balance: *currentBalance, Transaction: transaction})
+	})
+
+	metrics.ObserveOperation(operationDeposit, operationOutcome(err), amount)
+
+	if err != nil {
+		tracing.RecordError(span, err)
+
+		return nil, err
+	}
+
+	s.observeFee(operationDeposit, transaction)
+	s.publish(transaction)
+
+	return &entity.Deposit{
+		Transaction: *transaction,
+		Balance:     *currentBalance,
+	}, nil
+}
+
+func (s *Service) GetTransaction(ctx context.Context, transactionID uuid.UUID) (*entity.Transaction, error) {
+	ctx, span := tracing.Start(ctx, "Service.GetTransaction",
+		attribute.String("transaction.id", transactionID.String()))
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	transaction, err := s.userManager.GetTransaction(ctx, transactionID)
+	if err != nil {
+		err = responseOnRepoError(err)
+		tracing.RecordError(span, err)
+
+		return nil, err
+	}
+
+	return transaction, nil
 }
 
 func (s *Service) GetTransactions(ctx context.Context,
 	userID uuid.UUID) ([]entity.Transaction, error) {
+	ctx, span := tracing.Start(ctx, "Service.GetTransactions",
+		attribute.String("user.id", userID.String()))
+	defer span.End()
+
 	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
 	transactions, err := s.userManager.GetTransactions(ctx, userID)
 	if err != nil {
-		return nil, responseOnRepoError(err)
+		err = responseOnRepoError(err)
+		tracing.RecordError(span, err)
+
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+// GetTransactionsAfter returns all transactions of the user committed after the one with afterID,
+// read page by page, so clients of transaction feeds can catch up after reconnect. Some transactions
+// created shortly before it may be returned again, as concurrent transactions commit out of order.
+func (s *Service) GetTransactionsAfter(ctx context.Context,
+	userID, afterID uuid.UUID) ([]entity.Transaction, error) {
+	ctx, span := tracing.Start(ctx, "Service.GetTransactionsAfter",
+		attribute.String("user.id", userID.String()),
+		attribute.String("after.id", afterID.String()))
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var transactions []entity.Transaction
+
+	// pages are read until the last one is empty, so no missed transaction is dropped
+	for pageAfterID := uuid.Nil; ; {
+		page, err := s.userManager.GetTransactionsAfter(ctx, userID, afterID, pageAfterID)
+		if err != nil {
+			err = responseOnRepoError(err)
+			tracing.RecordError(span, err)
+
+			return nil, err
+		}
+
+		if len(page) == 0 {
+			break
+		}
+
+		transactions = append(transactions, page...)
+		pageAfterID = page[len(page)-1].ID
 	}
 
 	return transactions, nil
@@ -93,52 +448,238 @@ func (s *Service) Transfer(ctx context.Context,
 	receiverID,
 	senderID uuid.UUID,
 	amount decimal.Decimal) (*entity.Transaction, error) {
+	ctx, span := tracing.Start(ctx, "Service.Transfer",
+		attribute.String("sender.id", senderID.String()),
+		attribute.String("receiver.id", receiverID.String()),
+		attribute.String("amount", amount.String()))
+	defer span.End()
+
 	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
-	ctx, commitOrRollback, err := s.userManager.BeginTx(ctx)
+	var (
+		transaction *entity.Transaction
+		review      *entity.Review
+	)
+
+	err := s.screen(ctx, operationTransfer, senderID, receiverID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to begin db transaction: %w", err)
+		metrics.ObserveOperation(operationTransfer, operationOutcome(err), amount)
+		tracing.RecordError(span, err)
+
+		return nil, err
 	}
 
-	defer func() {
-		errTx := commitOrRollback(err)
-		if errTx != nil {
-			fmt.Printf("commit/rollback error: %v", errTx)
+	err = s.inTx(ctx, func(ctx context.Context) error {
+		var err error
+
+		review, err = s.evaluateRisk(ctx, receiverID, senderID, amount)
+		if err != nil || review != nil {
+			return err
+		}
+
+		transaction, err = s.moveMoney(ctx, receiverID, senderID, amount)
+
+		return err
+	})
+	if err == nil && review != nil {
+		err = &HeldError{Review: review}
+	}
+
+	metrics.ObserveOperation(operationTransfer, operationOutcome(err), amount)
+
+	if err != nil {
+		tracing.RecordError(span, err)
+
+		return nil, err
+	}
+
+	s.observeFee(operationTransfer, transaction)
+	s.publish(transaction)
+
+	return transaction, nil
+}
+
+// evaluateRisk checks the transfer by risk rules. The held transfer is saved
+// for review and returned, the denied one results in ErrTransferDenied.
+func (s *Service) evaluateRisk(ctx context.Context,
+	receiverID, senderID uuid.UUID,
+	amount decimal.Decimal) (*entity.Review, error) {
+	if s.risk == nil {
+		return nil, nil //nolint:nilnil
+	}
+
+	verdict, err := s.risk.Evaluate(ctx, senderID, receiverID, amount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate transfer risk: %w", err)
+	}
+
+	log := logger.FromContext(ctx).With(
+		slog.String("rule", verdict.Rule),
+		slog.String("reason", verdict.Reason))
+
+	switch verdict.Decision {
+	case risk.DecisionDeny:
+		log.Warn("transfer denied by risk rules")
+
+		return nil, fmt.Errorf("%w: %s", ErrTransferDenied, verdict.Reason)
+	case risk.DecisionReview:
+		log.Warn("transfer held for review")
+
+		review, err := s.userManager.CreateReview(ctx, receiverID, senderID, amount, verdict.Rule, verdict.Reason)
+		if err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+
+		err = s.record(ctx, audit.ActionTransferHold, resourceReview+review.ID.String(), nil, review)
+		if err != nil {
+			return nil, err
 		}
-	}()
 
+		return review, nil
+	default:
+		return nil, nil //nolint:nilnil
+	}
+}
+
+// moveMoney updates balances of both users, saves the transfer, charges its fee and records it
+// in the audit log, it must be called in a db transaction.
+func (s *Service) moveMoney(ctx context.Context,
+	receiverID, senderID uuid.UUID,
+	amount decimal.Decimal) (*entity.Transaction, error) {
 	// sender balance update
-	_, err = s.userManager.UpdateBalance(
+	senderBalance, err := s.userManager.UpdateBalance(
 		ctx,
 		senderID,
 		decimal.Zero.Sub(amount))
 	if err != nil {
-		return nil, responseOnRepoError(err)
+		return nil, err
 	}
 	// receiver balance update
-	_, err = s.userManager.UpdateBalance(
+	receiverBalance, err := s.userManager.UpdateBalance(
 		ctx,
 		receiverID,
 		amount)
 	if err != nil {
-		return nil, responseOnRepoError(err)
+		return nil, err
 	}
 
 	transaction, err := s.userManager.SaveTransaction(ctx, receiverID, senderID, amount, operationTransfer)
 	if err != nil {
-		return nil, responseOnRepoError(err)
+		return nil, err
+	}
+
+	transaction.Fee, err = s.chargeFee(ctx, operationTransfer, senderID, transaction)
+	if err != nil {
+		return nil, err
+	}
+
+	before := transferState{
+		SenderBalance:   senderBalance.Add(amount),
+		ReceiverBalance: receiverBalance.Sub(amount),
+	}
+
+	if transaction.Fee != nil {
+		*senderBalance = senderBalance.Sub(transaction.Fee.Amount)
+	}
+
+	err = s.record(ctx, audit.ActionTransfer, resourceTransaction+transaction.ID.String(),
+		before,
+		transferState{
+			SenderBalance:   *senderBalance,
+			ReceiverBalance: *receiverBalance,
+			Transaction:     transaction,
+		})
+	if err != nil {
+		return nil, err
 	}
 
 	return transaction, nil
 }
 
+// inTx runs fn in a db transaction and retries the whole transaction
+// if it conflicts with a concurrent one, e.g. on deadlock between two opposite transfers.
+func (s *Service) inTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	for attempt := 1; ; attempt++ {
+		err := s.runTx(ctx, fn)
+		if err == nil {
+			return nil
+		}
+
+		if !errors.Is(err, repository.ErrTxConflict) || attempt == maxTxAttempts {
+			return responseOnRepoError(err)
+		}
+
+		metrics.TxRetries.Inc()
+
+		logger.FromContext(ctx).Warn("retrying conflicted db transaction", slog.Int("attempt", attempt))
+	}
+}
+
+func (s *Service) runTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	txCtx, commitOrRollback, err := s.userManager.BeginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin db transaction: %w", err)
+	}
+
+	txCtx, pending := withPendingAudit(txCtx)
+
+	err = fn(txCtx)
+	if err == nil {
+		// the audit log stays locked from the first append until the commit
+		err = pending.flush(txCtx, s)
+	}
+
+	if err != nil {
+		// commitOrRollback returns err itself if rollback succeeded
+		errTx := commitOrRollback(err)
+		if errTx != nil && !errors.Is(err, errTx) {
+			logger.FromContext(ctx).Error("failed to rollback db transaction", slog.Any("error", errTx))
+		}
+
+		return err
+	}
+
+	return commitOrRollback(nil)
+}
+
+func (s *Service) publish(transaction *entity.Transaction) {
+	if s.publisher != nil {
+		s.publisher.Publish(*transaction)
+	}
+}
+
+func operationOutcome(err error) string {
+	switch {
+	case err == nil:
+		return metrics.OutcomeSuccess
+	case errors.Is(err, ErrUserNotFound):
+		return metrics.OutcomeUserNotFound
+	case errors.Is(err, ErrNegativeBalance):
+		return metrics.OutcomeNegativeBalance
+	case errors.Is(err, ErrTransferDenied):
+		return metrics.OutcomeDenied
+	case errors.Is(err, ErrTransferHeld):
+		return metrics.OutcomeHeld
+	case errors.Is(err, ErrPartyBlocked):
+		return metrics.OutcomeBlocked
+	default:
+		return metrics.OutcomeError
+	}
+}
+
 func responseOnRepoError(err error) error {
 	switch {
 	case errors.Is(err, repository.ErrNegativeBalance):
 		return ErrNegativeBalance
 	case errors.Is(err, repository.ErrUserNotFound):
 		return ErrUserNotFound
+	case errors.Is(err, repository.ErrNotFound):
+		return ErrNotFound
+	case errors.Is(err, repository.ErrAlreadyPaid):
+		return ErrInterestPaid
+	case errors.Is(err, ErrTransferDenied), errors.Is(err, ErrReviewDecided):
+		return err
 	default:
 		return fmt.Errorf("repository fail: %w", err)
 	}
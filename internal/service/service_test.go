@@ -4,10 +4,14 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"os"
 	"testing"
 	"time"
 
+	"github.com/aspirin100/finapi/internal/entity"
 	"github.com/aspirin100/finapi/internal/repository"
+	"github.com/aspirin100/finapi/internal/repository/memory"
+	"github.com/aspirin100/finapi/internal/seed"
 	"github.com/aspirin100/finapi/internal/service"
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
@@ -24,6 +28,24 @@ var UserIDs []string = []string{
 	"4178f61f-2ff9-4ab5-afa5-f30dc16e6ad9",
 }
 
+// TestMain loads the test users of the dev fixture, tests needing postgres fail on their own
+// if it's unavailable.
+func TestMain(m *testing.M) {
+	ctx := context.Background()
+
+	repo, err := repository.NewConnection(ctx, PostgresDSN)
+	if err == nil {
+		_, err = seed.LoadFile(ctx, service.New(DefaultTimeout, repo), seed.EnvTest, "../../configs/fixtures/dev.yml")
+		repo.Close()
+	}
+
+	if err != nil {
+		log.Println("failed to seed test users:", err)
+	}
+
+	os.Exit(m.Run())
+}
+
 func initService() (*service.Service, error) {
 	repo, err := repository.NewConnection(context.Background(), PostgresDSN)
 	if err != nil {
@@ -39,10 +61,7 @@ func TestDeposit(t *testing.T) {
 	ctx := context.Background()
 
 	srvc, err := initService()
-	if err != nil {
-		log.Print(err)
-		t.Fail()
-	}
+	require.NoError(t, err)
 
 	type Params struct {
 		UserID uuid.UUID
@@ -88,10 +107,7 @@ func TestTransfer(t *testing.T) {
 	ctx := context.Background()
 
 	srvc, err := initService()
-	if err != nil {
-		log.Print(err)
-		t.Fail()
-	}
+	require.NoError(t, err)
 
 	type Params struct {
 		ReceiverID uuid.UUID
@@ -151,10 +167,7 @@ func TestGetTransactions(t *testing.T) {
 	ctx := context.Background()
 
 	srvc, err := initService()
-	if err != nil {
-		log.Print(err)
-		t.Fail()
-	}
+	require.NoError(t, err)
 
 	cases := []struct {
 		Name        string
@@ -177,3 +190,26 @@ func TestGetTransactions(t *testing.T) {
 		})
 	}
 }
+
+func TestGetTransactionsAfter(t *testing.T) {
+	ctx := context.Background()
+
+	srvc := service.New(DefaultTimeout, memory.New())
+
+	account, err := srvc.CreateAccount(ctx, entity.AccountChecking)
+	require.NoError(t, err)
+
+	seen, err := srvc.Deposit(ctx, account.ID, decimal.NewFromInt(1))
+	require.NoError(t, err)
+
+	missed := repository.TransactionsAfterLimit*2 + 1
+
+	for range missed {
+		_, err = srvc.Deposit(ctx, account.ID, decimal.NewFromInt(1))
+		require.NoError(t, err)
+	}
+
+	transactions, err := srvc.GetTransactionsAfter(ctx, account.ID, seen.Transaction.ID)
+	require.NoError(t, err)
+	require.Len(t, transactions, missed, "transactions past the first page aren't dropped")
+}
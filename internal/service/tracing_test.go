@@ -0,0 +1,173 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+
+	"github.com/aspirin100/finapi/internal/entity"
+	"github.com/aspirin100/finapi/internal/repository"
+	"github.com/aspirin100/finapi/internal/service"
+	"github.com/aspirin100/finapi/internal/tracing"
+)
+
+// stubUserManager keeps no state and fails balance updates of uuid.Nil user.
+type stubUserManager struct{}
+
+func (stubUserManager) UpdateBalance(_ context.Context,
+	userID uuid.UUID,
+	amount decimal.Decimal) (*decimal.Decimal, error) {
+	if userID == uuid.Nil {
+		return nil, repository.ErrUserNotFound
+	}
+
+	return &amount, nil
+}
+
+func (stubUserManager) GetTransactions(_ context.Context, _ uuid.UUID) ([]entity.Transaction, error) {
+	return nil, nil
+}
+
+func (stubUserManager) GetTransaction(_ context.Context, _ uuid.UUID) (*entity.Transaction, error) {
+	return nil, repository.ErrNotFound
+}
+
+func (stubUserManager) GetTransactionsAfter(_ context.Context, _, _, _ uuid.UUID) ([]entity.Transaction, error) {
+	return nil, nil
+}
+
+func (stubUserManager) SaveTransaction(_ context.Context,
+	receiverID, senderID uuid.UUID,
+	amount decimal.Decimal,
+	operation string) (*entity.Transaction, error) {
+	return &entity.Transaction{
+		ID:         uuid.New(),
+		ReceiverID: receiverID,
+		SenderID:   senderID,
+		Amount:     amount,
+		Operation:  operation,
+	}, nil
+}
+
+func (stubUserManager) SaveLinkedTransaction(_ context.Context,
+	parentID, receiverID, senderID uuid.UUID,
+	amount decimal.Decimal,
+	operation string) (*entity.Transaction, error) {
+	return &entity.Transaction{
+		ID:         uuid.New(),
+		ReceiverID: receiverID,
+		SenderID:   senderID,
+		Amount:     amount,
+		Operation:  operation,
+		ParentID:   &parentID,
+	}, nil
+}
+
+func (stubUserManager) CreateAccount(_ context.Context, id uuid.UUID, accountType string) (*entity.Account, error) {
+	return &entity.Account{ID: id, Type: accountType}, nil
+}
+
+func (stubUserManager) UpdateAccountTier(_ context.Context, userID uuid.UUID, tier string) (*entity.Account, error) {
+	return &entity.Account{ID: userID, Tier: tier}, nil
+}
+
+func (stubUserManager) GetAccountsByType(_ context.Context, _ string) ([]entity.Account, error) {
+	return nil, nil
+}
+
+func (stubUserManager) GetDailyBalances(_ context.Context,
+	_ uuid.UUID,
+	_, _ time.Time) ([]entity.DailyBalance, error) {
+	return nil, nil
+}
+
+func (stubUserManager) SaveInterestPayout(_ context.Context, _ entity.InterestPayout) error {
+	return nil
+}
+
+func (stubUserManager) GetAccount(_ context.Context, userID uuid.UUID) (*entity.Account, error) {
+	return &entity.Account{ID: userID, Tier: entity.TierStandard}, nil
+}
+
+func (stubUserManager) CreateReview(_ context.Context,
+	receiverID, senderID uuid.UUID,
+	amount decimal.Decimal,
+	rule, reason string) (*entity.Review, error) {
+	return &entity.Review{
+		ID:         uuid.New(),
+		SenderID:   senderID,
+		ReceiverID: receiverID,
+		Amount:     amount,
+		Rule:       rule,
+		Reason:     reason,
+		Status:     entity.ReviewPending,
+	}, nil
+}
+
+func (stubUserManager) GetReview(_ context.Context, _ uuid.UUID) (*entity.Review, error) {
+	return nil, repository.ErrNotFound
+}
+
+func (stubUserManager) GetReviews(_ context.Context, _ string) ([]entity.Review, error) {
+	return nil, nil
+}
+
+func (stubUserManager) DecideReview(_ context.Context,
+	_ uuid.UUID,
+	_ string,
+	_ *uuid.UUID) (*entity.Review, error) {
+	return nil, repository.ErrNotFound
+}
+
+func (stubUserManager) GetBlockedParties(_ context.Context) ([]entity.BlockedParty, error) {
+	return nil, nil
+}
+
+func (stubUserManager) BlockParty(_ context.Context, partyID uuid.UUID, reason string) (*entity.BlockedParty, error) {
+	return &entity.BlockedParty{ID: partyID, Reason: reason}, nil
+}
+
+func (stubUserManager) UnblockParty(_ context.Context, _ uuid.UUID) error {
+	return nil
+}
+
+func (stubUserManager) SaveScreeningDecisions(_ context.Context, _ []entity.ScreeningDecision) error {
+	return nil
+}
+
+func (stubUserManager) AppendAudit(_ context.Context, _ entity.AuditEntry) error {
+	return nil
+}
+
+func (stubUserManager) BeginTx(ctx context.Context) (context.Context, repository.CommitOrRollback, error) {
+	return ctx, func(err error) error { return err }, nil
+}
+
+func TestServiceSpans(t *testing.T) {
+	provider, err := tracing.Setup(tracing.ExporterMemory, nil)
+	require.NoError(t, err)
+
+	defer provider.Shutdown(context.Background()) //nolint:errcheck
+
+	srvc := service.New(DefaultTimeout, stubUserManager{})
+
+	_, err = srvc.Transfer(context.Background(), uuid.New(), uuid.New(), decimal.NewFromInt(1))
+	require.NoError(t, err)
+
+	_, err = srvc.Deposit(context.Background(), uuid.Nil, decimal.NewFromInt(1))
+	require.ErrorIs(t, err, service.ErrUserNotFound)
+
+	spans := provider.Memory.GetSpans()
+	require.Len(t, spans, 2)
+
+	require.Equal(t, "Service.Transfer", spans[0].Name)
+	require.Equal(t, codes.Unset, spans[0].Status.Code)
+
+	require.Equal(t, "Service.Deposit", spans[1].Name)
+	require.Equal(t, codes.Error, spans[1].Status.Code)
+}
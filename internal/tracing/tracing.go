@@ -0,0 +1,105 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterMemory = "memory"
+)
+
+const (
+	ServiceName    = "finapi"
+	instrumentName = "github.com/aspirin100/finapi"
+)
+
+var ErrUnknownExporter = errors.New("unknown trace exporter")
+
+// Provider wraps sdk tracer provider installed as the global one.
+type Provider struct {
+	*sdktrace.TracerProvider
+
+	// Memory keeps finished spans if the memory exporter is used.
+	Memory *tracetest.InMemoryExporter
+}
+
+// Setup creates tracer provider with the given exporter and installs it globally
+// together with W3C trace context propagator. Stdout exporter writes spans to w.
+func Setup(exporter string, w io.Writer) (*Provider, error) {
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(ServiceName))),
+	}
+
+	var memory *tracetest.InMemoryExporter
+
+	switch exporter {
+	case ExporterNone:
+	case ExporterStdout:
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(w))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create stdout trace exporter: %w", err)
+		}
+
+		opts = append(opts, sdktrace.WithBatcher(exp))
+	case ExporterMemory:
+		memory = tracetest.NewInMemoryExporter()
+
+		opts = append(opts, sdktrace.WithSyncer(memory))
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownExporter, exporter)
+	}
+
+	provider := sdktrace.NewTracerProvider(opts...)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return &Provider{
+		TracerProvider: provider,
+		Memory:         memory,
+	}, nil
+}
+
+// Tracer returns finapi tracer of the global provider.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentName)
+}
+
+// Start starts a span named name as a child of the span in ctx.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// RecordError records err in the span and marks it as failed.
+func RecordError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// End records err, if any, and ends the span.
+func End(span trace.Span, err error) {
+	if err != nil {
+		RecordError(span, err)
+	}
+
+	span.End()
+}
@@ -0,0 +1,61 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+
+	"github.com/aspirin100/finapi/internal/tracing"
+)
+
+func TestSetup(t *testing.T) {
+	cases := []struct {
+		Name        string
+		Exporter    string
+		ExpectedErr error
+	}{
+		{Name: "none", Exporter: tracing.ExporterNone},
+		{Name: "stdout", Exporter: tracing.ExporterStdout},
+		{Name: "memory", Exporter: tracing.ExporterMemory},
+		{Name: "unknown", Exporter: "jaeger", ExpectedErr: tracing.ErrUnknownExporter},
+	}
+
+	for _, tcase := range cases {
+		t.Run(tcase.Name, func(t *testing.T) {
+			provider, err := tracing.Setup(tcase.Exporter, &bytes.Buffer{})
+			require.ErrorIs(t, err, tcase.ExpectedErr)
+
+			if err == nil {
+				require.NoError(t, provider.Shutdown(context.Background()))
+			}
+		})
+	}
+}
+
+func TestSpans(t *testing.T) {
+	provider, err := tracing.Setup(tracing.ExporterMemory, nil)
+	require.NoError(t, err)
+
+	defer provider.Shutdown(context.Background()) //nolint:errcheck
+
+	ctx, parent := tracing.Start(context.Background(), "parent")
+
+	_, child := tracing.Start(ctx, "child")
+	tracing.End(child, errors.New("boom"))
+
+	tracing.End(parent, nil)
+
+	spans := provider.Memory.GetSpans()
+	require.Len(t, spans, 2)
+
+	require.Equal(t, "child", spans[0].Name)
+	require.Equal(t, codes.Error, spans[0].Status.Code)
+	require.Equal(t, spans[1].SpanContext.SpanID(), spans[0].Parent.SpanID())
+
+	require.Equal(t, "parent", spans[1].Name)
+	require.Equal(t, codes.Unset, spans[1].Status.Code)
+}